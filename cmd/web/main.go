@@ -7,6 +7,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
@@ -14,13 +17,35 @@ import (
 	"github.com/cxt314/drvc-go/internal/driver"
 	"github.com/cxt314/drvc-go/internal/handlers"
 	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/mailer"
 	"github.com/cxt314/drvc-go/internal/models"
 	"github.com/cxt314/drvc-go/internal/render"
+	"github.com/cxt314/drvc-go/internal/repository"
+	"github.com/cxt314/drvc-go/internal/storage"
 	"github.com/pressly/goose/v3"
 )
 
 const portNumber = ":8080"
 
+// maxRequestSize limits the size of request bodies that aren't uploads
+const maxRequestSize = 1 << 20
+
+// uploadSizes are the larger request size limits of the upload routes, checked before the CSRF middleware reads the body
+var uploadSizes = []struct {
+	path *regexp.Regexp
+	size int64
+}{
+	// several log sheet scans at once
+	{regexp.MustCompile(`^/mileage-logs/\d+/attachments$`), 5*storage.MaxSize + 1<<20},
+	// one compliance document plus its form fields
+	{regexp.MustCompile(`^/vehicles/\d+/compliance$`), storage.MaxSize + 1<<20},
+	// a CSV of trips of up to 1 MB, which is posted again url encoded to confirm the import
+	{regexp.MustCompile(`^/mileage-logs/\d+/import(/confirm)?$`), 4 << 20},
+}
+
+// defaultTrashRetentionDays is how many days deleted items stay in the trash if TRASH_RETENTION_DAYS isn't set
+const defaultTrashRetentionDays = 30
+
 var app config.AppConfig
 var session *scs.SessionManager
 var infoLog *log.Logger
@@ -48,6 +73,12 @@ func main() {
         panic(err)
     }
 
+	// permanently delete old items from the trash once a day
+	go purgeTrash(handlers.Repo.DB, handlers.Repo.Store, app.TrashRetention)
+
+	// count expiring vehicle documents once a day, emailing a digest every Monday
+	go checkCompliance(handlers.Repo, os.Getenv("COMPLIANCE_DIGEST_TO"))
+
 	// start application
 	fmt.Printf("Starting application on port %s\n", portNumber)
 
@@ -67,9 +98,14 @@ func run() (*driver.DB, error) {
 	gob.Register(models.Member{})
 	gob.Register(models.MemberAlias{})
 	gob.Register(models.Vehicle{})
+	gob.Register(models.RateSchedule{})
+	gob.Register(models.FuelPurchase{})
+	gob.Register(models.LongDistanceRate{})
 	gob.Register(models.MileageLog{})
 	gob.Register(models.Trip{})
 	gob.Register(models.Rider{})
+	gob.Register(models.BillingPeriod{})
+	gob.Register(models.LedgerEntry{})
 
 	// read environment variables
 	inProduction := os.Getenv("IS_PRODUCTION")
@@ -98,6 +134,9 @@ func run() (*driver.DB, error) {
 
 	app.Session = session
 
+	app.TrashRetention = trashRetention()
+	app.ComplianceWarningDays = complianceWarningDays()
+
 	// connect to database
 	log.Println("Connecting to database...")
 	db, err := driver.ConnectSQL(dbURL)
@@ -122,10 +161,119 @@ func run() (*driver.DB, error) {
 		app.UseCache = true
 	}
 
-	repo := handlers.NewRepo(&app, db)
+	repo := handlers.NewRepo(&app, db, newMailer(), newStore())
 	handlers.NewHandlers(repo)
 	render.NewRenderer(&app)
 	helpers.NewHelpers(&app)
 
 	return db, nil
 }
+
+// newMailer returns an SMTP mailer if SMTP_HOST is set. Otherwise messages are written to
+// MAIL_DIR, or ./mail if that isn't set, so nothing is sent during development
+func newMailer() mailer.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	host := os.Getenv("SMTP_HOST")
+
+	if host == "" {
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+
+		log.Println("SMTP_HOST not set, writing email to", dir)
+		return mailer.NewFileMailer(dir, from)
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		port = 587
+	}
+
+	return mailer.NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+}
+
+// newStore returns the store for uploaded mileage log scans, kept in ATTACHMENT_DIR or ./attachments if that isn't set
+func newStore() storage.Store {
+	dir := os.Getenv("ATTACHMENT_DIR")
+	if dir == "" {
+		dir = "attachments"
+	}
+
+	return storage.NewLocalStore(dir)
+}
+
+// trashRetention returns how long deleted items are kept in the trash, set in days by TRASH_RETENTION_DAYS
+func trashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultTrashRetentionDays
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// complianceWarningDays returns how many days before vehicle documents expire they are warned about,
+// set by COMPLIANCE_WARNING_DAYS
+func complianceWarningDays() int {
+	days, err := strconv.Atoi(os.Getenv("COMPLIANCE_WARNING_DAYS"))
+	if err != nil || days <= 0 {
+		days = models.DefaultComplianceWarningDays
+	}
+
+	return days
+}
+
+// checkCompliance recounts the vehicle documents that have expired or are expiring soon for the navbar warning,
+// then does it again every day. Once a week, on Monday or the first check after it, the list is emailed to the
+// comma separated addresses in digestTo if there is anything on it. The last send is kept in the database so
+// restarting the app doesn't send it again
+func checkCompliance(repo *handlers.Repository, digestTo string) {
+	var to []string
+	for _, address := range strings.Split(digestTo, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			to = append(to, address)
+		}
+	}
+
+	for {
+		alerts, err := repo.RefreshComplianceAlerts()
+		if err != nil {
+			app.ErrorLog.Println("counting compliance alerts:", err)
+		} else if len(alerts) > 0 && len(to) > 0 {
+			lastSent, err := repo.DB.GetLastComplianceDigestTime()
+			if err != nil {
+				app.ErrorLog.Println("getting last compliance digest:", err)
+			} else if models.ComplianceDigestDue(lastSent, time.Now()) {
+				err = repo.SendComplianceDigest(to, app.ComplianceWarningDays)
+				if err != nil {
+					app.ErrorLog.Println("sending compliance digest:", err)
+				}
+			}
+		}
+
+		time.Sleep(24 * time.Hour)
+	}
+}
+
+// purgeTrash permanently deletes items that have been in the trash longer than the retention period,
+// along with the stored files of their attachments, then does it again every day
+func purgeTrash(db repository.DatabaseRepo, store storage.Store, retention time.Duration) {
+	for {
+		result, err := db.PurgeTrash(time.Now().Add(-retention))
+		for _, key := range result.UnusedAttachmentKeys {
+			if err := store.Delete(key); err != nil {
+				app.ErrorLog.Println("deleting purged attachment:", err)
+			}
+		}
+
+		if err != nil {
+			app.ErrorLog.Println("purging trash:", err)
+		} else if result.Total() > 0 || result.Skipped > 0 {
+			app.InfoLog.Printf("purged %d trips, %d mileage logs, %d members and %d vehicles from the trash, %d still referenced by billing records\n",
+				result.Trips, result.MileageLogs, result.Members, result.Vehicles, result.Skipped)
+		}
+
+		time.Sleep(24 * time.Hour)
+	}
+}
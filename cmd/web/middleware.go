@@ -1,12 +1,41 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/cxt314/drvc-go/internal/helpers"
 	"github.com/justinas/nosurf"
 )
 
+// LimitRequestSize limits the size of request bodies to maxRequestSize, or the route's limit in uploadSizes.
+// It has to run before NoSurf, which reads the whole form to check the CSRF token. Requests that say they are
+// larger are refused up front with a message, a body that is larger than it said fails to parse
+func LimitRequestSize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := requestSizeLimit(r.URL.Path)
+
+		if r.ContentLength > limit {
+			http.Error(w, fmt.Sprintf("Uploads are limited to %d MB at a time", limit>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestSizeLimit returns the largest request body accepted for path
+func requestSizeLimit(path string) int64 {
+	for _, u := range uploadSizes {
+		if u.path.MatchString(path) {
+			return u.size
+		}
+	}
+
+	return maxRequestSize
+}
+
 // NoSurf adds CSRF protection to all POST requests
 func NoSurf(next http.Handler) http.Handler {
 	csrfHandler := nosurf.New(next)
@@ -2,6 +2,8 @@ package main
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -32,3 +34,34 @@ func TestSessionLoad(t *testing.T) {
 	}
 
 }
+
+func TestLimitRequestSize(t *testing.T) {
+	var myH myHandler
+
+	h := LimitRequestSize(&myH)
+
+	tests := []struct {
+		path   string
+		size   int64
+		status int
+	}{
+		{"/members/1", 1 << 10, http.StatusOK},
+		{"/members/1", 2 << 20, http.StatusRequestEntityTooLarge},
+		{"/vehicles/1/compliance", 8 << 20, http.StatusOK},
+		{"/vehicles/1/compliance", 12 << 20, http.StatusRequestEntityTooLarge},
+		{"/mileage-logs/1/attachments", 40 << 20, http.StatusOK},
+		{"/mileage-logs/1/attachments", 60 << 20, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(""))
+		r.ContentLength = tt.size
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s of %d bytes: expected status %d but got %d", tt.path, tt.size, tt.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rate_schedules (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    billing_type VARCHAR(255) NOT NULL,
+    base_per_mile INTEGER DEFAULT 0 NOT NULL,
+    secondary_per_mile INTEGER DEFAULT 0 NOT NULL,
+    minimum_fee INTEGER DEFAULT 0 NOT NULL,
+    valid_from DATE NOT NULL,
+    valid_to DATE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX rate_schedules_vehicle_valid_from_idx ON rate_schedules (vehicle_id, valid_from);
+
+-- Seed one open-ended schedule per vehicle from its current rates so that
+-- existing mileage logs keep billing exactly as they do today
+INSERT INTO rate_schedules (vehicle_id, billing_type, base_per_mile, secondary_per_mile, minimum_fee,
+    valid_from, valid_to, created_at, updated_at)
+SELECT id, billing_type, COALESCE(base_per_mile, 0), COALESCE(secondary_per_mile, 0), COALESCE(minimum_fee, 0),
+    '1900-01-01', NULL, NOW(), NOW()
+FROM vehicles;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX rate_schedules_vehicle_valid_from_idx;
+DROP TABLE rate_schedules;
+-- +goose StatementEnd
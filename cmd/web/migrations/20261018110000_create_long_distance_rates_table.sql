@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- billing_type is empty for co-op wide rates, otherwise the rate only applies
+-- to vehicles billed with that billing type
+CREATE TABLE long_distance_rates (
+    id SERIAL PRIMARY KEY,
+    billing_type VARCHAR(255) DEFAULT '' NOT NULL,
+    single_day_rate INTEGER NOT NULL,
+    multi_day_rate INTEGER NOT NULL,
+    valid_from DATE NOT NULL,
+    valid_to DATE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX long_distance_rates_billing_type_valid_from_idx ON long_distance_rates (billing_type, valid_from);
+
+-- Seed the co-op wide rates that were previously hard-coded ($85 single day, $50 per day multi-day)
+INSERT INTO long_distance_rates (billing_type, single_day_rate, multi_day_rate, valid_from, valid_to, created_at, updated_at)
+VALUES ('', 8500, 5000, '1900-01-01', NULL, NOW(), NOW());
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX long_distance_rates_billing_type_valid_from_idx;
+DROP TABLE long_distance_rates;
+-- +goose StatementEnd
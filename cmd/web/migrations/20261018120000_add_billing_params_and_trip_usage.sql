@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE vehicles ADD COLUMN billing_params JSONB DEFAULT '{}' NOT NULL;
+ALTER TABLE rate_schedules ADD COLUMN billing_params JSONB DEFAULT '{}' NOT NULL;
+ALTER TABLE trips ADD COLUMN hours NUMERIC(6,2) DEFAULT 0 NOT NULL;
+ALTER TABLE trips ADD COLUMN days INTEGER DEFAULT 0 NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE trips DROP COLUMN days;
+ALTER TABLE trips DROP COLUMN hours;
+ALTER TABLE rate_schedules DROP COLUMN billing_params;
+ALTER TABLE vehicles DROP COLUMN billing_params;
+-- +goose StatementEnd
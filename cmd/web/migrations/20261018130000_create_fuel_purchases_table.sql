@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE fuel_purchases (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    member_id INTEGER NOT NULL,
+    purchase_date DATE NOT NULL,
+    quantity NUMERIC(8,3) DEFAULT 0 NOT NULL,
+    unit VARCHAR(10) NOT NULL,
+    amount INTEGER DEFAULT 0 NOT NULL,
+    notes VARCHAR(255) DEFAULT '' NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles (id) ON DELETE CASCADE,
+    FOREIGN KEY (member_id) REFERENCES members (id) ON DELETE CASCADE
+);
+
+CREATE INDEX fuel_purchases_vehicle_date_idx ON fuel_purchases (vehicle_id, purchase_date);
+
+ALTER TABLE vehicles ADD COLUMN fuel_surcharge_per_mile INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE rate_schedules ADD COLUMN fuel_surcharge_per_mile INTEGER DEFAULT 0 NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rate_schedules DROP COLUMN fuel_surcharge_per_mile;
+ALTER TABLE vehicles DROP COLUMN fuel_surcharge_per_mile;
+DROP INDEX fuel_purchases_vehicle_date_idx;
+DROP TABLE fuel_purchases;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE riders ADD COLUMN share_weight NUMERIC(4,2) DEFAULT 1 NOT NULL;
+ALTER TABLE riders ADD COLUMN is_exempt BOOLEAN DEFAULT false NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE riders DROP COLUMN is_exempt;
+ALTER TABLE riders DROP COLUMN share_weight;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE billing_periods (
+    id SERIAL PRIMARY KEY,
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    status VARCHAR(20) DEFAULT 'open' NOT NULL,
+    finalized_at TIMESTAMP,
+    exported_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (year, month)
+);
+
+CREATE TABLE billing_period_charges (
+    id SERIAL PRIMARY KEY,
+    billing_period_id INTEGER NOT NULL,
+    member_id INTEGER NOT NULL,
+    vehicle_id INTEGER NOT NULL,
+    mileage_log_id INTEGER NOT NULL,
+    regular_trips_cost INTEGER DEFAULT 0 NOT NULL,
+    long_distance_trips_cost INTEGER DEFAULT 0 NOT NULL,
+    fuel_surcharge_cost INTEGER DEFAULT 0 NOT NULL,
+    fuel_credit INTEGER DEFAULT 0 NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (billing_period_id) REFERENCES billing_periods (id) ON DELETE CASCADE,
+    FOREIGN KEY (member_id) REFERENCES members (id) ON DELETE CASCADE,
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX billing_period_charges_period_idx ON billing_period_charges (billing_period_id);
+
+CREATE TABLE billing_period_events (
+    id SERIAL PRIMARY KEY,
+    billing_period_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    reason VARCHAR(255) DEFAULT '' NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (billing_period_id) REFERENCES billing_periods (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX billing_period_events_period_idx ON billing_period_events (billing_period_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX billing_period_events_period_idx;
+DROP TABLE billing_period_events;
+DROP INDEX billing_period_charges_period_idx;
+DROP TABLE billing_period_charges;
+DROP TABLE billing_periods;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE SEQUENCE qbo_invoice_no_seq START 10000;
+
+CREATE TABLE qbo_invoices (
+    id SERIAL PRIMARY KEY,
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    member_id INTEGER NOT NULL,
+    invoice_no INTEGER DEFAULT nextval('qbo_invoice_no_seq') NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (year, month, member_id),
+    FOREIGN KEY (member_id) REFERENCES members (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE qbo_invoices;
+DROP SEQUENCE qbo_invoice_no_seq;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    member_id INTEGER NOT NULL,
+    entry_date DATE NOT NULL,
+    entry_type VARCHAR(20) NOT NULL,
+    amount INTEGER DEFAULT 0 NOT NULL,
+    description VARCHAR(255) DEFAULT '' NOT NULL,
+    period_year INTEGER DEFAULT 0 NOT NULL,
+    period_month INTEGER DEFAULT 0 NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (member_id) REFERENCES members (id) ON DELETE CASCADE
+);
+
+CREATE INDEX ledger_entries_member_date_idx ON ledger_entries (member_id, entry_date);
+CREATE INDEX ledger_entries_period_idx ON ledger_entries (period_year, period_month);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX ledger_entries_period_idx;
+DROP INDEX ledger_entries_member_date_idx;
+DROP TABLE ledger_entries;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE statement_sends (
+    id SERIAL PRIMARY KEY,
+    year INTEGER NOT NULL,
+    month INTEGER NOT NULL,
+    member_id INTEGER NOT NULL,
+    email VARCHAR(255) DEFAULT '' NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT DEFAULT '' NOT NULL,
+    attempts INTEGER DEFAULT 0 NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (year, month, member_id),
+    FOREIGN KEY (member_id) REFERENCES members (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE statement_sends;
+-- +goose StatementEnd
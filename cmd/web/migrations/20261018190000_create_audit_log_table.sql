@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_log (
+    id SERIAL PRIMARY KEY,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    mileage_log_id INTEGER DEFAULT 0 NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    user_id INTEGER,
+    before_data TEXT DEFAULT '' NOT NULL,
+    after_data TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_mileage_log_id_idx ON audit_log (mileage_log_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX audit_log_mileage_log_id_idx;
+DROP INDEX audit_log_entity_idx;
+DROP TABLE audit_log;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE trips ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE mileage_logs ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE members ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE vehicles ADD COLUMN deleted_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE vehicles DROP COLUMN deleted_at;
+ALTER TABLE members DROP COLUMN deleted_at;
+ALTER TABLE mileage_logs DROP COLUMN deleted_at;
+ALTER TABLE trips DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE mileage_logs ADD COLUMN status VARCHAR(20) DEFAULT 'draft' NOT NULL;
+ALTER TABLE mileage_logs ADD COLUMN submitted_by INTEGER REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE mileage_logs ADD COLUMN submitted_at TIMESTAMP;
+ALTER TABLE mileage_logs ADD COLUMN approved_by INTEGER REFERENCES users (id) ON DELETE SET NULL;
+ALTER TABLE mileage_logs ADD COLUMN approved_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE mileage_logs DROP COLUMN approved_at;
+ALTER TABLE mileage_logs DROP COLUMN approved_by;
+ALTER TABLE mileage_logs DROP COLUMN submitted_at;
+ALTER TABLE mileage_logs DROP COLUMN submitted_by;
+ALTER TABLE mileage_logs DROP COLUMN status;
+-- +goose StatementEnd
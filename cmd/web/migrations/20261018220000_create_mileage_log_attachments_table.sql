@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE mileage_log_attachments (
+    id SERIAL PRIMARY KEY,
+    mileage_log_id INTEGER NOT NULL,
+    storage_key VARCHAR(64) NOT NULL,
+    filename VARCHAR(255) DEFAULT '' NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size INTEGER NOT NULL,
+    uploaded_by INTEGER,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (mileage_log_id) REFERENCES mileage_logs (id) ON DELETE CASCADE,
+    FOREIGN KEY (uploaded_by) REFERENCES users (id) ON DELETE SET NULL
+);
+CREATE INDEX mileage_log_attachments_mileage_log_id_idx ON mileage_log_attachments (mileage_log_id);
+CREATE INDEX mileage_log_attachments_storage_key_idx ON mileage_log_attachments (storage_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE mileage_log_attachments;
+-- +goose StatementEnd
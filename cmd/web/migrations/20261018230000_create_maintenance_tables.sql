@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE service_records (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    service_date DATE NOT NULL,
+    odometer INTEGER DEFAULT 0 NOT NULL,
+    service_type VARCHAR(50) NOT NULL,
+    cost INTEGER DEFAULT 0 NOT NULL,
+    vendor VARCHAR(255) DEFAULT '' NOT NULL,
+    notes TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles (id) ON DELETE CASCADE
+);
+CREATE INDEX service_records_vehicle_id_idx ON service_records (vehicle_id);
+
+CREATE TABLE maintenance_schedules (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    service_type VARCHAR(50) NOT NULL,
+    interval_miles INTEGER DEFAULT 0 NOT NULL,
+    interval_months INTEGER DEFAULT 0 NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (vehicle_id, service_type),
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE maintenance_schedules;
+DROP TABLE service_records;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE vehicle_expenses (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    expense_date DATE NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    amount INTEGER DEFAULT 0 NOT NULL,
+    coverage_months INTEGER DEFAULT 1 NOT NULL,
+    notes VARCHAR(255) DEFAULT '' NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles (id) ON DELETE CASCADE
+);
+CREATE INDEX vehicle_expenses_vehicle_id_idx ON vehicle_expenses (vehicle_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE vehicle_expenses;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE compliance_documents (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER NOT NULL,
+    document_type VARCHAR(50) NOT NULL,
+    reference VARCHAR(255) DEFAULT '' NOT NULL,
+    issued_date DATE,
+    expires_date DATE NOT NULL,
+    storage_key VARCHAR(64) DEFAULT '' NOT NULL,
+    filename VARCHAR(255) DEFAULT '' NOT NULL,
+    content_type VARCHAR(100) DEFAULT '' NOT NULL,
+    size INTEGER DEFAULT 0 NOT NULL,
+    notes VARCHAR(255) DEFAULT '' NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (vehicle_id) REFERENCES vehicles (id) ON DELETE CASCADE
+);
+CREATE INDEX compliance_documents_vehicle_id_idx ON compliance_documents (vehicle_id);
+CREATE INDEX compliance_documents_storage_key_idx ON compliance_documents (storage_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE compliance_documents;
+-- +goose StatementEnd
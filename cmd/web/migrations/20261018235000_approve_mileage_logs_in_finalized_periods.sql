@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- logs billed before review statuses existed were left as drafts, mark them approved as of when their period was finalized
+UPDATE mileage_logs SET status = 'approved', approved_at = billing_periods.finalized_at
+FROM billing_periods
+WHERE billing_periods.year = mileage_logs.year AND billing_periods.month = mileage_logs.month
+    AND billing_periods.status IN ('finalized', 'exported') AND mileage_logs.status = 'draft';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- the backfilled logs can't be told apart from logs approved since, so they are left approved
+SELECT 1;
+-- +goose StatementEnd
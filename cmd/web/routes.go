@@ -14,6 +14,7 @@ func routes(app *config.AppConfig) http.Handler {
 
 	// middleware
 	mux.Use(middleware.Recoverer)
+	mux.Use(LimitRequestSize)
 	mux.Use(NoSurf)
 	mux.Use(SessionLoad)
 
@@ -52,8 +53,29 @@ func routes(app *config.AppConfig) http.Handler {
 		mux.Post("/new-vehicle", handlers.Repo.VehicleCreatePost)
 		mux.Get("/vehicles/{id}", handlers.Repo.VehicleEdit)
 		mux.Post("/vehicles/{id}", handlers.Repo.VehicleEditPost)
-		//mux.Get("/vehicles/{id}/delete", handlers.Repo.VehicleDelete)
+		mux.Get("/vehicles/{id}/delete", handlers.Repo.VehicleDelete)
+		mux.Post("/vehicles/{id}/delete", handlers.Repo.VehicleDeletePost)
+		mux.Post("/vehicles/{id}/merge", handlers.Repo.VehicleMergePost)
 		mux.Get("/vehicles/{id}/deactivate", handlers.Repo.VehicleDeactivate)
+		mux.Get("/vehicles/{id}/reconciliation", handlers.Repo.VehicleReconciliation)
+		mux.Get("/vehicles/{id}/maintenance", handlers.Repo.VehicleMaintenance)
+		mux.Post("/vehicles/{id}/maintenance/services", handlers.Repo.ServiceRecordPost)
+		mux.Post("/vehicles/{id}/maintenance/services/{service_id}/delete", handlers.Repo.ServiceRecordDelete)
+		mux.Post("/vehicles/{id}/maintenance/schedules", handlers.Repo.MaintenanceSchedulePost)
+		mux.Post("/vehicles/{id}/maintenance/schedules/defaults", handlers.Repo.MaintenanceScheduleDefaults)
+		mux.Post("/vehicles/{id}/maintenance/schedules/{schedule_id}/delete", handlers.Repo.MaintenanceScheduleDelete)
+		mux.Get("/maintenance", handlers.Repo.MaintenanceDashboard)
+		mux.Get("/vehicles/{id}/economics", handlers.Repo.VehicleEconomics)
+		mux.Post("/vehicles/{id}/economics/expenses", handlers.Repo.VehicleExpensePost)
+		mux.Post("/vehicles/{id}/economics/expenses/{expense_id}/delete", handlers.Repo.VehicleExpenseDelete)
+		mux.Get("/vehicles/{id}/compliance", handlers.Repo.VehicleCompliance)
+		mux.Post("/vehicles/{id}/compliance", handlers.Repo.ComplianceDocumentPost)
+		mux.Get("/vehicles/{id}/compliance/{document_id}", handlers.Repo.ComplianceDocumentFile)
+		mux.Post("/vehicles/{id}/compliance/{document_id}/delete", handlers.Repo.ComplianceDocumentDelete)
+		mux.Get("/compliance", handlers.Repo.Compliance)
+		mux.Post("/compliance/digest", handlers.Repo.ComplianceDigestPost)
+		mux.Get("/utilization", handlers.Repo.Utilization)
+		mux.Get("/utilization/csv", handlers.Repo.UtilizationCSV)
 
 		// members routes
 		mux.Get("/members", handlers.Repo.MemberList)
@@ -61,7 +83,9 @@ func routes(app *config.AppConfig) http.Handler {
 		mux.Post("/new-member", handlers.Repo.MemberCreatePost)
 		mux.Get("/members/{id}", handlers.Repo.MemberEdit)
 		mux.Post("/members/{id}", handlers.Repo.MemberEditPost)
-		//mux.Get("/members/{id}/delete", handlers.Repo.MemberDelete)
+		mux.Get("/members/{id}/delete", handlers.Repo.MemberDelete)
+		mux.Post("/members/{id}/delete", handlers.Repo.MemberDeletePost)
+		mux.Post("/members/{id}/merge", handlers.Repo.MemberMergePost)
 		mux.Get("/members/{id}/deactivate", handlers.Repo.MemberDeactivate)
 
 		// mileage logs routes
@@ -72,6 +96,7 @@ func routes(app *config.AppConfig) http.Handler {
 		mux.Get("/mileage-logs/{id}", handlers.Repo.MileageLogEdit)
 		mux.Post("/mileage-logs/{id}", handlers.Repo.MileageLogEditPost)
 		mux.Get("/mileage-logs/{id}/delete", handlers.Repo.MileageLogDelete)
+		mux.Post("/mileage-logs/{id}/status", handlers.Repo.MileageLogStatusPost)
 		mux.Get("/mileage-logs/{id}/edit-trips", handlers.Repo.TripsEdit)
 		//mux.Post("/mileage-logs/{id}/edit-trips", handlers.Repo.TripsEditPost)
 		mux.Post("/mileage-logs/{id}/add-trip", handlers.Repo.AddTripPost) // htmx handler
@@ -80,6 +105,19 @@ func routes(app *config.AppConfig) http.Handler {
 		mux.Get("/mileage-logs/{id}/billing", handlers.Repo.MileageLogBilling)
 		mux.Get("/mileage-logs/{id}/download-csv", handlers.Repo.MileageLogCSV)
 		mux.Get("/trip-delete/{id}", handlers.Repo.DeleteTrip)
+		mux.Get("/trip-delete/{id}/confirm", handlers.Repo.DeleteTrip)
+		mux.Get("/trip-insert/{id}", handlers.Repo.InsertTrip)      // htmx handler
+		mux.Post("/trip-insert/{id}", handlers.Repo.InsertTripPost) // htmx insert trip handler
+		mux.Get("/mileage-logs/{id}/fuel", handlers.Repo.FuelPurchases)
+		mux.Post("/mileage-logs/{id}/fuel", handlers.Repo.FuelPurchasePost)
+		mux.Post("/mileage-logs/{id}/fuel/{fuel_id}/delete", handlers.Repo.FuelPurchaseDelete)
+		mux.Get("/mileage-logs/{id}/import", handlers.Repo.MileageLogImport)
+		mux.Post("/mileage-logs/{id}/import", handlers.Repo.MileageLogImportPost)
+		mux.Post("/mileage-logs/{id}/import/confirm", handlers.Repo.MileageLogImportConfirm)
+		mux.Post("/mileage-logs/{id}/attachments", handlers.Repo.AttachmentsPost)
+		mux.Get("/mileage-logs/{id}/attachments/{attachment_id}", handlers.Repo.Attachment)
+		mux.Post("/mileage-logs/{id}/attachments/{attachment_id}/delete", handlers.Repo.AttachmentDelete)
+		mux.Get("/log-sheets", handlers.Repo.LogSheets)
 
 
 		// billing routes
@@ -87,8 +125,28 @@ func routes(app *config.AppConfig) http.Handler {
 		mux.Get("/billings/{yyyy}/{mm}", handlers.Repo.BillingSummaryYearMonth)
 		mux.Post("/billings", handlers.Repo.BillingSummaryPost)
 		mux.Get("/billings/{yyyy}/{mm}/create-logs", handlers.Repo.BillingCreateMileageLogs)
+		mux.Get("/billings/{yyyy}/{mm}/reconciliation", handlers.Repo.BillingReconciliation)
 		mux.Get("/billings/{yyyy}/{mm}/download-csv", handlers.Repo.BillingCSV)
 		mux.Get("/billings/{yyyy}/{mm}/download-qbo-invoices", handlers.Repo.QBOBulkInvoicesCSV)
+		mux.Post("/billings/{yyyy}/{mm}/finalize", handlers.Repo.BillingPeriodFinalize)
+		mux.Post("/billings/{yyyy}/{mm}/reopen", handlers.Repo.BillingPeriodReopen)
+		mux.Get("/billings/{yyyy}/{mm}/download-statements", handlers.Repo.MemberStatementsZIP)
+		mux.Get("/billings/{yyyy}/{mm}/statements/{member_id}", handlers.Repo.MemberStatementPDF)
+		mux.Post("/billings/{yyyy}/{mm}/send-statements", handlers.Repo.SendStatements)
+
+		// ledger routes
+		mux.Get("/ledger", handlers.Repo.LedgerSummary)
+		mux.Get("/ledger/{id}", handlers.Repo.MemberLedger)
+		mux.Post("/ledger/{id}", handlers.Repo.MemberLedgerPost)
+		mux.Post("/ledger/{id}/entries/{entry_id}/delete", handlers.Repo.LedgerEntryDelete)
+
+		// trash routes
+		mux.Get("/trash", handlers.Repo.Trash)
+		mux.Post("/trash/{type}/{id}/restore", handlers.Repo.TrashRestore)
+
+		// settings routes
+		mux.Get("/settings/long-distance", handlers.Repo.LongDistanceSettings)
+		mux.Post("/settings/long-distance", handlers.Repo.LongDistanceSettingsPost)
 
 		// htmx routes
 		mux.Get("/remove-item", handlers.Repo.RemoveItem)
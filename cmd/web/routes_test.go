@@ -1,6 +1,8 @@
 package main
 
 import (
+	"net/http"
+	"slices"
 	"testing"
 
 	"github.com/cxt314/drvc-go/internal/config"
@@ -19,3 +21,53 @@ func TestRoutes(t *testing.T) {
 		t.Errorf("type is not *chi.Mux, type is %T", v)
 	}
 }
+
+// TestDeleteRoutes checks members and vehicles are only moved to the trash by the POST delete routes, which check
+// for dependents first. The GET routes only show what refers to them
+func TestDeleteRoutes(t *testing.T) {
+	var app config.AppConfig
+
+	methods := make(map[string][]string)
+	err := chi.Walk(routes(&app).(*chi.Mux), func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		methods[route] = append(methods[route], method)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, route := range []string{"/members/{id}/delete", "/vehicles/{id}/delete"} {
+		if !slices.Contains(methods[route], http.MethodPost) {
+			t.Errorf("expected %s to be a POST route, got %v", route, methods[route])
+		}
+	}
+}
+
+// TestDeleteRoutesArePost checks records that are deleted straight away can only be deleted by a POST,
+// which the CSRF check covers, and not by following a link
+func TestDeleteRoutesArePost(t *testing.T) {
+	var app config.AppConfig
+
+	methods := make(map[string][]string)
+	err := chi.Walk(routes(&app).(*chi.Mux), func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		methods[route] = append(methods[route], method)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, route := range []string{
+		"/ledger/{id}/entries/{entry_id}/delete",
+		"/mileage-logs/{id}/attachments/{attachment_id}/delete",
+		"/mileage-logs/{id}/fuel/{fuel_id}/delete",
+		"/vehicles/{id}/maintenance/services/{service_id}/delete",
+		"/vehicles/{id}/maintenance/schedules/{schedule_id}/delete",
+		"/vehicles/{id}/economics/expenses/{expense_id}/delete",
+		"/vehicles/{id}/compliance/{document_id}/delete",
+	} {
+		if !slices.Equal(methods[route], []string{http.MethodPost}) {
+			t.Errorf("expected %s to be POST only, got %v", route, methods[route])
+		}
+	}
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"html/template"
 	"log"
+	"sync/atomic"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
 )
@@ -21,4 +23,11 @@ type AppConfig struct {
 	ErrorLog      *log.Logger
 	InProduction  bool
 	Session       *scs.SessionManager
+	// TrashRetention is how long deleted items stay in the trash before they are purged
+	TrashRetention time.Duration
+	// ComplianceWarningDays is how many days before a vehicle document expires it is warned about
+	ComplianceWarningDays int
+	// ComplianceAlerts counts the vehicle documents that have expired or expire within ComplianceWarningDays,
+	// for the warning in the navbar. It is updated when documents change and once a day
+	ComplianceAlerts atomic.Int64
 }
@@ -0,0 +1,302 @@
+// Package csvimport parses paper mileage logs typed into a spreadsheet. The layout is the same
+// as the mileage log CSV download: date, 3-digit end mileage, miles, destination, purpose, then one rider name per column
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// dateLayouts are the date formats accepted in the date column
+var dateLayouts = []string{config.DateLayout, "1/2/2006", "1/2/06"}
+
+// Row is one trip read from the CSV
+type Row struct {
+	// Line is the line number in the file, starting at 1
+	Line         int
+	TripDate     time.Time
+	StartMileage int
+	EndMileage   int
+	// Miles is the miles column as written, or -1 if it was left blank
+	Miles       int
+	Destination string
+	Purpose     string
+	RiderNames  []string
+	Riders      []models.Member
+	// Unmatched holds rider names that don't match any member's name or alias
+	Unmatched []string
+	// Gap is the miles between the end of the previous trip and the start of this one.
+	// A positive gap is miles nobody logged, a negative gap means the trips overlap
+	Gap    int
+	Errors []string
+}
+
+// Preview is the result of parsing a CSV for a mileage log, shown to the user before anything is inserted
+type Preview struct {
+	Rows []Row
+	// StartOdometer is the odometer reading the first trip is expected to start at
+	StartOdometer int
+}
+
+// Valid returns true if every row parsed and every rider name matched a member. Overlapping trips are row errors
+func (p Preview) Valid() bool {
+	if len(p.Rows) == 0 {
+		return false
+	}
+
+	for _, r := range p.Rows {
+		if len(r.Errors) > 0 || len(r.Unmatched) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Unmatched returns each rider name that didn't match a member, once
+func (p Preview) Unmatched() []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, r := range p.Rows {
+		for _, n := range r.Unmatched {
+			if !seen[strings.ToLower(n)] {
+				seen[strings.ToLower(n)] = true
+				names = append(names, n)
+			}
+		}
+	}
+
+	return names
+}
+
+// GapCount returns the number of rows that don't start where the previous trip ended
+func (p Preview) GapCount() int {
+	count := 0
+	for _, r := range p.Rows {
+		if r.Gap != 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Trips returns the rows as trips for the mileage log. Riders pay a full share each
+func (p Preview) Trips(log models.MileageLog) []models.Trip {
+	var trips []models.Trip
+
+	for _, r := range p.Rows {
+		t := models.Trip{
+			MileageLog:   log,
+			TripDate:     r.TripDate,
+			StartMileage: r.StartMileage,
+			EndMileage:   r.EndMileage,
+			BillingRate:  models.BillingRates[0],
+			Destination:  r.Destination,
+			Purpose:      r.Purpose,
+		}
+
+		for _, m := range r.Riders {
+			t.Riders = append(t.Riders, models.Rider{Member: m, Weight: 1})
+		}
+
+		trips = append(trips, t)
+	}
+
+	return trips
+}
+
+// Parse reads a CSV of trips for the mileage log. startOdometer is where the first trip should start,
+// normally the end mileage of the log's latest trip. Full odometer readings are rebuilt from the 3-digit
+// end mileages using the miles column when it is filled in, otherwise by rolling over to the next thousand whenever
+// the reading goes down. A trip that starts before the previous one ended is a row error, a positive gap is only
+// highlighted since it can be a trip nobody logged.
+// Rows before the "Date" header row, such as the title rows of the CSV download, are skipped
+func Parse(r io.Reader, log models.MileageLog, startOdometer int, members []models.Member) (Preview, error) {
+	preview := Preview{StartOdometer: startOdometer}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return preview, err
+	}
+
+	// skip to the row after the header if there is one
+	start := 0
+	for i, rec := range records {
+		if len(rec) > 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "Date") {
+			start = i + 1
+			break
+		}
+	}
+
+	names := memberNames(members)
+	prevEnd := startOdometer
+
+	for i := start; i < len(records); i++ {
+		rec := records[i]
+		if isBlank(rec) {
+			continue
+		}
+
+		row := parseRow(rec, i+1, log, names)
+		if row.EndMileage >= 0 {
+			row.EndMileage = fullOdometer(prevEnd, row.EndMileage, row.Miles)
+
+			row.StartMileage = prevEnd
+			if row.Miles >= 0 {
+				row.StartMileage = row.EndMileage - row.Miles
+			}
+			row.Gap = row.StartMileage - prevEnd
+			if row.Gap < 0 {
+				row.Errors = append(row.Errors, fmt.Sprintf("Trip starts %d miles before the previous trip ended, check the end mileage and miles",
+					-row.Gap))
+			}
+
+			prevEnd = row.EndMileage
+		}
+
+		preview.Rows = append(preview.Rows, row)
+	}
+
+	if len(preview.Rows) == 0 {
+		return preview, errors.New("no trips found in the file")
+	}
+
+	return preview, nil
+}
+
+// parseRow reads the columns of one trip. EndMileage is the 3-digit value, or -1 if it couldn't be read
+func parseRow(rec []string, line int, log models.MileageLog, names map[string]models.Member) Row {
+	row := Row{Line: line, EndMileage: -1, Miles: -1}
+
+	col := func(i int) string {
+		if i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	date, err := parseDate(col(0))
+	if err != nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("Date %q is not a valid date", col(0)))
+	} else if date.Year() != log.Year || int(date.Month()) != log.Month {
+		row.Errors = append(row.Errors, fmt.Sprintf("Date %s is not in %04d-%02d", col(0), log.Year, log.Month))
+	}
+	row.TripDate = date
+
+	end, err := strconv.Atoi(col(1))
+	if err != nil || end < 0 || end > 999 {
+		row.Errors = append(row.Errors, fmt.Sprintf("End mileage %q must be a number from 0 to 999", col(1)))
+	} else {
+		row.EndMileage = end
+	}
+
+	if col(2) != "" {
+		miles, err := strconv.Atoi(col(2))
+		if err != nil || miles < 0 {
+			row.Errors = append(row.Errors, fmt.Sprintf("Miles %q must be a whole number", col(2)))
+		} else {
+			row.Miles = miles
+		}
+	}
+
+	row.Destination = col(3)
+	row.Purpose = col(4)
+
+	for i := 5; i < len(rec); i++ {
+		name := strings.TrimSpace(rec[i])
+		if name == "" {
+			continue
+		}
+
+		row.RiderNames = append(row.RiderNames, name)
+
+		m, ok := names[strings.ToLower(name)]
+		if !ok {
+			row.Unmatched = append(row.Unmatched, name)
+			continue
+		}
+		row.Riders = append(row.Riders, m)
+	}
+
+	if len(row.RiderNames) == 0 {
+		row.Errors = append(row.Errors, "Trip has no riders")
+	}
+
+	return row
+}
+
+// fullOdometer returns the odometer reading ending in end3 for a trip that ended after prevEnd.
+// If the miles are known, the reading closest to prevEnd plus the miles is used, so trips of a thousand miles or
+// more roll over the right number of times. Otherwise it is the first reading that isn't below prevEnd
+func fullOdometer(prevEnd int, end3 int, miles int) int {
+	end := prevEnd - prevEnd%1000 + end3
+
+	if miles >= 0 {
+		if target := prevEnd + miles; target > end {
+			end += (target - end + 500) / 1000 * 1000
+		}
+
+		return end
+	}
+
+	if end < prevEnd {
+		end += 1000
+	}
+
+	return end
+}
+
+// memberNames maps lower case member names and aliases to their member
+func memberNames(members []models.Member) map[string]models.Member {
+	names := make(map[string]models.Member)
+
+	for _, m := range members {
+		for _, a := range m.Aliases {
+			names[strings.ToLower(strings.TrimSpace(a.Name))] = m
+		}
+	}
+
+	// names take priority over aliases
+	for _, m := range members {
+		names[strings.ToLower(strings.TrimSpace(m.Name))] = m
+	}
+
+	return names
+}
+
+func parseDate(s string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var d time.Time
+		d, err = time.Parse(layout, s)
+		if err == nil {
+			return d, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+func isBlank(rec []string) bool {
+	for _, c := range rec {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+
+	return true
+}
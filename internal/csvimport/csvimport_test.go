@@ -0,0 +1,136 @@
+package csvimport
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+var testMembers = []models.Member{
+	{ID: 1, Name: "Alice Smith", Aliases: []models.MemberAlias{{Name: "Al"}}},
+	{ID: 2, Name: "Bob Jones"},
+}
+
+var testLog = models.MileageLog{ID: 7, Year: 2026, Month: 9}
+
+// testCSV is in the layout of the mileage log CSV download, including its title rows
+const testCSV = `
+Prius,2026-09
+Starting Mileage:,45900,Ending Mileage:,0,Total Miles:,0
+Date,End mileage 3-digits,Miles,Destination,Purpose,Riders
+2026-09-01,950,40,Memphis,Errands,Alice Smith
+9/3/2026,010,60,Fairfield,Shopping,al,Bob Jones
+
+2026-09-05,030,15,Rutledge,Work,Carol
+2026-10-01,x,,Somewhere,,
+`
+
+func TestParse(t *testing.T) {
+	p, err := Parse(strings.NewReader(testCSV), testLog, 45910, testMembers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Rows) != 4 {
+		t.Fatalf("expected 4 rows but got %d", len(p.Rows))
+	}
+
+	first := p.Rows[0]
+	if first.StartMileage != 45910 || first.EndMileage != 45950 || first.Gap != 0 {
+		t.Errorf("unexpected first row mileage %d-%d gap %d", first.StartMileage, first.EndMileage, first.Gap)
+	}
+
+	// 010 after 950 rolls over to the next thousand
+	second := p.Rows[1]
+	if second.StartMileage != 45950 || second.EndMileage != 46010 {
+		t.Errorf("expected rollover to 45950-46010 but got %d-%d", second.StartMileage, second.EndMileage)
+	}
+	if len(second.Riders) != 2 || second.Riders[0].ID != 1 || second.Riders[1].ID != 2 {
+		t.Errorf("expected alias and name to match Alice and Bob, got %v", second.Riders)
+	}
+
+	// 15 miles ending at 46030 leaves 5 miles nobody logged
+	third := p.Rows[2]
+	if third.StartMileage != 46015 || third.Gap != 5 {
+		t.Errorf("expected a 5 mile gap starting at 46015, got start %d gap %d", third.StartMileage, third.Gap)
+	}
+	if !slices.Equal(third.Unmatched, []string{"Carol"}) {
+		t.Errorf("expected Carol to be unmatched, got %v", third.Unmatched)
+	}
+
+	if len(p.Rows[3].Errors) != 3 {
+		t.Errorf("expected date, end mileage and rider errors, got %v", p.Rows[3].Errors)
+	}
+
+	if p.Valid() {
+		t.Error("expected preview with errors to be invalid")
+	}
+	if p.GapCount() != 1 {
+		t.Errorf("expected 1 gap but got %d", p.GapCount())
+	}
+}
+
+func TestParseValid(t *testing.T) {
+	csv := "2026-09-01,950,40,Memphis,Errands,Alice Smith\n2026-09-02,960,,Home,,Bob Jones\n"
+
+	p, err := Parse(strings.NewReader(csv), testLog, 45910, testMembers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.Valid() {
+		t.Fatalf("expected valid preview, got %+v", p.Rows)
+	}
+
+	trips := p.Trips(testLog)
+	if len(trips) != 2 || trips[1].StartMileage != 45950 || trips[1].EndMileage != 45960 {
+		t.Errorf("unexpected trips %+v", trips)
+	}
+	if trips[0].MileageLog.ID != testLog.ID || trips[0].Riders[0].Weight != 1 {
+		t.Errorf("expected trips for the log with full shares, got %+v", trips[0])
+	}
+}
+
+var fullOdometerTests = []struct {
+	prevEnd  int
+	end3     int
+	miles    int
+	expected int
+}{
+	{45910, 950, 40, 45950},
+	{45950, 10, 60, 46010},
+	{45950, 950, 0, 45950},
+	{45950, 950, 1000, 46950},
+	{999, 0, 1, 1000},
+	{12950, 50, 1100, 14050},
+	{12950, 50, 100, 13050},
+	{12950, 50, -1, 13050},
+	{45950, 940, -1, 46940},
+}
+
+func TestParseOverlap(t *testing.T) {
+	// 30 miles ending at 45950 would start 20 miles before the log's last trip ended
+	csv := "2026-09-01,950,30,Memphis,Errands,Alice Smith\n"
+
+	p, err := Parse(strings.NewReader(csv), testLog, 45940, testMembers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Rows[0].Gap != -20 || len(p.Rows[0].Errors) != 1 {
+		t.Errorf("expected a -20 mile gap reported as an error, got gap %d errors %v", p.Rows[0].Gap, p.Rows[0].Errors)
+	}
+	if p.Valid() {
+		t.Error("expected preview with overlapping trips to be invalid")
+	}
+}
+
+func TestFullOdometer(t *testing.T) {
+	for _, tt := range fullOdometerTests {
+		if got := fullOdometer(tt.prevEnd, tt.end3, tt.miles); got != tt.expected {
+			t.Errorf("fullOdometer(%d, %d, %d): expected %d but got %d", tt.prevEnd, tt.end3, tt.miles, tt.expected, got)
+		}
+	}
+}
@@ -74,6 +74,23 @@ func (f *Form) IsEqual(field string, match string) {
 	}
 }
 
+// IsValidShareWeights checks that each selected rider's share weight, if entered, is a number from 0 to 99.99.
+// Errors are added to the riders field since the weight inputs are created by the rider select
+func (f *Form) IsValidShareWeights(ridersField string) {
+	for _, riderID := range f.Values[ridersField] {
+		weight := f.Get("rider-weight-" + riderID)
+		if weight == "" {
+			continue
+		}
+
+		x, err := strconv.ParseFloat(weight, 64)
+		if err != nil || x < 0 || x > 99.99 {
+			f.Errors.Add(ridersField, "Share weights must be numbers from 0 to 99.99")
+			return
+		}
+	}
+}
+
 func (f *Form) IsValidEndMileage(endField string, startField string) {
 	startMileage, err := strconv.Atoi(f.Get(startField))
 	if err != nil {
@@ -90,22 +107,9 @@ func (f *Form) IsValidEndMileage(endField string, startField string) {
 	}
 }
 
-func (f *Form) IsValidNewEndMileage(field string, originalStartMileage int, originalEndMileage int, nextTripEndMileage int) {
-	newEndMileage, err := strconv.Atoi(f.Get(field))
-	if err != nil {
+// IsNumber checks that a field is a whole number
+func (f *Form) IsNumber(field string) {
+	if _, err := strconv.Atoi(f.Get(field)); err != nil {
 		f.Errors.Add(field, "This field must be a number")
 	}
-
-	if newEndMileage < originalStartMileage {
-		f.Errors.Add(field, "End mileage cannot be less than start mileage")
-	}
-
-	diff := newEndMileage - originalEndMileage
-	if diff == 1000 {
-		// no error, all future trips will have mileages adjusted by 1000
-	} else if diff > 0 && diff < 1000 {
-		if newEndMileage > nextTripEndMileage {
-			f.Errors.Add(field, fmt.Sprintf("End Mileage must be less than next trip's end mileage: %d", nextTripEndMileage))
-		}
-	}
 }
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/storage"
+)
+
+// maxAttachmentFilename is the longest filename kept for an attachment
+const maxAttachmentFilename = 255
+
+// AttachmentsPost stores scans or photos of the paper log sheets uploaded for a mileage log.
+// Files that are too large or aren't images or PDFs are skipped and listed in an error message.
+// Scans can still be added once the billing period is finalized since they don't change the billing
+func (m *Repository) AttachmentsPost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	redirectURL := fmt.Sprintf("/mileage-logs/%d/edit-trips", id)
+
+	// each file is checked against storage.MaxSize below, the size of the whole request is limited by the
+	// request size middleware
+	err = r.ParseMultipartForm(storage.MaxSize)
+	if err != nil {
+		m.App.Session.Put(r.Context(), "error", "Could not read the uploaded files")
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["attachments"]
+	if len(files) == 0 {
+		m.App.Session.Put(r.Context(), "error", "Select a scan or photo to upload")
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+
+	userID := m.App.Session.GetInt(r.Context(), "user_id")
+
+	var problems []string
+	uploaded := 0
+	for _, fh := range files {
+		name, data, err := readUploadedFile(fh)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+
+		contentType, err := storage.Check(data)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+			continue
+		}
+
+		key, err := m.Store.Put(data)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+
+		_, err = m.DB.InsertAttachment(models.Attachment{
+			MileageLogID: id,
+			Key:          key,
+			Filename:     name,
+			ContentType:  contentType,
+			Size:         len(data),
+		}, userID)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+
+		uploaded++
+	}
+
+	if len(problems) > 0 {
+		m.App.Session.Put(r.Context(), "error", "Not uploaded: "+strings.Join(problems, "; "))
+	}
+	if uploaded > 0 {
+		m.App.Session.Put(r.Context(), "flash", fmt.Sprintf("Uploaded %d file(s)", uploaded))
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+// Attachment serves an uploaded file so it can be shown beside the trips or downloaded
+func (m *Repository) Attachment(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.URL.Path, "/")
+	logID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(exploded[4])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	a, err := m.DB.GetAttachmentByID(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && a.MileageLogID != logID) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.serveStoredFile(w, r, a)
+}
+
+// serveStoredFile sends an uploaded file from storage, inline or as a download with ?download
+func (m *Repository) serveStoredFile(w http.ResponseWriter, r *http.Request, a models.Attachment) {
+	f, err := m.Store.Open(a.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	defer f.Close()
+
+	disposition := "inline"
+	if r.URL.Query().Get("download") != "" {
+		disposition = "attachment"
+	}
+
+	// stored files never change, so browsers can keep them
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(a.Size))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+
+	io.Copy(w, f)
+}
+
+// AttachmentDelete removes an attachment from a mileage log, and its file from storage if no other
+// attachment uses it. Scans are kept as evidence once the billing period is finalized
+func (m *Repository) AttachmentDelete(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	logID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(exploded[4])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	redirectURL := fmt.Sprintf("/mileage-logs/%d/edit-trips", logID)
+
+	if m.mileageLogLocked(w, r, logID, redirectURL) {
+		return
+	}
+
+	a, err := m.DB.GetAttachmentByID(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && a.MileageLogID != logID) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	unused, err := m.DB.DeleteAttachment(id, m.App.Session.GetInt(r.Context(), "user_id"))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if unused {
+		err = m.Store.Delete(a.Key)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Deleted "+a.Filename)
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+// readUploadedFile reads an uploaded file, returning its name without any path and shortened to
+// maxAttachmentFilename. Oversized files are read one byte past storage.MaxSize so storage.Check catches them
+func readUploadedFile(fh *multipart.FileHeader) (string, []byte, error) {
+	name := filepath.Base(fh.Filename)
+	if len(name) > maxAttachmentFilename {
+		name = name[len(name)-maxAttachmentFilename:]
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		return name, nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, storage.MaxSize+1))
+
+	return name, data, err
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -45,7 +46,7 @@ func (m *Repository) BillingSummaryPost(w http.ResponseWriter, r *http.Request)
 
 // BillingSummaryYearMonth gives a summary billing for each member and each vehicle by year and month
 func (m *Repository) BillingSummaryYearMonth(w http.ResponseWriter, r *http.Request) {
-	exploded := strings.Split(r.RequestURI, "/")
+	exploded := strings.Split(r.URL.Path, "/")
 	year, err := strconv.Atoi(exploded[2])
 	if err != nil {
 		helpers.ServerError(w, err)
@@ -57,7 +58,7 @@ func (m *Repository) BillingSummaryYearMonth(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	td, err := m.getSummaryBillingTemplateData(year, month)
+	td, err := m.getSummaryBillingTemplateData(year, month, statusFilter(r))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -82,6 +83,12 @@ func (m *Repository) getMileageLogBilling(log models.MileageLog, members []model
 	// total cost of member billings (for checksum)
 	logBilling.TotalMemberBillings = m.calcTotalMemberBillingsCost(memberBillings)
 
+	logBilling.TotalExemptCost = calcTotalExemptCost(log)
+
+	// fuel surcharges and credits are billed on top of trip costs
+	logBilling.TotalFuelSurcharge = calcTotalFuelSurcharge(log)
+	logBilling.TotalFuelCredit = calcTotalFuelCredit(log)
+
 	return logBilling, nil
 }
 
@@ -97,6 +104,8 @@ func (m *Repository) getSummaryBillingDisplay(vehicleBills map[string]models.Mil
 		keyOrder = append(keyOrder, v.Name)
 		keyOrder = append(keyOrder, v.Name+" LD")
 	}
+	keyOrder = append(keyOrder, "Fuel Surcharge")
+	keyOrder = append(keyOrder, "Fuel Credit")
 	keyOrder = append(keyOrder, "Total")
 
 	// create & append row for each member
@@ -105,18 +114,26 @@ func (m *Repository) getSummaryBillingDisplay(vehicleBills map[string]models.Mil
 		row["Member"] = i.Name
 
 		memberTotal := models.ToUSD(0.0)
+		fuelSurcharge := models.ToUSD(0.0)
+		fuelCredit := models.ToUSD(0.0)
 
 		for _, v := range vehicles {
 			row[v.Name] = vehicleBills[v.Name].MemberBills[i.ID].RegularTripsCost.String()
 			memberTotal = memberTotal.AddUSD(vehicleBills[v.Name].MemberBills[i.ID].RegularTripsCost)
 			row[v.Name+" LD"] = vehicleBills[v.Name].MemberBills[i.ID].LongDistanceTripsCost.String()
 			memberTotal = memberTotal.AddUSD(vehicleBills[v.Name].MemberBills[i.ID].LongDistanceTripsCost)
+			fuelSurcharge = fuelSurcharge.AddUSD(vehicleBills[v.Name].MemberBills[i.ID].FuelSurchargeCost)
+			fuelCredit = fuelCredit.AddUSD(vehicleBills[v.Name].MemberBills[i.ID].FuelCredit)
 		}
 
+		row["Fuel Surcharge"] = fuelSurcharge.String()
+		row["Fuel Credit"] = fuelCredit.String()
+		memberTotal = memberTotal + fuelSurcharge - fuelCredit
+
 		row["Total"] = memberTotal.String()
 
-		// only append to display if member's bill is > 0
-		if memberTotal != 0 {
+		// only append to display if member has any charges or credits
+		if memberTotal != 0 || fuelCredit != 0 {
 			displayArray = append(displayArray, row)
 		}
 	}
@@ -125,7 +142,9 @@ func (m *Repository) getSummaryBillingDisplay(vehicleBills map[string]models.Mil
 	return displayArray, keyOrder
 }
 
-func (m *Repository) getSummaryBillingTemplateData(year int, month int) (*models.TemplateData, error) {
+// getSummaryBillingTemplateData returns the billing summary for a year/month. status filters the list of
+// mileage logs shown, the billing always covers every log in the month
+func (m *Repository) getSummaryBillingTemplateData(year int, month int, status string) (*models.TemplateData, error) {
 	td := models.TemplateData{}
 
 	// get mileage logs for given year and month from database
@@ -160,7 +179,50 @@ func (m *Repository) getSummaryBillingTemplateData(year int, month int) (*models
 
 	billDisplay, keyOrder := m.getSummaryBillingDisplay(mileageLogBills, members, vehicles)
 
+	period, err := m.DB.GetBillingPeriod(year, month)
+	if err != nil {
+		return &td, err
+	}
+
+	// total of the charges as they are now, to compare with the snapshot taken when the period was finalized
+	var currentTotal models.USD
+	for _, l := range mileageLogBills {
+		for _, b := range l.MemberBills {
+			currentTotal += b.Total()
+		}
+	}
+
+	var billings []models.MileageLogBilling
+	for _, l := range mileageLogBills {
+		billings = append(billings, l)
+	}
+
 	data := make(map[string]interface{})
+	data["statements"] = models.BuildMemberStatements(billings, year, month)
+
+	sends, err := m.DB.GetStatementSends(year, month)
+	if err != nil {
+		return &td, err
+	}
+
+	recs, err := m.getMonthReconciliations(logs)
+	if err != nil {
+		return &td, err
+	}
+
+	// logs are listed by vehicle name
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].Vehicle.Name < logs[j].Vehicle.Name
+	})
+
+	data["status"] = status
+	data["statuses"] = models.MileageLogStatuses
+	data["mileage-logs"] = models.FilterMileageLogsByStatus(logs, status)
+	data["unapproved-logs"] = models.UnapprovedMileageLogs(logs)
+	data["statement-sends"] = sends
+	data["reconciliation-errors"] = reconciliationErrorCount(recs)
+	data["billing-period"] = period
+	data["current-total"] = currentTotal
 	data["vehicles"] = vehicles
 	data["mileage-log-bills"] = mileageLogBills
 	data["bill-display"] = billDisplay
@@ -206,6 +268,10 @@ func (m *Repository) getMileageLogBillingTemplateData(mileageLogId int) (*models
 	// total cost of member billings (for checksum)
 	data["total-member-billings"] = m.calcTotalMemberBillingsCost(memberBillings)
 
+	data["total-exempt-cost"] = calcTotalExemptCost(v)
+	data["total-fuel-surcharge"] = calcTotalFuelSurcharge(v)
+	data["total-fuel-credit"] = calcTotalFuelCredit(v)
+
 	td.Data = data
 
 	return &td, nil
@@ -224,37 +290,36 @@ func (m *Repository) calcTotalTripCost(log models.MileageLog) models.USD {
 
 // calcPerMemberBillings takes a mileage log and a list of members
 // and returns a map of member id to a models.MemberMileageLogBilling
-// that splits LD trips from regular trips for that member
+// that splits LD trips from regular trips for that member.
+// Each trip's cost is allocated to its riders in whole cents, so member shares always add up to the trip cost
 func (m *Repository) calcPerMemberBillings(log models.MileageLog, members []models.Member) map[int]models.MemberMileageLogBilling {
 	memberBillings := make(map[int]models.MemberMileageLogBilling)
 
 	tripMap := make(map[int]models.USD)
 	ldMap := make(map[int]models.USD)
-	tripMapFloat := make(map[int]float64)
-	ldMapFloat := make(map[int]float64)
+	fuelMap := make(map[int]models.USD)
+	creditMap := make(map[int]models.USD)
 
 	for _, v := range log.Trips {
-		numRiders := len(v.Riders)
-		// when summing up trip costs, keep things as a float to avoid rounding issues
-		tripShare := v.Cost().Float64() / float64(numRiders)
+		// riders pay in proportion to their share weight, exempt riders pay nothing
+		tripShares, fuelShares := v.RiderShares()
+
+		for i, r := range v.Riders {
+			fuelMap[r.Member.ID] += fuelShares[i]
 
-		for _, r := range v.Riders {
 			if v.LongDistanceDays > 0 {
 				// long distance trip, add to ldMap
-				ldMapFloat[r.ID] = ldMapFloat[r.ID] + tripShare
+				ldMap[r.Member.ID] += tripShares[i]
 			} else {
 				// regular trip, add to tripMap
-				tripMapFloat[r.ID] = tripMapFloat[r.ID] + tripShare
+				tripMap[r.Member.ID] += tripShares[i]
 			}
 		}
 	}
 
-	// convert trip cost floats to USD
-	for k, v := range tripMapFloat {
-		tripMap[k] = models.ToUSD(v)
-	}
-	for k, v := range ldMapFloat {
-		ldMap[k] = models.ToUSD(v)
+	// credit members for the fuel they paid for
+	for _, f := range log.FuelPurchases {
+		creditMap[f.Member.ID] += f.Amount
 	}
 
 	for _, v := range members {
@@ -262,6 +327,8 @@ func (m *Repository) calcPerMemberBillings(log models.MileageLog, members []mode
 			Member:                v,
 			RegularTripsCost:      tripMap[v.ID],
 			LongDistanceTripsCost: ldMap[v.ID],
+			FuelSurchargeCost:     fuelMap[v.ID],
+			FuelCredit:            creditMap[v.ID],
 		}
 
 		memberBillings[v.ID] = memberBilling
@@ -271,6 +338,44 @@ func (m *Repository) calcPerMemberBillings(log models.MileageLog, members []mode
 	return memberBillings
 }
 
+// calcTotalExemptCost returns the cost of trips in the log that no rider pays a share of
+func calcTotalExemptCost(log models.MileageLog) models.USD {
+	total := models.ToUSD(0.0)
+
+	for _, v := range log.Trips {
+		if !v.IsBillable() {
+			total = total + v.Cost()
+		}
+	}
+
+	return total
+}
+
+// calcTotalFuelSurcharge returns the fuel surcharge for the trips in the log that are billed to riders.
+// Like their cost, the surcharge on trips where every rider is exempt isn't billed to anyone
+func calcTotalFuelSurcharge(log models.MileageLog) models.USD {
+	total := models.ToUSD(0.0)
+
+	for _, v := range log.Trips {
+		if v.IsBillable() {
+			total = total + v.FuelSurcharge()
+		}
+	}
+
+	return total
+}
+
+// calcTotalFuelCredit returns the total paid by members for fuel during the log's month
+func calcTotalFuelCredit(log models.MileageLog) models.USD {
+	total := models.ToUSD(0.0)
+
+	for _, f := range log.FuelPurchases {
+		total = total + f.Amount
+	}
+
+	return total
+}
+
 func (m *Repository) calcTotalMemberBillingsCost(billings map[int]models.MemberMileageLogBilling) models.USD {
 	var totalUSD models.USD
 
@@ -295,6 +400,17 @@ func (m *Repository) BillingCreateMileageLogs(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// no new logs in a finalized period
+	period, err := m.DB.GetBillingPeriod(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if m.billingPeriodLocked(w, r, period, fmt.Sprintf("/billings/%d/%d", year, month)) {
+		return
+	}
+
 	// get all active vehicles
 	vehicles, err := m.DB.GetVehicleByActive(true)
 	if err != nil {
@@ -302,15 +418,43 @@ func (m *Repository) BillingCreateMileageLogs(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// vehicles that already have a log for the year/month are skipped
+	logs, err := m.DB.GetMileageLogsByYearMonth(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	hasLog := make(map[int]bool)
+	for _, l := range logs {
+		hasLog[l.Vehicle.ID] = true
+	}
+
 	// create new mileage log for each active vehicle for given year/month
+	var created, skipped []string
 	for _, v := range vehicles {
-		err = m.createMileageLogStub(v, year, month)
+		if hasLog[v.ID] {
+			skipped = append(skipped, v.Name)
+			continue
+		}
+
+		err = m.createMileageLogStub(v, year, month, m.App.Session.GetInt(r.Context(), "user_id"))
 		if err != nil {
 			helpers.ServerError(w, err)
 			return
 		}
+		created = append(created, v.Name)
 	}
 
+	msg := "No mileage logs created"
+	if len(created) > 0 {
+		msg = fmt.Sprintf("Created mileage logs for %s", strings.Join(created, ", "))
+	}
+	if len(skipped) > 0 {
+		msg += fmt.Sprintf(". Skipped %s, which already have a log", strings.Join(skipped, ", "))
+	}
+	m.App.Session.Put(r.Context(), "flash", msg)
+
 	http.Redirect(w, r, fmt.Sprintf("/billings/%d/%d", year, month), http.StatusSeeOther)
 }
 
@@ -362,8 +506,10 @@ func (m *Repository) BillingCSV(w http.ResponseWriter, r *http.Request) {
 }
 
 // QBOBulkInvoicesCSV generates a csv for download that uses Quickbooks Online's bulk import
-// invoice via csv tool to quickly transfer a monthly billing to Quickbooks invoices
-// TODO: how do we add gas mileage that needs to be tacked on? this is happening manually atm
+// invoice via csv tool to quickly transfer a monthly billing to Quickbooks invoices.
+// Fuel surcharges and fuel reimbursements are added as their own invoice lines.
+// Each member gets one invoice per month covering all vehicles, numbered from the qbo_invoices sequence.
+// Downloading the invoices for a finalized billing period marks it as exported
 func (m *Repository) QBOBulkInvoicesCSV(w http.ResponseWriter, r *http.Request) {
 	exploded := strings.Split(r.RequestURI, "/")
 	year, err := strconv.Atoi(exploded[2])
@@ -383,6 +529,20 @@ func (m *Repository) QBOBulkInvoicesCSV(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// invoice numbers are allocated before anything is written so a database error can still be reported
+	invoiceLines, err := m.getQBOInvoiceLines(year, month, logs)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// the period is marked exported before streaming, once the response has started an error can't be reported
+	err = m.DB.MarkBillingPeriodExported(year, month, m.App.Session.GetInt(r.Context(), "user_id"))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
 	// Set headers so browser will download the file
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=qbo-bulk-invoices-%04d%02d.csv", year, month))
@@ -395,14 +555,9 @@ func (m *Repository) QBOBulkInvoicesCSV(w http.ResponseWriter, r *http.Request)
 	headerRow := m.getQBOInvoicesHeaderRow()
 	wr.Write(headerRow)
 
-	// get invoice lines for each mileage log
-	for _, l := range logs {
-		logCSV := m.convertMileageLogToQBOInvoiceLineRaw(l)
-
-		if err := wr.WriteAll(logCSV); err != nil {
-			helpers.ServerError(w, err)
-			return
-		}
+	if err := wr.WriteAll(invoiceLines); err != nil {
+		helpers.ServerError(w, err)
+		return
 	}
 
 	// Flush the writer and check for any errors
@@ -412,7 +567,6 @@ func (m *Repository) QBOBulkInvoicesCSV(w http.ResponseWriter, r *http.Request)
 		helpers.ServerError(w, err)
 		return
 	}
-
 }
 
 // getQBOInvoicesHeaderRow returns a csvSlice of the headers for QBO's bulk invoices
@@ -426,93 +580,161 @@ func (m *Repository) getQBOInvoicesHeaderRow() []string {
 	return headerRow
 }
 
-// convertMileageLogToQBOInvoiceLineRaw convers a mileage log to a csv string
-// containing QBO invoice line items for every member with a non-zero billing
-// for that log
-func (m *Repository) convertMileageLogToQBOInvoiceLineRaw(log models.MileageLog) [][]string {
-	csvSlice := [][]string{{}}
+// getQBOInvoiceLines returns the QBO invoice lines for every member with a non-zero billing in the logs.
+// A member's lines for all vehicles share one invoice number and are written together so QBO creates one invoice
+func (m *Repository) getQBOInvoiceLines(year int, month int, logs []models.MileageLog) ([][]string, error) {
+	var csvSlice [][]string
 
-	// get active members
+	// get active members, ordered by name so new invoice numbers are allocated alphabetically
 	members, err := m.DB.GetMemberByActive(true)
 	if err != nil {
-		return csvSlice
+		return csvSlice, err
 	}
 
-	// get per member billings for the log
-	memberBillings := m.calcPerMemberBillings(log, members)
-
-	// create new lines for each member in the per member billing where either RegularTripsCost or LongDistanceTripsCost are non-zero
-	for k, v := range memberBillings {
-		// use QBOName for customer name, unless QBOName is empty
-		customer := v.Member.QBOName
-		if customer == "" {
-			customer = v.Member.Name
-		}
+	// get per member billings for each log
+	logBillings := make([]map[int]models.MemberMileageLogBilling, len(logs))
+	for i, l := range logs {
+		logBillings[i] = m.calcPerMemberBillings(l, members)
+	}
 
-		// skip the line if the customer is DRVC
-		if customer == "DRVC" {
+	// find the members that need an invoice
+	var invoiced []models.Member
+	for _, v := range members {
+		// skip the member if the customer is DRVC
+		if qboCustomerName(v) == "DRVC" {
 			continue
 		}
 
-		// calculate invoice date & due date
-		nextMonthFirstDay := time.Date(log.Year, time.Month(log.Month+1), 1, 0, 0, 0, 0, time.UTC)
-		invoiceDate := nextMonthFirstDay.AddDate(0, 0, -1)
-		dueDate := invoiceDate.AddDate(0, 0, 15)
-
-		// check if amount owed is > 0 for trips
-		if v.RegularTripsCost > 0 {
-			tripRow := []string{
-				strconv.Itoa(k),                       // *InvoiceNo - use key (member id)
-				customer,                              // *Customer - use QBOName if not empty, else member name
-				invoiceDate.Format(config.DateLayout), // *InvoiceDate - last date of Mileage Log's month
-				dueDate.Format(config.DateLayout),     // *DueDate - 15 days from InvoiceDate
-				"Net 15",                              // Terms
-				"",                                    // Location - blank
-				"",                                    // Memo - blank
-				"Mileage Fee",                         // Item(Product/Service)
-				log.Vehicle.Name,                      // ItemDescription - name of vehicle
-				"1",                                   // ItemQuantity
-				v.RegularTripsCost.String(),           // ItemRate
-				v.RegularTripsCost.String(),           // *ItemAmount
-				log.Vehicle.QBOClass,                  // Class
-				"",                                    // Shipping address
-				"",                                    // Ship via - blank
-				"",                                    // Shipping date - blank
-				"",                                    // Tracking no. - blank
-				"",                                    // Shipping Charge - blank
-				"",                                    // Service Date - blank
-			}
+		var billings []models.MemberMileageLogBilling
+		for _, b := range logBillings {
+			billings = append(billings, b[v.ID])
+		}
 
-			csvSlice = append(csvSlice, tripRow)
+		if netQBOFuelCredits(billings) != nil {
+			invoiced = append(invoiced, v)
 		}
+	}
 
-		// check if amount owed is > = for ld
-		if v.LongDistanceTripsCost > 0 {
-			ldRow := []string{
-				strconv.Itoa(k),                       // *InvoiceNo - use key (member id)
-				customer,                              // *Customer - use QBOName if not empty, else member name
-				invoiceDate.Format(config.DateLayout), // *InvoiceDate - last date of Mileage Log's month
-				dueDate.Format(config.DateLayout),     // *DueDate - 15 days from InvoiceDate
-				"Net 15",                              // Terms
-				"",                                    // Location - blank
-				"",                                    // Memo - blank
-				"Long Distance",                       // Item(Product/Service)
-				log.Vehicle.Name,                      // ItemDescription - name of vehicle
-				"1",                                   // ItemQuantity
-				v.LongDistanceTripsCost.String(),      // ItemRate
-				v.LongDistanceTripsCost.String(),      // *ItemAmount
-				log.Vehicle.QBOClass,                  // Class
-				"",                                    // Shipping address
-				"",                                    // Ship via - blank
-				"",                                    // Shipping date - blank
-				"",                                    // Tracking no. - blank
-				"",                                    // Shipping Charge - blank
-				"",                                    // Service Date - blank
-			}
+	var memberIDs []int
+	for _, v := range invoiced {
+		memberIDs = append(memberIDs, v.ID)
+	}
 
-			csvSlice = append(csvSlice, ldRow)
+	invoiceNos, err := m.DB.GetQBOInvoiceNumbers(year, month, memberIDs)
+	if err != nil {
+		return csvSlice, err
+	}
+
+	for _, v := range invoiced {
+		invoiceNo := strconv.Itoa(invoiceNos[v.ID])
+
+		var billings []models.MemberMileageLogBilling
+		for _, b := range logBillings {
+			billings = append(billings, b[v.ID])
+		}
+
+		for i, b := range netQBOFuelCredits(billings) {
+			csvSlice = append(csvSlice, convertMileageLogToQBOInvoiceLineRaw(logs[i], b, invoiceNo)...)
 		}
 	}
 
+	return csvSlice, nil
+}
+
+// qboCustomerName returns the member's QBOName, unless QBOName is empty
+func qboCustomerName(v models.Member) string {
+	if v.QBOName == "" {
+		return v.Name
+	}
+
+	return v.QBOName
+}
+
+// netQBOFuelCredits nets a member's fuel credits against their charges on the same invoice, since QBO rejects
+// invoices with a negative total. billings are the member's billings for each log of the invoice. The returned
+// billings have their FuelCredit reduced, earliest log first, so the credits never exceed the charges. Credit beyond
+// the charges isn't invoiced and stays on the member's ledger balance. nil is returned if the member has no charges
+func netQBOFuelCredits(billings []models.MemberMileageLogBilling) []models.MemberMileageLogBilling {
+	var charges models.USD
+	for _, b := range billings {
+		charges += b.RegularTripsCost + b.LongDistanceTripsCost + b.FuelSurchargeCost
+	}
+
+	if charges <= 0 {
+		return nil
+	}
+
+	netted := make([]models.MemberMileageLogBilling, len(billings))
+	for i, b := range billings {
+		b.FuelCredit = min(b.FuelCredit, charges)
+		charges -= b.FuelCredit
+		netted[i] = b
+	}
+
+	return netted
+}
+
+// convertMileageLogToQBOInvoiceLineRaw converts a member's billing for a mileage log to QBO invoice
+// line items for every non-zero trip cost, fuel surcharge or fuel credit
+func convertMileageLogToQBOInvoiceLineRaw(log models.MileageLog, v models.MemberMileageLogBilling, invoiceNo string) [][]string {
+	var csvSlice [][]string
+
+	customer := qboCustomerName(v.Member)
+
+	// calculate invoice date & due date
+	nextMonthFirstDay := time.Date(log.Year, time.Month(log.Month+1), 1, 0, 0, 0, 0, time.UTC)
+	invoiceDate := nextMonthFirstDay.AddDate(0, 0, -1)
+	dueDate := invoiceDate.AddDate(0, 0, 15)
+
+	// check if amount owed is > 0 for trips
+	if v.RegularTripsCost > 0 {
+		csvSlice = append(csvSlice, qboInvoiceLine(invoiceNo, customer, invoiceDate, dueDate,
+			"Mileage Fee", log.Vehicle.Name, v.RegularTripsCost, log.Vehicle.QBOClass))
+	}
+
+	// check if amount owed is > = for ld
+	if v.LongDistanceTripsCost > 0 {
+		csvSlice = append(csvSlice, qboInvoiceLine(invoiceNo, customer, invoiceDate, dueDate,
+			"Long Distance", log.Vehicle.Name, v.LongDistanceTripsCost, log.Vehicle.QBOClass))
+	}
+
+	// check if amount owed is > 0 for fuel surcharge
+	if v.FuelSurchargeCost > 0 {
+		csvSlice = append(csvSlice, qboInvoiceLine(invoiceNo, customer, invoiceDate, dueDate,
+			"Fuel Surcharge", log.Vehicle.Name, v.FuelSurchargeCost, log.Vehicle.QBOClass))
+	}
+
+	// fuel the member paid for is credited as a negative line
+	if v.FuelCredit > 0 {
+		csvSlice = append(csvSlice, qboInvoiceLine(invoiceNo, customer, invoiceDate, dueDate,
+			"Fuel Reimbursement", log.Vehicle.Name, -v.FuelCredit, log.Vehicle.QBOClass))
+	}
+
 	return csvSlice
 }
+
+// qboInvoiceLine returns one QBO bulk invoice csv row with the columns in getQBOInvoicesHeaderRow order
+func qboInvoiceLine(invoiceNo string, customer string, invoiceDate time.Time, dueDate time.Time,
+	item string, description string, amount models.USD, class string) []string {
+	return []string{
+		invoiceNo,                             // *InvoiceNo
+		customer,                              // *Customer - use QBOName if not empty, else member name
+		invoiceDate.Format(config.DateLayout), // *InvoiceDate - last date of Mileage Log's month
+		dueDate.Format(config.DateLayout),     // *DueDate - 15 days from InvoiceDate
+		"Net 15",                              // Terms
+		"",                                    // Location - blank
+		"",                                    // Memo - blank
+		item,                                  // Item(Product/Service)
+		description,                           // ItemDescription - name of vehicle
+		"1",                                   // ItemQuantity
+		amount.SignedString(),                 // ItemRate
+		amount.SignedString(),                 // *ItemAmount
+		class,                                 // Class
+		"",                                    // Shipping address
+		"",                                    // Ship via - blank
+		"",                                    // Shipping date - blank
+		"",                                    // Tracking no. - blank
+		"",                                    // Shipping Charge - blank
+		"",                                    // Service Date - blank
+	}
+}
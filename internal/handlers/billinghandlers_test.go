@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+func TestNetQBOFuelCredits(t *testing.T) {
+	tests := []struct {
+		name     string
+		billings []models.MemberMileageLogBilling
+		// credits are the expected fuel credits per log, nil if nothing is invoiced
+		credits []models.USD
+	}{
+		{
+			name: "credit below charges is kept",
+			billings: []models.MemberMileageLogBilling{
+				{RegularTripsCost: models.ToUSD(50), FuelCredit: models.ToUSD(20)},
+			},
+			credits: []models.USD{models.ToUSD(20)},
+		},
+		{
+			name: "credit only is not invoiced",
+			billings: []models.MemberMileageLogBilling{
+				{FuelCredit: models.ToUSD(40)},
+				{},
+			},
+			credits: nil,
+		},
+		{
+			name: "credit is capped at the charges across logs",
+			billings: []models.MemberMileageLogBilling{
+				{FuelCredit: models.ToUSD(40)},
+				{RegularTripsCost: models.ToUSD(10), FuelSurchargeCost: models.ToUSD(5), FuelCredit: models.ToUSD(10)},
+			},
+			credits: []models.USD{models.ToUSD(15), 0},
+		},
+	}
+
+	for _, tt := range tests {
+		got := netQBOFuelCredits(tt.billings)
+		if tt.credits == nil {
+			if got != nil {
+				t.Errorf("%s: expected no invoice, got %v", tt.name, got)
+			}
+			continue
+		}
+
+		if len(got) != len(tt.credits) {
+			t.Fatalf("%s: expected %d billings, got %d", tt.name, len(tt.credits), len(got))
+		}
+
+		var total models.USD
+		for i, b := range got {
+			if b.FuelCredit != tt.credits[i] {
+				t.Errorf("%s: log %d: expected credit %d, got %d", tt.name, i, tt.credits[i], b.FuelCredit)
+			}
+			total += b.Total()
+		}
+
+		if total < 0 {
+			t.Errorf("%s: invoice total is negative: %d", tt.name, total)
+		}
+	}
+}
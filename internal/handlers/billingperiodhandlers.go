@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// BillingPeriodFinalize snapshots every member's charges for the year/month, posts them to the member ledgers
+// and locks its mileage logs
+func (m *Repository) BillingPeriodFinalize(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	year, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	month, err := strconv.Atoi(exploded[3])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	period, err := m.DB.GetBillingPeriod(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if period.IsLocked() {
+		m.App.Session.Put(r.Context(), "warning", "Billing period is already finalized")
+		http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d", year, month), http.StatusSeeOther)
+		return
+	}
+
+	billings, err := m.getMonthMileageLogBillings(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// reconciliation errors block finalizing unless the user overrides them
+	var logs []models.MileageLog
+	for _, b := range billings {
+		logs = append(logs, b.Log)
+	}
+
+	recs, err := m.getMonthReconciliations(logs)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	reason := ""
+	if errorCount := reconciliationErrorCount(recs); errorCount > 0 {
+		if r.Form.Get("override") != "true" {
+			m.App.Session.Put(r.Context(), "error",
+				fmt.Sprintf("Billing period has %d reconciliation errors. Fix them or finalize with the override checked", errorCount))
+			http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d/reconciliation", year, month), http.StatusSeeOther)
+			return
+		}
+
+		reason = fmt.Sprintf("Finalized with %d reconciliation errors overridden", errorCount)
+	}
+
+	period.Charges = billingPeriodCharges(billings)
+
+	// ledger entries are posted in the same transaction, so a failure leaves the period open with the ledger unchanged
+	var entries []models.LedgerEntry
+	for _, b := range billings {
+		entries = append(entries, models.LedgerEntriesFromBilling(b)...)
+	}
+
+	err = m.DB.FinalizeBillingPeriod(period, entries, m.App.Session.GetInt(r.Context(), "user_id"), reason)
+	if errors.Is(err, models.ErrBillingPeriodStatus) {
+		// finalized by someone else since the check above
+		m.App.Session.Put(r.Context(), "warning", "Billing period is already finalized")
+		http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d", year, month), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Finalized billing period")
+	http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d", year, month), http.StatusSeeOther)
+}
+
+// BillingPeriodReopen re-opens a finalized year/month so its mileage logs can be edited. A reason is required
+func (m *Repository) BillingPeriodReopen(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	year, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	month, err := strconv.Atoi(exploded[3])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	form.Required("reason")
+
+	if !form.Valid() {
+		m.App.Session.Put(r.Context(), "error", "A reason is required to re-open a billing period")
+		http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d", year, month), http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.ReopenBillingPeriod(year, month, m.App.Session.GetInt(r.Context(), "user_id"), strings.TrimSpace(r.Form.Get("reason")))
+	if errors.Is(err, models.ErrBillingPeriodStatus) {
+		m.App.Session.Put(r.Context(), "error", "Only a finalized billing period can be re-opened")
+		http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d", year, month), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Re-opened billing period")
+	http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d", year, month), http.StatusSeeOther)
+}
+
+// getMonthMileageLogBillings returns the billing for each mileage log in the year/month. Every member who rode
+// or bought fuel that month is billed, including members who have since been made inactive
+func (m *Repository) getMonthMileageLogBillings(year int, month int) ([]models.MileageLogBilling, error) {
+	var billings []models.MileageLogBilling
+
+	logs, err := m.DB.GetMileageLogsByYearMonth(year, month)
+	if err != nil {
+		return billings, err
+	}
+
+	members, err := m.DB.GetMemberByActive(true)
+	if err != nil {
+		return billings, err
+	}
+
+	billed := make(map[int]bool)
+	for _, mem := range members {
+		billed[mem.ID] = true
+	}
+
+	addMember := func(mem models.Member) {
+		if !billed[mem.ID] {
+			billed[mem.ID] = true
+			members = append(members, mem)
+		}
+	}
+
+	for _, l := range logs {
+		for _, t := range l.Trips {
+			for _, r := range t.Riders {
+				addMember(r.Member)
+			}
+		}
+
+		for _, f := range l.FuelPurchases {
+			addMember(f.Member)
+		}
+	}
+
+	for _, l := range logs {
+		b, err := m.getMileageLogBilling(l, members)
+		if err != nil {
+			return billings, err
+		}
+
+		billings = append(billings, b)
+	}
+
+	return billings, nil
+}
+
+// billingPeriodCharges returns a charge for every member with a charge or credit on each mileage log billing
+func billingPeriodCharges(billings []models.MileageLogBilling) []models.BillingPeriodCharge {
+	var charges []models.BillingPeriodCharge
+
+	for _, l := range billings {
+		for _, b := range l.MemberBills {
+			if b.RegularTripsCost == 0 && b.LongDistanceTripsCost == 0 && b.FuelSurchargeCost == 0 && b.FuelCredit == 0 {
+				continue
+			}
+
+			charges = append(charges, models.BillingPeriodCharge{
+				Member:                b.Member,
+				Vehicle:               l.Log.Vehicle,
+				MileageLogID:          l.Log.ID,
+				RegularTripsCost:      b.RegularTripsCost,
+				LongDistanceTripsCost: b.LongDistanceTripsCost,
+				FuelSurchargeCost:     b.FuelSurchargeCost,
+				FuelCredit:            b.FuelCredit,
+			})
+		}
+	}
+
+	return charges
+}
+
+// mileageLogLocked checks if the mileage log's billing period is finalized and, if it is,
+// sends the user back to redirectURL with an error. It returns true when the request has been handled
+func (m *Repository) mileageLogLocked(w http.ResponseWriter, r *http.Request, logID int, redirectURL string) bool {
+	period, err := m.DB.GetBillingPeriodByMileageLogID(logID)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return true
+	}
+
+	return m.billingPeriodLocked(w, r, period, redirectURL)
+}
+
+// billingPeriodLocked sends the user back to redirectURL with an error if the billing period is finalized.
+// It returns true when the request has been handled
+func (m *Repository) billingPeriodLocked(w http.ResponseWriter, r *http.Request, period models.BillingPeriod, redirectURL string) bool {
+	if !period.IsLocked() {
+		return false
+	}
+
+	m.App.Session.Put(r.Context(), "error",
+		fmt.Sprintf("Billing period %04d-%02d is %s. Re-open it from the billing summary to make changes", period.Year, period.Month, period.Status))
+
+	lockedRedirect(w, r, redirectURL)
+	return true
+}
+
+// lockedRedirect sends the user back to redirectURL after a change was refused.
+// HTMX requests are redirected with the HX-Redirect header so the whole page is reloaded
+func lockedRedirect(w http.ResponseWriter, r *http.Request, redirectURL string) {
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", redirectURL)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/mailer"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+	"github.com/cxt314/drvc-go/internal/storage"
+)
+
+// Compliance lists the insurance, registration and inspection documents of active vehicles that have expired
+// or expire within ?days=N, defaulting to the configured warning days, with a form to email the list
+func (m *Repository) Compliance(w http.ResponseWriter, r *http.Request) {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days < 0 {
+		days = m.App.ComplianceWarningDays
+	}
+
+	alerts, err := m.getComplianceAlerts(days)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	user, err := m.DB.GetUserByID(m.App.Session.GetInt(r.Context(), "user_id"))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["alerts"] = alerts
+
+	stringmap := make(map[string]string)
+	stringmap["email"] = user.Email
+
+	intmap := make(map[string]int)
+	intmap["days"] = days
+
+	render.Template(w, r, "compliance.page.tmpl", &models.TemplateData{
+		Data:      data,
+		StringMap: stringmap,
+		IntMap:    intmap,
+	})
+}
+
+// ComplianceDigestPost emails the list of vehicle documents expiring within the posted number of days
+// to each of the comma separated addresses posted
+func (m *Repository) ComplianceDigestPost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	days, err := strconv.Atoi(r.Form.Get("days"))
+	if err != nil || days < 0 {
+		days = m.App.ComplianceWarningDays
+	}
+
+	redirectURL := fmt.Sprintf("/compliance?days=%d", days)
+
+	to, err := parseAddressList(r.Form.Get("to"))
+	if err != nil || len(to) == 0 {
+		m.App.Session.Put(r.Context(), "error", "Enter the email addresses to send the digest to, separated by commas")
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+
+	err = m.SendComplianceDigest(to, days)
+	if err != nil {
+		m.App.Session.Put(r.Context(), "error", "Could not send the digest: "+err.Error())
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Sent the digest to "+strings.Join(to, ", "))
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+// VehicleCompliance displays a vehicle's insurance, registration and inspection documents with the form to add them
+func (m *Repository) VehicleCompliance(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getVehicleComplianceTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td.Form = forms.New(nil)
+
+	render.Template(w, r, "vehicle-compliance.page.tmpl", td)
+}
+
+// ComplianceDocumentPost processes the POST request for adding a compliance document to a vehicle,
+// along with an optional scan or photo of it
+func (m *Repository) ComplianceDocumentPost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getVehicleComplianceTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// the size of the upload is limited by the request size middleware
+	err = r.ParseMultipartForm(storage.MaxSize)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	form := forms.New(r.PostForm)
+	// do form validation checks
+	form.Required("document-type", "expires-date")
+	for _, field := range []string{"issued-date", "expires-date"} {
+		if _, err := time.Parse(config.DateLayout, form.Get(field)); form.Get(field) != "" && err != nil {
+			form.Errors.Add(field, "Enter a valid date")
+		}
+	}
+
+	d := models.ComplianceDocument{}
+
+	if files := r.MultipartForm.File["file"]; len(files) > 0 {
+		name, data, err := readUploadedFile(files[0])
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+
+		contentType, err := storage.Check(data)
+		if err != nil {
+			form.Errors.Add("file", err.Error())
+		} else if form.Valid() {
+			d.File.Key, err = m.Store.Put(data)
+			if err != nil {
+				helpers.ServerError(w, err)
+				return
+			}
+			d.File.Filename = name
+			d.File.ContentType = contentType
+			d.File.Size = len(data)
+		}
+	}
+
+	if !form.Valid() {
+		td.Form = form
+		render.Template(w, r, "vehicle-compliance.page.tmpl", td)
+		return
+	}
+
+	err = helpers.ParseFormToComplianceDocument(r, &d, td.Data["vehicle"].(models.Vehicle))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	_, err = m.DB.InsertComplianceDocument(d)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.refreshComplianceAlerts()
+
+	m.App.Session.Put(r.Context(), "flash", fmt.Sprintf("Added %s document successfully", d.DocumentType))
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/compliance", id), http.StatusSeeOther)
+}
+
+// ComplianceDocumentFile serves the uploaded copy of a compliance document
+func (m *Repository) ComplianceDocumentFile(w http.ResponseWriter, r *http.Request) {
+	d, ok := m.getVehicleComplianceDocument(w, r)
+	if !ok {
+		return
+	}
+
+	if !d.HasFile() {
+		http.NotFound(w, r)
+		return
+	}
+
+	m.serveStoredFile(w, r, d.File)
+}
+
+// ComplianceDocumentDelete deletes a compliance document, and its file from storage if nothing else uses it
+func (m *Repository) ComplianceDocumentDelete(w http.ResponseWriter, r *http.Request) {
+	d, ok := m.getVehicleComplianceDocument(w, r)
+	if !ok {
+		return
+	}
+
+	unused, err := m.DB.DeleteComplianceDocument(d.Vehicle.ID, d.ID)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if unused {
+		err = m.Store.Delete(d.File.Key)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+	}
+
+	m.refreshComplianceAlerts()
+
+	m.App.Session.Put(r.Context(), "flash", fmt.Sprintf("Deleted %s document", d.DocumentType))
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/compliance", d.Vehicle.ID), http.StatusSeeOther)
+}
+
+// getVehicleComplianceDocument returns the compliance document for /vehicles/{id}/compliance/{document_id}/...
+// It responds with not found and returns false if the document isn't the vehicle's
+func (m *Repository) getVehicleComplianceDocument(w http.ResponseWriter, r *http.Request) (models.ComplianceDocument, bool) {
+	exploded := strings.Split(r.URL.Path, "/")
+	vehicleID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return models.ComplianceDocument{}, false
+	}
+	id, err := strconv.Atoi(exploded[4])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return models.ComplianceDocument{}, false
+	}
+
+	d, err := m.DB.GetComplianceDocumentByID(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && d.Vehicle.ID != vehicleID) {
+		http.NotFound(w, r)
+		return d, false
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return d, false
+	}
+
+	return d, true
+}
+
+// getComplianceAlerts returns the documents of active vehicles that have expired or expire within days
+func (m *Repository) getComplianceAlerts(days int) ([]models.ComplianceAlert, error) {
+	docs, err := m.DB.GetActiveVehicleComplianceDocuments()
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewComplianceAlerts(docs, time.Now(), days), nil
+}
+
+// RefreshComplianceAlerts recounts the vehicle documents that have expired or expire within the warning days
+// for the navbar warning, and returns them
+func (m *Repository) RefreshComplianceAlerts() ([]models.ComplianceAlert, error) {
+	alerts, err := m.getComplianceAlerts(m.App.ComplianceWarningDays)
+	if err != nil {
+		return nil, err
+	}
+
+	m.App.ComplianceAlerts.Store(int64(len(alerts)))
+
+	return alerts, nil
+}
+
+// refreshComplianceAlerts recounts the alerts after a document changes. The change has already been saved,
+// so a failure is only logged and the count is corrected by the next daily refresh
+func (m *Repository) refreshComplianceAlerts() {
+	_, err := m.RefreshComplianceAlerts()
+	if err != nil {
+		m.App.ErrorLog.Println("counting compliance alerts:", err)
+	}
+}
+
+// SendComplianceDigest emails each address the list of vehicle documents that have expired or expire within days
+// and records the send, so the weekly digest isn't sent again the same week
+func (m *Repository) SendComplianceDigest(to []string, days int) error {
+	alerts, err := m.getComplianceAlerts(days)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range to {
+		err = m.Mailer.Send(mailer.Message{
+			To:      address,
+			Subject: models.ComplianceDigestSubject(alerts),
+			Body:    models.ComplianceDigestBody(alerts, days),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.DB.InsertComplianceDigest(to, time.Now())
+}
+
+// parseAddressList returns the email addresses in a comma separated list
+func parseAddressList(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	addresses, err := mail.ParseAddressList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	var to []string
+	for _, a := range addresses {
+		to = append(to, a.Address)
+	}
+
+	return to, nil
+}
+
+func (m *Repository) getVehicleComplianceTemplateData(vehicleID int) (*models.TemplateData, error) {
+	td := models.TemplateData{}
+
+	v, err := m.DB.GetVehicleByID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	docs, err := m.DB.GetComplianceDocumentsByVehicleID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	// documents replaced by a renewal no longer matter, the rest show whether they are expiring as of today
+	statuses := make(map[string]string)
+	for _, d := range docs {
+		statuses[strconv.Itoa(d.ID)] = models.ComplianceReplaced
+	}
+	for _, d := range models.LatestComplianceDocuments(docs) {
+		statuses[strconv.Itoa(d.ID)] = d.Status(time.Now(), m.App.ComplianceWarningDays)
+	}
+
+	data := make(map[string]interface{})
+	data["vehicle"] = v
+	data["documents"] = docs
+	data["document-types"] = models.ComplianceTypes
+
+	td.Data = data
+	td.StringMap = statuses
+
+	return &td, nil
+}
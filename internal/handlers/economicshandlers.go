@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// VehicleEconomics displays a vehicle's cost of ownership against what it billed by year, and by month for the
+// year given as ?year=YYYY, defaulting to the latest year with activity
+func (m *Repository) VehicleEconomics(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.URL.Path, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	year, _ := strconv.Atoi(r.URL.Query().Get("year"))
+
+	td, err := m.getVehicleEconomicsTemplateData(id, year)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td.Form = forms.New(nil)
+
+	render.Template(w, r, "vehicle-economics.page.tmpl", td)
+}
+
+// VehicleExpensePost processes the POST request for adding an expense such as insurance or registration to a vehicle
+func (m *Repository) VehicleExpensePost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getVehicleEconomicsTemplateData(id, 0)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	// do form validation checks
+	form.Required("expense-date", "category", "amount")
+	if _, err := time.Parse(config.DateLayout, form.Get("expense-date")); form.Get("expense-date") != "" && err != nil {
+		form.Errors.Add("expense-date", "Enter a valid date")
+	}
+	if form.Get("coverage-months") != "" {
+		form.IsNumber("coverage-months")
+		if months, _ := strconv.Atoi(form.Get("coverage-months")); months < 1 {
+			form.Errors.Add("coverage-months", "Enter at least 1 month")
+		}
+	}
+
+	if !form.Valid() {
+		td.Form = form
+		render.Template(w, r, "vehicle-economics.page.tmpl", td)
+		return
+	}
+
+	x := models.VehicleExpense{}
+	err = helpers.ParseFormToVehicleExpense(r, &x, td.Data["vehicle"].(models.Vehicle))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.InsertVehicleExpense(x)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Added expense successfully")
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/economics?year=%d", id, x.ExpenseDate.Year()), http.StatusSeeOther)
+}
+
+// VehicleExpenseDelete deletes a vehicle expense and returns to the vehicle's economics
+func (m *Repository) VehicleExpenseDelete(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	vehicleID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(exploded[5])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.DeleteVehicleExpense(vehicleID, id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Deleted expense")
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/economics", vehicleID), http.StatusSeeOther)
+}
+
+// getVehicleEconomicsTemplateData builds a vehicle's economics up to today. The months shown are for year,
+// or the latest year with activity if year is 0
+func (m *Repository) getVehicleEconomicsTemplateData(vehicleID int, year int) (*models.TemplateData, error) {
+	td := models.TemplateData{}
+
+	v, err := m.DB.GetVehicleByID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	logs, err := m.DB.GetMileageLogsByVehicleID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	fuel, err := m.DB.GetFuelPurchasesByVehicleID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	records, err := m.DB.GetServiceRecordsByVehicleID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	expenses, err := m.DB.GetVehicleExpensesByVehicleID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	e := models.NewVehicleEconomics(v, logs, fuel, records, expenses, time.Now())
+
+	if year == 0 {
+		year = time.Now().Year()
+		if len(e.Years) > 0 {
+			year = e.Years[len(e.Years)-1].Year
+		}
+	}
+
+	data := make(map[string]interface{})
+	data["vehicle"] = v
+	data["economics"] = e
+	data["months"] = e.MonthsOf(year)
+	data["expenses"] = expenses
+	data["expense-categories"] = models.ExpenseCategories
+	data["today"] = time.Now().Format(config.DateLayout)
+
+	intmap := make(map[string]int)
+	intmap["year"] = year
+	intmap["default-depreciation-years"] = models.DefaultDepreciationMonths / 12
+
+	td.Data = data
+	td.IntMap = intmap
+
+	return &td, nil
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// FuelPurchases displays the fuel purchases for a mileage log's vehicle & month and the form to add one
+func (m *Repository) FuelPurchases(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getFuelPurchasesTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td.Form = forms.New(nil)
+
+	render.Template(w, r, "fuel-purchases.page.tmpl", td)
+}
+
+// FuelPurchasePost processes the POST request for adding a fuel purchase to a mileage log's vehicle & month
+func (m *Repository) FuelPurchasePost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if m.mileageLogLocked(w, r, id, fmt.Sprintf("/mileage-logs/%d/fuel", id)) {
+		return
+	}
+
+	td, err := m.getFuelPurchasesTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	log := td.Data["mileage-log"].(models.MileageLog)
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	// do form validation checks
+	form.Required("purchase-day", "member", "quantity", "unit", "amount")
+	if q, err := strconv.ParseFloat(form.Get("quantity"), 64); err != nil || q <= 0 {
+		form.Errors.Add("quantity", "Enter a quantity greater than zero")
+	}
+	if models.StrToUSD(form.Get("amount")) <= 0 {
+		form.Errors.Add("amount", "Enter an amount greater than zero")
+	}
+
+	if !form.Valid() {
+		td.Form = form
+		render.Template(w, r, "fuel-purchases.page.tmpl", td)
+		return
+	}
+
+	f := models.FuelPurchase{}
+	err = helpers.ParseFormToFuelPurchase(r, &f, log)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.InsertFuelPurchase(f)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Added fuel purchase successfully")
+	http.Redirect(w, r, fmt.Sprintf("/mileage-logs/%d/fuel", id), http.StatusSeeOther)
+}
+
+// FuelPurchaseDelete deletes a fuel purchase and returns to the mileage log's fuel purchases.
+// The purchase must be for the log's vehicle and month, and that month's billing period must be open
+func (m *Repository) FuelPurchaseDelete(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	logID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(exploded[4])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	log, err := m.DB.GetMileageLogByID(logID)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	f, err := m.DB.GetFuelPurchaseByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if f.Vehicle.ID != log.Vehicle.ID || f.PurchaseDate.Year() != log.Year || int(f.PurchaseDate.Month()) != log.Month {
+		http.NotFound(w, r)
+		return
+	}
+
+	period, err := m.DB.GetBillingPeriod(f.PurchaseDate.Year(), int(f.PurchaseDate.Month()))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if m.billingPeriodLocked(w, r, period, fmt.Sprintf("/mileage-logs/%d/fuel", logID)) {
+		return
+	}
+
+	err = m.DB.DeleteFuelPurchase(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Deleted fuel purchase")
+	http.Redirect(w, r, fmt.Sprintf("/mileage-logs/%d/fuel", logID), http.StatusSeeOther)
+}
+
+func (m *Repository) getFuelPurchasesTemplateData(mileageLogId int) (*models.TemplateData, error) {
+	td := models.TemplateData{}
+
+	// get mileage log from database, this populates the fuel purchases for the month
+	v, err := m.DB.GetMileageLogByID(mileageLogId)
+	if err != nil {
+		return &td, err
+	}
+
+	// get Members from database for selecting who paid
+	members, err := m.DB.GetMemberByActive(true)
+	if err != nil {
+		return &td, err
+	}
+
+	data := make(map[string]interface{})
+	data["mileage-log"] = v
+	data["members"] = members
+	data["fuel-units"] = models.FuelUnits
+	data["total-fuel-credit"] = calcTotalFuelCredit(v)
+
+	td.Data = data
+
+	return &td, nil
+}
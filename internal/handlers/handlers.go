@@ -7,10 +7,12 @@ import (
 	"github.com/cxt314/drvc-go/internal/driver"
 	"github.com/cxt314/drvc-go/internal/forms"
 	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/mailer"
 	"github.com/cxt314/drvc-go/internal/models"
 	"github.com/cxt314/drvc-go/internal/render"
 	"github.com/cxt314/drvc-go/internal/repository"
 	"github.com/cxt314/drvc-go/internal/repository/dbrepo"
+	"github.com/cxt314/drvc-go/internal/storage"
 )
 
 // Repo is the repository used by the handlers
@@ -18,15 +20,19 @@ var Repo *Repository
 
 // Repository is the repository type
 type Repository struct {
-	App *config.AppConfig
-	DB  repository.DatabaseRepo
+	App    *config.AppConfig
+	DB     repository.DatabaseRepo
+	Mailer mailer.Mailer
+	Store  storage.Store
 }
 
 // NewRepo creates a new repository
-func NewRepo(a *config.AppConfig, db *driver.DB) *Repository {
+func NewRepo(a *config.AppConfig, db *driver.DB, mail mailer.Mailer, store storage.Store) *Repository {
 	return &Repository{
-		App: a,
-		DB:  dbrepo.NewPostgresRepo(db.SQL, a),
+		App:    a,
+		DB:     dbrepo.NewPostgresRepo(db.SQL, a),
+		Mailer: mail,
+		Store:  store,
 	}
 }
 
@@ -36,9 +42,22 @@ func NewHandlers(r *Repository) {
 }
 
 // Home is the home page handler
+// Logged in users are warned about vehicle documents that have expired or are expiring soon
 func (m *Repository) Home(w http.ResponseWriter, r *http.Request) {
+	data := make(map[string]interface{})
+
+	if helpers.IsAuthenticated(r) {
+		alerts, err := m.getComplianceAlerts(m.App.ComplianceWarningDays)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+		data["compliance-alerts"] = alerts
+	}
 
-	render.Template(w, r, "home.page.tmpl", &models.TemplateData{})
+	render.Template(w, r, "home.page.tmpl", &models.TemplateData{
+		Data: data,
+	})
 }
 
 // About is the about page handler
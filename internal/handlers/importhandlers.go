@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cxt314/drvc-go/internal/csvimport"
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// maxImportSize is the largest CSV file that can be uploaded
+const maxImportSize = 1 << 20
+
+// MileageLogImport displays the form to upload a CSV of trips for a mileage log
+func (m *Repository) MileageLogImport(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	v, err := m.DB.GetMileageLogByID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["mileage-log"] = v
+	data["preview"] = csvimport.Preview{}
+
+	render.Template(w, r, "import-trips.page.tmpl", &models.TemplateData{
+		Form: forms.New(nil),
+		Data: data,
+	})
+}
+
+// MileageLogImportPost parses an uploaded CSV of trips and shows a preview of what will be inserted
+func (m *Repository) MileageLogImportPost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if m.tripsLocked(w, r, id, fmt.Sprintf("/mileage-logs/%d/edit-trips", id)) {
+		return
+	}
+
+	err = r.ParseMultipartForm(maxImportSize)
+	if err != nil {
+		m.App.Session.Put(r.Context(), "error", "Could not read the uploaded file")
+		http.Redirect(w, r, fmt.Sprintf("/mileage-logs/%d/import", id), http.StatusSeeOther)
+		return
+	}
+
+	file, _, err := r.FormFile("csv-file")
+	if err != nil {
+		m.App.Session.Put(r.Context(), "error", "Select a CSV file to import")
+		http.Redirect(w, r, fmt.Sprintf("/mileage-logs/%d/import", id), http.StatusSeeOther)
+		return
+	}
+	defer file.Close()
+
+	csvData, err := io.ReadAll(io.LimitReader(file, maxImportSize))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.renderImportPreview(w, r, id, string(csvData))
+}
+
+// MileageLogImportConfirm inserts the trips from a previewed CSV in one transaction.
+// The CSV is parsed again so the trips continue from the latest trip at the time of inserting
+func (m *Repository) MileageLogImportConfirm(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if m.tripsLocked(w, r, id, fmt.Sprintf("/mileage-logs/%d/edit-trips", id)) {
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	v, preview, err := m.parseTripImport(id, r.Form.Get("csv-data"))
+	if err != nil || !preview.Valid() {
+		m.renderImportPreview(w, r, id, r.Form.Get("csv-data"))
+		return
+	}
+
+	err = m.DB.InsertTrips(preview.Trips(v), m.App.Session.GetInt(r.Context(), "user_id"))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", fmt.Sprintf("Imported %d trips", len(preview.Rows)))
+	http.Redirect(w, r, fmt.Sprintf("/mileage-logs/%d/edit-trips", id), http.StatusSeeOther)
+}
+
+// renderImportPreview shows the parsed trips with unmatched names, mileage gaps and errors
+func (m *Repository) renderImportPreview(w http.ResponseWriter, r *http.Request, id int, csvData string) {
+	v, preview, err := m.parseTripImport(id, csvData)
+
+	form := forms.New(nil)
+	if err != nil {
+		form.Errors.Add("csv-file", err.Error())
+	}
+
+	data := make(map[string]interface{})
+	data["mileage-log"] = v
+	data["preview"] = preview
+	data["csv-data"] = csvData
+
+	render.Template(w, r, "import-trips.page.tmpl", &models.TemplateData{
+		Form: form,
+		Data: data,
+	})
+}
+
+// parseTripImport parses a CSV of trips for the mileage log, continuing from the log's latest trip.
+// Rider names are matched against all members and their aliases
+func (m *Repository) parseTripImport(id int, csvData string) (models.MileageLog, csvimport.Preview, error) {
+	v, err := m.DB.GetMileageLogByID(id)
+	if err != nil {
+		return v, csvimport.Preview{}, err
+	}
+
+	members, err := m.DB.AllMembers()
+	if err != nil {
+		return v, csvimport.Preview{}, err
+	}
+
+	preview, err := csvimport.Parse(strings.NewReader(csvData), v, calcLastOdometerValue(v), members)
+
+	return v, preview, err
+}
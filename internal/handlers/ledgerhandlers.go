@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// LedgerSummary displays every member's balance split into aging buckets
+func (m *Repository) LedgerSummary(w http.ResponseWriter, r *http.Request) {
+	members, err := m.DB.AllMembers()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	entries, err := m.DB.AllLedgerEntries()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// group entries by member, AllLedgerEntries returns them oldest first
+	memberEntries := make(map[int][]models.LedgerEntry)
+	for _, e := range entries {
+		memberEntries[e.Member.ID] = append(memberEntries[e.Member.ID], e)
+	}
+
+	var balances []models.MemberBalance
+	var total models.MemberBalance
+	now := time.Now()
+
+	for _, v := range members {
+		// inactive members are only shown if they have ledger history
+		if !v.Active && len(memberEntries[v.ID]) == 0 {
+			continue
+		}
+
+		mb := models.CalcMemberBalance(v, memberEntries[v.ID], now)
+		balances = append(balances, mb)
+
+		total.Balance += mb.Balance
+		total.Unapplied += mb.Unapplied
+		for i := range mb.Aging {
+			total.Aging[i] += mb.Aging[i]
+		}
+	}
+
+	data := make(map[string]interface{})
+	data["balances"] = balances
+	data["total"] = total
+	data["aging-labels"] = models.AgingLabels
+
+	render.Template(w, r, "ledger-summary.page.tmpl", &models.TemplateData{
+		Data: data,
+	})
+}
+
+// MemberLedger displays a member's ledger entries with running balances and the form to add an entry
+func (m *Repository) MemberLedger(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getMemberLedgerTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td.Form = forms.New(nil)
+
+	render.Template(w, r, "member-ledger.page.tmpl", td)
+}
+
+// MemberLedgerPost adds a payment, credit or adjustment to a member's ledger
+func (m *Repository) MemberLedgerPost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getMemberLedgerTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	// do form validation checks
+	form.Required("entry-date", "entry-type", "amount", "description")
+	if _, ok := models.LedgerEntryTypes[form.Get("entry-type")]; !ok {
+		form.Errors.Add("entry-type", "Select a payment, credit or adjustment")
+	}
+	if _, err := time.Parse(config.DateLayout, form.Get("entry-date")); err != nil {
+		form.Errors.Add("entry-date", "Enter a valid date")
+	}
+	if err := models.CheckEnteredAmount(form.Get("entry-type"), models.StrToUSD(form.Get("amount"))); err != nil {
+		form.Errors.Add("amount", errorDetail(err, models.ErrInvalidAmount))
+	}
+
+	if !form.Valid() {
+		td.Form = form
+		render.Template(w, r, "member-ledger.page.tmpl", td)
+		return
+	}
+
+	e := models.LedgerEntry{}
+	err = helpers.ParseFormToLedgerEntry(r, &e, td.Data["member"].(models.Member))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.InsertLedgerEntry(e)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", fmt.Sprintf("Added %s successfully", models.LedgerEntryTypes[e.EntryType]))
+	http.Redirect(w, r, fmt.Sprintf("/ledger/%d", id), http.StatusSeeOther)
+}
+
+// LedgerEntryDelete deletes a ledger entry entered by hand from the member's ledger. Entries generated from billings
+// are replaced by finalizing the billing period again
+func (m *Repository) LedgerEntryDelete(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	memberID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(exploded[4])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	e, err := m.DB.GetLedgerEntryByID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// the entry id in the url must belong to the member's ledger being viewed
+	if e.Member.ID != memberID {
+		m.App.Session.Put(r.Context(), "error", "Ledger entry not found on this member's ledger")
+		http.Redirect(w, r, fmt.Sprintf("/ledger/%d", memberID), http.StatusSeeOther)
+		return
+	}
+
+	if e.IsGenerated() {
+		m.App.Session.Put(r.Context(), "error", "Billing charges can only be changed by re-opening and finalizing the billing period")
+		http.Redirect(w, r, fmt.Sprintf("/ledger/%d", memberID), http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.DeleteLedgerEntry(memberID, id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Deleted ledger entry")
+	http.Redirect(w, r, fmt.Sprintf("/ledger/%d", memberID), http.StatusSeeOther)
+}
+
+func (m *Repository) getMemberLedgerTemplateData(memberID int) (*models.TemplateData, error) {
+	td := models.TemplateData{}
+
+	member, err := m.DB.GetMemberByID(memberID)
+	if err != nil {
+		return &td, err
+	}
+
+	entries, err := m.DB.GetLedgerEntriesByMemberID(memberID)
+	if err != nil {
+		return &td, err
+	}
+
+	models.CalcRunningBalances(entries)
+
+	data := make(map[string]interface{})
+	data["member"] = member
+	data["entries"] = entries
+	data["balance"] = models.CalcMemberBalance(member, entries, time.Now())
+	data["aging-labels"] = models.AgingLabels
+	data["entry-types"] = models.LedgerEntryTypes
+
+	td.Data = data
+
+	return &td, nil
+}
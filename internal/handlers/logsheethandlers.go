@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/logsheets"
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// logSheetMonthLayout is the format of the month input used to pick which month's log sheets to print
+const logSheetMonthLayout = "2006-01"
+
+// LogSheets downloads blank paper mileage logs for a month as a PDF. The month is given as ?month=YYYY-MM and
+// defaults to next month. With ?vehicle={id} only that vehicle's sheet is printed, otherwise every active vehicle's
+func (m *Repository) LogSheets(w http.ResponseWriter, r *http.Request) {
+	month, err := time.Parse(logSheetMonthLayout, r.URL.Query().Get("month"))
+	if err != nil {
+		month, _ = time.Parse(logSheetMonthLayout, nextMonth())
+	}
+	year := month.Year()
+
+	var vehicles []models.Vehicle
+	if vehicleID, err := strconv.Atoi(r.URL.Query().Get("vehicle")); err == nil && vehicleID > 0 {
+		v, err := m.DB.GetVehicleByID(vehicleID)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+		vehicles = append(vehicles, v)
+	} else {
+		vehicles, err = m.DB.GetVehicleByActive(true)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+	}
+
+	members, err := m.DB.GetMemberByActive(true)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// the month's logs, for vehicles whose log has already been started
+	logs, err := m.DB.GetMileageLogsByYearMonth(year, int(month.Month()))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	logsByVehicle := make(map[int]models.MileageLog)
+	for _, l := range logs {
+		logsByVehicle[l.Vehicle.ID] = l
+	}
+
+	var sheets []models.LogSheet
+	for _, v := range vehicles {
+		previous, err := m.DB.GetPreviousOdometer(v.ID, year, int(month.Month()))
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+
+		sheets = append(sheets, models.NewLogSheet(v, year, int(month.Month()), logsByVehicle[v.ID], previous, members))
+	}
+
+	// Set headers so browser will download the file
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=%s", logsheets.Filename(sheets)))
+
+	err = logsheets.WritePDF(w, sheets)
+	if err != nil {
+		helpers.ServerError(w, err)
+	}
+}
+
+// nextMonth returns next month as YYYY-MM, the month log sheets are usually printed for
+func nextMonth() string {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0).Format(logSheetMonthLayout)
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// MaintenanceDashboard lists the scheduled maintenance of every active vehicle, overdue and due soon first
+func (m *Repository) MaintenanceDashboard(w http.ResponseWriter, r *http.Request) {
+	vehicles, err := m.DB.GetVehicleByActive(true)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	var due []models.MaintenanceDue
+	for _, v := range vehicles {
+		vehicleDue, _, _, err := m.getMaintenanceDue(v)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+		due = append(due, vehicleDue...)
+	}
+	models.SortMaintenanceDue(due)
+
+	intmap := make(map[string]int)
+	for _, d := range due {
+		switch {
+		case d.IsOverdue():
+			intmap["overdue"]++
+		case d.IsDueSoon():
+			intmap["due-soon"]++
+		}
+	}
+
+	data := make(map[string]interface{})
+	data["due"] = due
+
+	render.Template(w, r, "maintenance.page.tmpl", &models.TemplateData{
+		Data:   data,
+		IntMap: intmap,
+	})
+}
+
+// VehicleMaintenance displays a vehicle's service records and maintenance schedules with the forms to add them
+func (m *Repository) VehicleMaintenance(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getVehicleMaintenanceTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td.Form = forms.New(nil)
+
+	render.Template(w, r, "vehicle-maintenance.page.tmpl", td)
+}
+
+// ServiceRecordPost processes the POST request for adding a service record to a vehicle
+func (m *Repository) ServiceRecordPost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getVehicleMaintenanceTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	// do form validation checks
+	form.Required("service-date", "odometer", "service-type")
+	form.IsNumber("odometer")
+	if _, err := time.Parse(config.DateLayout, form.Get("service-date")); form.Get("service-date") != "" && err != nil {
+		form.Errors.Add("service-date", "Enter a valid date")
+	}
+
+	if !form.Valid() {
+		td.Form = form
+		render.Template(w, r, "vehicle-maintenance.page.tmpl", td)
+		return
+	}
+
+	s := models.ServiceRecord{}
+	err = helpers.ParseFormToServiceRecord(r, &s, td.Data["vehicle"].(models.Vehicle))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.InsertServiceRecord(s)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Added service record successfully")
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/maintenance", id), http.StatusSeeOther)
+}
+
+// ServiceRecordDelete deletes a service record and returns to the vehicle's maintenance
+func (m *Repository) ServiceRecordDelete(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	vehicleID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(exploded[5])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.DeleteServiceRecord(vehicleID, id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Deleted service record")
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/maintenance", vehicleID), http.StatusSeeOther)
+}
+
+// MaintenanceSchedulePost adds a maintenance schedule to a vehicle, or changes the intervals of the
+// vehicle's existing schedule for the same service type
+func (m *Repository) MaintenanceSchedulePost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getVehicleMaintenanceTemplateData(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	// do form validation checks
+	form.Required("service-type")
+	for _, field := range []string{"interval-miles", "interval-months"} {
+		if form.Get(field) != "" {
+			form.IsNumber(field)
+		}
+	}
+	miles, _ := strconv.Atoi(form.Get("interval-miles"))
+	months, _ := strconv.Atoi(form.Get("interval-months"))
+	if miles < 0 || months < 0 || (miles == 0 && months == 0) {
+		form.Errors.Add("interval-miles", "Enter how many miles or months the service recurs after")
+	}
+
+	if !form.Valid() {
+		td.Form = form
+		render.Template(w, r, "vehicle-maintenance.page.tmpl", td)
+		return
+	}
+
+	s := models.MaintenanceSchedule{}
+	err = helpers.ParseFormToMaintenanceSchedule(r, &s, td.Data["vehicle"].(models.Vehicle))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.UpsertMaintenanceSchedule(s)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Saved maintenance schedule successfully")
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/maintenance", id), http.StatusSeeOther)
+}
+
+// MaintenanceScheduleDefaults gives a vehicle the default maintenance schedules it doesn't have yet
+func (m *Repository) MaintenanceScheduleDefaults(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	existing, err := m.DB.GetMaintenanceSchedulesByVehicleID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	has := make(map[string]bool)
+	for _, s := range existing {
+		has[s.ServiceType] = true
+	}
+
+	added := 0
+	for _, s := range models.DefaultMaintenanceSchedules {
+		if has[s.ServiceType] {
+			continue
+		}
+
+		s.Vehicle.ID = id
+		err = m.DB.UpsertMaintenanceSchedule(s)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+		added++
+	}
+
+	m.App.Session.Put(r.Context(), "flash", fmt.Sprintf("Added %d default maintenance schedule(s)", added))
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/maintenance", id), http.StatusSeeOther)
+}
+
+// MaintenanceScheduleDelete deletes a maintenance schedule and returns to the vehicle's maintenance
+func (m *Repository) MaintenanceScheduleDelete(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	vehicleID, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(exploded[5])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.DeleteMaintenanceSchedule(vehicleID, id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Deleted maintenance schedule")
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/maintenance", vehicleID), http.StatusSeeOther)
+}
+
+// getMaintenanceDue returns when each of a vehicle's maintenance schedules is next due, along with its service records
+// and the latest odometer reading from its mileage logs and service records
+func (m *Repository) getMaintenanceDue(v models.Vehicle) ([]models.MaintenanceDue, []models.ServiceRecord, int, error) {
+	schedules, err := m.DB.GetMaintenanceSchedulesByVehicleID(v.ID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	records, err := m.DB.GetServiceRecordsByVehicleID(v.ID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	logOdometer, err := m.DB.GetLatestOdometer(v.ID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	odometer := models.LatestOdometer(logOdometer, records)
+
+	var due []models.MaintenanceDue
+	for _, s := range schedules {
+		due = append(due, models.NewMaintenanceDue(v, s, records, odometer, time.Now()))
+	}
+
+	return due, records, odometer, nil
+}
+
+func (m *Repository) getVehicleMaintenanceTemplateData(vehicleID int) (*models.TemplateData, error) {
+	td := models.TemplateData{}
+
+	v, err := m.DB.GetVehicleByID(vehicleID)
+	if err != nil {
+		return &td, err
+	}
+
+	due, records, odometer, err := m.getMaintenanceDue(v)
+	if err != nil {
+		return &td, err
+	}
+	models.SortMaintenanceDue(due)
+
+	data := make(map[string]interface{})
+	data["vehicle"] = v
+	data["due"] = due
+	data["service-records"] = records
+	data["service-types"] = models.ServiceTypes
+	data["today"] = time.Now().Format(config.DateLayout)
+
+	intmap := make(map[string]int)
+	intmap["odometer"] = odometer
+
+	td.Data = data
+	td.IntMap = intmap
+
+	return &td, nil
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -84,8 +86,15 @@ func (m *Repository) MemberEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	history, err := m.DB.GetAuditEntriesByEntity(models.AuditMember, id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
 	data := make(map[string]interface{})
 	data["member"] = v
+	data["history"] = history
 
 	render.Template(w, r, "edit-member.page.tmpl", &models.TemplateData{
 		Form: forms.New(nil),
@@ -130,7 +139,7 @@ func (m *Repository) MemberEditPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = m.DB.UpdateMember(v)
+	err = m.DB.UpdateMember(v, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -150,7 +159,7 @@ func (m *Repository) MemberDeactivate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// update member set is_active to false
-	err = m.DB.UpdateMemberActiveByID(id, false)
+	err = m.DB.UpdateMemberActiveByID(id, false, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -177,14 +186,114 @@ func (m *Repository) AddAlias(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(html))
 }
 
-/*// MemberDelete deletes the member with the given id
+// MemberDelete lists everything that refers to the member with the given id before they can be deleted, and offers
+// to deactivate them or merge them into another member instead when they have history that deleting would lose
 func (m *Repository) MemberDelete(w http.ResponseWriter, r *http.Request) {
-	// exploded := strings.Split(r.RequestURI, "/")
-	// id, err := strconv.Atoi(exploded[2])
-	// if err != nil {
-	// 	helpers.ServerError(w, err)
-	// 	return
-	// }
-
-	render.Template(w, r, "member-list.page.tmpl", &models.TemplateData{})
-}*/
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	v, err := m.DB.GetMemberByID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	dependents, err := m.DB.GetMemberDependents(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	members, err := m.DB.AllMembers()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	var targets []models.Member
+	for _, x := range members {
+		if x.ID != id {
+			targets = append(targets, x)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Name < targets[j].Name
+	})
+
+	data := make(map[string]interface{})
+	data["dependents"] = dependents
+	data["merge-targets"] = targets
+	data["active"] = v.Active
+
+	render.Template(w, r, "confirm-delete.page.tmpl", &models.TemplateData{
+		Data:      data,
+		StringMap: map[string]string{"entity": "member", "name": v.Name, "path": fmt.Sprintf("/members/%d", id)},
+	})
+}
+
+// MemberDeletePost moves the member with the given id to the trash, unless they have history
+func (m *Repository) MemberDeletePost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.DeleteMember(id, m.App.Session.GetInt(r.Context(), "user_id"))
+	if errors.Is(err, models.ErrHasHistory) {
+		m.App.Session.Put(r.Context(), "error", errorDetail(err, models.ErrHasHistory)+
+			". Deactivate them or merge them into another member instead")
+		http.Redirect(w, r, fmt.Sprintf("/members/%d/delete", id), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Moved member to the trash. It can be restored from the Trash page")
+	http.Redirect(w, r, "/members", http.StatusSeeOther)
+}
+
+// MemberMergePost moves everything that refers to the member with the given id over to the member chosen in the
+// form and moves the member to the trash
+func (m *Repository) MemberMergePost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	intoID, err := strconv.Atoi(r.Form.Get("into"))
+	if err != nil {
+		m.App.Session.Put(r.Context(), "error", "Choose the member to merge into")
+		http.Redirect(w, r, fmt.Sprintf("/members/%d/delete", id), http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.MergeMember(id, intoID, m.App.Session.GetInt(r.Context(), "user_id"))
+	if errors.Is(err, models.ErrCannotMerge) {
+		m.App.Session.Put(r.Context(), "error", errorDetail(err, models.ErrCannotMerge))
+		http.Redirect(w, r, fmt.Sprintf("/members/%d/delete", id), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Merged member and moved them to the trash")
+	http.Redirect(w, r, fmt.Sprintf("/members/%d", intoID), http.StatusSeeOther)
+}
@@ -9,6 +9,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cxt314/drvc-go/internal/forms"
 	"github.com/cxt314/drvc-go/internal/helpers"
@@ -26,13 +27,28 @@ func (m *Repository) MileageLogList(w http.ResponseWriter, r *http.Request) {
 	data := make(map[string]interface{})
 	data["vehicles"] = vehicles
 
+	// with a status filter, every vehicle's logs in that status are listed
+	status := statusFilter(r)
+	data["status"] = status
+	data["statuses"] = models.MileageLogStatuses
+	data["log-sheet-month"] = nextMonth()
+
+	if status != "" {
+		logs, err := m.DB.AllMileageLogs()
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+		data["mileage-logs"] = models.FilterMileageLogsByStatus(logs, status)
+	}
+
 	render.Template(w, r, "mileage-log-list.page.tmpl", &models.TemplateData{
 		Data: data,
 	})
 }
 
 func (m *Repository) MileageLogListByVehicle(w http.ResponseWriter, r *http.Request) {
-	exploded := strings.Split(r.RequestURI, "/")
+	exploded := strings.Split(r.URL.Path, "/")
 	id, err := strconv.Atoi(exploded[3])
 	if err != nil {
 		helpers.ServerError(w, err)
@@ -63,7 +79,12 @@ func (m *Repository) MileageLogListByVehicle(w http.ResponseWriter, r *http.Requ
 		helpers.ServerError(w, err)
 		return
 	}
-	data["mileage-logs"] = logs
+
+	status := statusFilter(r)
+	data["status"] = status
+	data["statuses"] = models.MileageLogStatuses
+	data["mileage-logs"] = models.FilterMileageLogsByStatus(logs, status)
+	data["log-sheet-month"] = nextMonth()
 
 	render.Template(w, r, "mileage-log-list.page.tmpl", &models.TemplateData{
 		Data: data,
@@ -117,7 +138,18 @@ func (m *Repository) MileageLogCreatePost(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	_, err = m.DB.InsertMileageLog(v)
+	// no new logs in a finalized period
+	period, err := m.DB.GetBillingPeriod(v.Year, v.Month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if m.billingPeriodLocked(w, r, period, "/new-mileage-log") {
+		return
+	}
+
+	_, err = m.DB.InsertMileageLog(v, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -167,6 +199,10 @@ func (m *Repository) MileageLogEditPost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if m.mileageLogLocked(w, r, id, fmt.Sprintf("/mileage-logs/%d", id)) {
+		return
+	}
+
 	// get mileage log from database
 	v, err := m.DB.GetMileageLogByID(id)
 	if err != nil {
@@ -181,6 +217,17 @@ func (m *Repository) MileageLogEditPost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// the log can't be moved into a finalized billing period either
+	period, err := m.DB.GetBillingPeriod(v.Year, v.Month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if m.billingPeriodLocked(w, r, period, fmt.Sprintf("/mileage-logs/%d", id)) {
+		return
+	}
+
 	form := forms.New(r.PostForm)
 	// do form validation checks
 
@@ -203,7 +250,7 @@ func (m *Repository) MileageLogEditPost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = m.DB.UpdateMileageLog(v)
+	err = m.DB.UpdateMileageLog(v, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -221,15 +268,91 @@ func (m *Repository) MileageLogDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = m.DB.DeleteMileageLog(id)
+	if m.mileageLogLocked(w, r, id, fmt.Sprintf("/mileage-logs/%d", id)) {
+		return
+	}
+
+	err = m.DB.DeleteMileageLog(id, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
 	}
 
+	m.App.Session.Put(r.Context(), "flash", "Moved mileage log to the trash. It can be restored from the Trash page")
 	http.Redirect(w, r, "/mileage-logs", http.StatusSeeOther)
 }
 
+// MileageLogStatusPost moves a mileage log through review: a draft is submitted by the vehicle steward,
+// approved by the treasurer, or sent back to draft for changes
+func (m *Repository) MileageLogStatusPost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	redirectURL := fmt.Sprintf("/mileage-logs/%d/edit-trips", id)
+
+	if m.mileageLogLocked(w, r, id, redirectURL) {
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	v, err := m.DB.GetMileageLogByID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	status := r.Form.Get("status")
+	err = v.CanChangeStatus(status)
+	if err != nil {
+		m.App.Session.Put(r.Context(), "error", capitalize(err.Error()))
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.UpdateMileageLogStatus(id, status, m.App.Session.GetInt(r.Context(), "user_id"))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", fmt.Sprintf("Mileage log is now %s", status))
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+// tripsLocked checks if the mileage log's trips can't be changed, either because its billing period is
+// finalized or because it has been submitted for review, and if so sends the user back to redirectURL with an error.
+// A submitted or approved log has to be sent back to draft first so it is reviewed again after the change.
+// It returns true when the request has been handled
+func (m *Repository) tripsLocked(w http.ResponseWriter, r *http.Request, logID int, redirectURL string) bool {
+	if m.mileageLogLocked(w, r, logID, redirectURL) {
+		return true
+	}
+
+	v, err := m.DB.GetMileageLogByID(logID)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return true
+	}
+
+	if v.Status == models.MileageLogDraft {
+		return false
+	}
+
+	m.App.Session.Put(r.Context(), "error",
+		fmt.Sprintf("Mileage log is %s. Send it back to draft to change its trips", v.Status))
+	lockedRedirect(w, r, redirectURL)
+	return true
+}
+
 func (m *Repository) MileageLogCSV(w http.ResponseWriter, r *http.Request) {
 	exploded := strings.Split(r.RequestURI, "/")
 	id, err := strconv.Atoi(exploded[2])
@@ -282,7 +405,7 @@ func convertMileageLogToCSVRaw(log models.MileageLog) [][]string {
 		"Total Miles:", strconv.Itoa(log.Distance)}
 	csvSlice = append(csvSlice, infoRow)
 
-	headerRow := []string{"Date", "End mileage 3-digits", "Miles", "Destination", "Purpose", "Riders"}
+	headerRow := append([]string{}, models.MileageLogColumns...)
 	csvSlice = append(csvSlice, headerRow)
 
 	// reverse Trips to get trips from earliest to latest
@@ -300,7 +423,7 @@ func convertMileageLogToCSVRaw(log models.MileageLog) [][]string {
 
 		// append riders to tripRow
 		for _, r := range t.Riders {
-			tripRow = append(tripRow, r.Name)
+			tripRow = append(tripRow, r.Member.Name)
 		}
 
 		csvSlice = append(csvSlice, tripRow)
@@ -350,6 +473,18 @@ func (m *Repository) getTripEditTemplateData(mileageLogId int) (*models.Template
 
 	data["ld-days"] = models.LongDistanceDays
 
+	// the billing type decides if the trip form asks for hours or days
+	periodStart := time.Date(v.Year, time.Month(v.Month), 1, 0, 0, 0, 0, time.UTC)
+	billingType, _ := models.GetBillingType(v.Vehicle.RatesOn(periodStart).BillingType)
+	data["billing-type"] = billingType
+
+	period, err := m.DB.GetBillingPeriodByMileageLogID(mileageLogId)
+	if err != nil {
+		return &td, err
+	}
+
+	data["billing-period"] = period
+
 	td.Data = data
 
 	// calculate last odometer value from trips & mileage log start odometer
@@ -374,6 +509,21 @@ func (m *Repository) TripsEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// history of the log and its trips is only shown on the full page, not in HTMX partials
+	history, err := m.DB.GetAuditEntriesByMileageLogID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	td.Data["history"] = history
+
+	attachments, err := m.DB.GetAttachmentsByMileageLogID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	td.Data["attachments"] = attachments
+
 	td.Form = forms.New(nil)
 
 	render.Template(w, r, "edit-mileage-log-trips.page.tmpl", td)
@@ -390,6 +540,10 @@ func (m *Repository) AddTripPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if m.tripsLocked(w, r, id, fmt.Sprintf("/mileage-logs/%d/edit-trips", id)) {
+		return
+	}
+
 	// get mileage log from database
 	v, err := m.DB.GetMileageLogByID(id)
 	if err != nil {
@@ -404,6 +558,7 @@ func (m *Repository) AddTripPost(w http.ResponseWriter, r *http.Request) {
 	// do form validation checks
 	form.Required("trip-day", "start-mileage", "end-mileage", "end-mileage-input", "riders")
 	form.IsValidEndMileage("end-mileage", "start-mileage")
+	form.IsValidShareWeights("riders")
 
 	// if there were errors, only generate the partial form w/ errors
 	if !form.Valid() {
@@ -430,7 +585,7 @@ func (m *Repository) AddTripPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = m.DB.InsertTrip(t)
+	_, err = m.DB.InsertTrip(t, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -500,7 +655,8 @@ func (m *Repository) EditTrip(w http.ResponseWriter, r *http.Request) {
 
 }
 
-// EditTripPost is the HTMX route that updates a trip
+// EditTripPost is the HTMX route that updates a trip. If the change moves neighbouring trips,
+// a preview of those changes is returned and the user submits again to confirm
 func (m *Repository) EditTripPost(w http.ResponseWriter, r *http.Request) {
 
 	exploded := strings.Split(r.RequestURI, "/")
@@ -519,37 +675,21 @@ func (m *Repository) EditTripPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// get later trips in case we need to update Start and End Mileages
-	originalEndMileage := t.EndMileage
-	laterTrips, err := m.DB.GetLaterTrips(t)
-	if err != nil {
-		helpers.ServerError(w, err)
+	if m.tripsLocked(w, r, t.MileageLog.ID, fmt.Sprintf("/mileage-logs/%d/edit-trips", t.MileageLog.ID)) {
 		return
 	}
 
-	buf := new(bytes.Buffer)
-
 	form := forms.New(r.PostForm)
 
 	// do form validation checks
 	form.Required("trip-day", "start-mileage", "end-mileage", "riders")
-	// check for valid end mileage if there are any later trips
-	if len(laterTrips) > 0 {
-		form.IsValidNewEndMileage("end-mileage", t.StartMileage, originalEndMileage, laterTrips[0].EndMileage)
-	}
+	form.IsNumber("start-mileage")
+	form.IsNumber("end-mileage")
+	form.IsValidShareWeights("riders")
 
 	// if there were errors, re-generate the partial form w/ errors
 	if !form.Valid() {
-		td, err := m.getTripEditTemplateData(t.MileageLog.ID)
-		if err != nil {
-			helpers.ServerError(w, err)
-			return
-		}
-
-		td.Data["trip"] = t
-		td.Form = form
-		render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "tripEditTableSwapError", td)
-		buf.WriteTo(w)
+		m.renderTripSequenceForm(w, r, t, form, nil, nil)
 		return
 	}
 
@@ -567,40 +707,95 @@ func (m *Repository) EditTripPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// update later trips if end mileage changed
-	if len(laterTrips) > 0 {
-		err = m.updateFutureTripMileages(t, laterTrips, originalEndMileage)
-		if err != nil {
-			helpers.ServerError(w, err)
-			return
-		}
+	plan, err := models.PlanTripUpdate(v.Trips, t)
+	if err != nil {
+		form.Errors.Add("end-mileage", capitalize(err.Error()))
+		m.renderTripSequenceForm(w, r, t, form, nil, nil)
+		return
+	}
+
+	m.applyTripPlan(w, r, plan, form, nil)
+}
+
+// InsertTrip is the HTMX route that returns a form to insert a missed trip after a trip
+func (m *Repository) InsertTrip(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
 	}
 
-	// update trip
-	err = m.DB.UpdateTripByID(t)
+	after, err := m.DB.GetTripByID(id)
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
 	}
 
-	td, err := m.getTripEditTemplateData(t.MileageLog.ID)
+	m.renderTripSequenceForm(w, r, newTripAfter(after), forms.New(nil), nil, &after)
+}
+
+// InsertTripPost is the HTMX route that inserts a missed trip between existing trips.
+// Neighbouring trips give up any miles the new trip overlaps, after the user confirms a preview of the changes
+func (m *Repository) InsertTripPost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
 	}
 
-	td.Form = forms.New(nil)
+	w.Header().Set("Content-Type", "text/html")
 
-	// create HTMX response
-	render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "tripEditTableSwap", td)
-	render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "tripDistanceSwap", td)
-	render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "newTripFormSwap", td)
+	after, err := m.DB.GetTripByID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
 
-	buf.WriteTo(w)
+	if m.tripsLocked(w, r, after.MileageLog.ID, fmt.Sprintf("/mileage-logs/%d/edit-trips", after.MileageLog.ID)) {
+		return
+	}
+
+	form := forms.New(r.PostForm)
+
+	// do form validation checks
+	form.Required("trip-day", "start-mileage", "end-mileage", "riders")
+	form.IsNumber("start-mileage")
+	form.IsNumber("end-mileage")
+	form.IsValidShareWeights("riders")
 
+	if !form.Valid() {
+		m.renderTripSequenceForm(w, r, newTripAfter(after), form, nil, &after)
+		return
+	}
+
+	v, err := m.DB.GetMileageLogByID(after.MileageLog.ID)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	t := models.Trip{}
+	err = helpers.ParseFormToTrip(r, &t, v)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	plan, err := models.PlanTripInsert(v.Trips, t)
+	if err != nil {
+		form.Errors.Add("end-mileage", capitalize(err.Error()))
+		m.renderTripSequenceForm(w, r, t, form, nil, &after)
+		return
+	}
+
+	m.applyTripPlan(w, r, plan, form, &after)
 }
 
-// DeleteTrip is the htmx route that deletes a trip
+// DeleteTrip is the htmx route that deletes a trip. If the next trip has to move back to where the deleted trip
+// started, a preview is returned and the trip is only deleted from the /trip-delete/{id}/confirm route with the
+// confirm-plan key of that same preview
 func (m *Repository) DeleteTrip(w http.ResponseWriter, r *http.Request) {
 
 	exploded := strings.Split(r.RequestURI, "/")
@@ -610,7 +805,6 @@ func (m *Repository) DeleteTrip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	buf := new(bytes.Buffer)
 	w.Header().Set("Content-Type", "text/html")
 
 	// get trip by id
@@ -621,16 +815,81 @@ func (m *Repository) DeleteTrip(w http.ResponseWriter, r *http.Request) {
 	}
 
 	mileageLogID := t.MileageLog.ID
-	
-	// delete trip
-	err = m.DB.DeleteTripByID(t)
+
+	if m.tripsLocked(w, r, mileageLogID, fmt.Sprintf("/mileage-logs/%d/edit-trips", mileageLogID)) {
+		return
+	}
+
+	v, err := m.DB.GetMileageLogByID(mileageLogID)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	plan, err := models.PlanTripDelete(v.Trips, t)
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
 	}
 
-	// get template data 
-	td, err := m.getTripEditTemplateData(mileageLogID)
+	// the confirm route carries the key of the previewed plan, if the trips changed since then the new plan is previewed
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.applyTripPlan(w, r, plan, forms.New(nil), nil)
+}
+
+// applyTripPlan saves a planned trip change. Plans that move other trips are only saved once the user
+// has confirmed a preview of exactly the same changes
+func (m *Repository) applyTripPlan(w http.ResponseWriter, r *http.Request, plan models.TripPlan, form *forms.Form, after *models.Trip) {
+	if plan.HasCascades() && r.Form.Get("confirm-plan") != plan.Key() {
+		m.renderTripSequenceForm(w, r, plan.Target.Trip, form, &plan, after)
+		return
+	}
+
+	err := m.DB.ApplyTripPlan(plan, m.App.Session.GetInt(r.Context(), "user_id"))
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.renderTripTable(w, r, plan.Target.Trip.MileageLog.ID)
+}
+
+// renderTripSequenceForm returns the trip table with the edit or insert trip form open, showing
+// any errors and the preview of a trip plan waiting to be confirmed
+func (m *Repository) renderTripSequenceForm(w http.ResponseWriter, r *http.Request, t models.Trip, form *forms.Form, plan *models.TripPlan, after *models.Trip) {
+	logID := t.MileageLog.ID
+	if after != nil {
+		logID = after.MileageLog.ID
+	}
+
+	td, err := m.getTripEditTemplateData(logID)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td.Data["trip"] = t
+	if plan != nil {
+		td.Data["trip-plan"] = *plan
+	}
+	if after != nil {
+		td.Data["insert-after"] = *after
+	}
+	td.Form = form
+
+	buf := new(bytes.Buffer)
+	render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "tripEditTableSwapError", td)
+	buf.WriteTo(w)
+}
+
+// renderTripTable returns the updated trip table, trip distance and new trip form
+func (m *Repository) renderTripTable(w http.ResponseWriter, r *http.Request, logID int) {
+	td, err := m.getTripEditTemplateData(logID)
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -638,54 +897,40 @@ func (m *Repository) DeleteTrip(w http.ResponseWriter, r *http.Request) {
 
 	td.Form = forms.New(nil)
 
+	buf := new(bytes.Buffer)
+
 	// create HTMX response
 	render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "tripEditTableSwap", td)
 	render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "tripDistanceSwap", td)
 	render.PartialHTMX(buf, r, "edit-mileage-log-trips.page.tmpl", "newTripFormSwap", td)
 
 	buf.WriteTo(w)
-
 }
 
-// updateFutureTripMileages updates subsequent start & end mileages when a trip is updated
-// TODO: what if we need to reduce end mileage?
-func (m *Repository) updateFutureTripMileages(updated models.Trip, laterTrips []models.Trip, originalEndMileage int) error {
-	//fmt.Println("In updateFutureTripMileages")
-	diff := updated.EndMileage - originalEndMileage
-	//fmt.Printf("Mileage diff: %d\n", diff)
-
-	if diff == 1000 {
-		//fmt.Println("1000 mile roll-over")
-		// 1000 roll-over: increase start and end mileages of all future trips by 1000
-		for _, t := range laterTrips {
-			t.StartMileage += 1000
-			t.EndMileage += 1000
-
-			err := m.DB.UpdateTripByID(t)
-			//fmt.Printf("Trip ID: %d Mileage Log ID: %d StartMileage: %d EndMileage %d\n", t.ID, t.MileageLog.ID, t.StartMileage, t.EndMileage)
-			if err != nil {
-				return err
-			}
-		}
-
-	} else if diff != 0 && diff < 1000 && diff > -1000 {
-		//fmt.Println("Mileage changed by less than 1000")
-		// only update the next trip's starting mileage. next trip's END mileage should be greater than updated.EndMileage
-		nextTrip := laterTrips[0]
-		nextTrip.StartMileage = updated.EndMileage
-
-		if nextTrip.StartMileage > nextTrip.EndMileage {
-			return fmt.Errorf("start mileage (%d) cannot be greater than end mileage (%d)", nextTrip.StartMileage, nextTrip.EndMileage)
-		}
+// newTripAfter returns the starting values for a trip inserted after the given trip
+func newTripAfter(after models.Trip) models.Trip {
+	return models.Trip{
+		MileageLog:   after.MileageLog,
+		TripDate:     after.TripDate,
+		StartMileage: after.EndMileage,
+		EndMileage:   after.EndMileage,
+		BillingRate:  after.BillingRate,
+	}
+}
 
-		//fmt.Printf("Trip ID: %d Mileage Log ID: %d StartMileage: %d EndMileage %d\n", nextTrip.ID, nextTrip.MileageLog.ID, nextTrip.StartMileage, nextTrip.EndMileage)
-		err := m.DB.UpdateTripByID(nextTrip)
-		if err != nil {
-			return err
-		}
+// capitalize upper cases the first letter of an error message for display on a form
+func capitalize(s string) string {
+	if s == "" {
+		return s
 	}
 
-	return nil
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// errorDetail returns the detail an error wrapping sentinel adds, e.g. "vehicle has 3 trips" from
+// "has history: vehicle has 3 trips", capitalized for display to the user
+func errorDetail(err error, sentinel error) string {
+	return capitalize(strings.TrimPrefix(err.Error(), sentinel.Error()+": "))
 }
 
 func (m *Repository) MileageLogBilling(w http.ResponseWriter, r *http.Request) {
@@ -705,20 +950,40 @@ func (m *Repository) MileageLogBilling(w http.ResponseWriter, r *http.Request) {
 	render.Template(w, r, "mileage-log-billing.page.tmpl", td)
 }
 
-func (m *Repository) createMileageLogStub(v models.Vehicle, year int, month int) error {
+// createMileageLogStub creates an empty mileage log for a vehicle's year/month. The log starts at the
+// odometer reading the vehicle's previous mileage log ended at
+func (m *Repository) createMileageLogStub(v models.Vehicle, year int, month int, userID int) error {
 	var log models.MileageLog
 
+	odometer, err := m.DB.GetPreviousOdometer(v.ID, year, month)
+	if err != nil {
+		return err
+	}
+
 	log.Vehicle = v
 	log.Year = year
 	log.Month = month
 	log.Name = fmt.Sprintf("%s - %04d/%02d", v.Name, year, month)
-	log.StartOdometer = 0
-	log.EndOdometer = 0
+	log.StartOdometer = odometer
+	log.EndOdometer = odometer
 
-	_, err := m.DB.InsertMileageLog(log)
+	_, err = m.DB.InsertMileageLog(log, userID)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// statusFilter returns the mileage log review status in the request's status query parameter,
+// or an empty string if it isn't set or isn't a known status
+func statusFilter(r *http.Request) string {
+	status := r.URL.Query().Get("status")
+	for _, s := range models.MileageLogStatuses {
+		if s == status {
+			return status
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// BillingReconciliation displays the reconciliation of every mileage log in a year/month
+func (m *Repository) BillingReconciliation(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	year, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	month, err := strconv.Atoi(exploded[3])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	logs, err := m.DB.GetMileageLogsByYearMonth(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	recs, err := m.getMonthReconciliations(logs)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["title"] = fmt.Sprintf("Reconciliation: %04d-%02d", year, month)
+	data["back-url"] = fmt.Sprintf("/billings/%04d/%02d", year, month)
+	data["reconciliations"] = recs
+	data["error-count"] = reconciliationErrorCount(recs)
+
+	render.Template(w, r, "reconciliation.page.tmpl", &models.TemplateData{
+		Data: data,
+	})
+}
+
+// VehicleReconciliation displays the reconciliation of every mileage log for a vehicle, checking each month
+// continues from the one before it
+func (m *Repository) VehicleReconciliation(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	v, err := m.DB.GetVehicleByID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	logs, err := m.DB.GetMileageLogsByVehicleID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	recs := models.ReconcileMileageLogs(logs)
+
+	// show the latest month first
+	for i, j := 0, len(recs)-1; i < j; i, j = i+1, j-1 {
+		recs[i], recs[j] = recs[j], recs[i]
+	}
+
+	data := make(map[string]interface{})
+	data["title"] = fmt.Sprintf("Reconciliation: %s", v.Name)
+	data["back-url"] = fmt.Sprintf("/vehicles/%d", v.ID)
+	data["reconciliations"] = recs
+	data["error-count"] = reconciliationErrorCount(recs)
+
+	render.Template(w, r, "reconciliation.page.tmpl", &models.TemplateData{
+		Data: data,
+	})
+}
+
+// getMonthReconciliations reconciles each of a month's mileage logs against its vehicle's previous log
+func (m *Repository) getMonthReconciliations(logs []models.MileageLog) ([]models.LogReconciliation, error) {
+	var recs []models.LogReconciliation
+
+	for _, l := range logs {
+		prev, err := m.DB.GetPreviousMileageLog(l.Vehicle.ID, l.Year, l.Month)
+		if err != nil {
+			return recs, err
+		}
+
+		if prev.ID == 0 {
+			recs = append(recs, models.ReconcileMileageLog(l, nil))
+		} else {
+			recs = append(recs, models.ReconcileMileageLog(l, &prev))
+		}
+	}
+
+	return recs, nil
+}
+
+// reconciliationErrorCount returns the total number of errors across the reconciliations
+func reconciliationErrorCount(recs []models.LogReconciliation) int {
+	count := 0
+	for _, r := range recs {
+		count += r.ErrorCount()
+	}
+
+	return count
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/forms"
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// LongDistanceSettings displays the long distance rate history and the form to set new rates
+func (m *Repository) LongDistanceSettings(w http.ResponseWriter, r *http.Request) {
+	td, err := m.getLongDistanceSettingsTemplateData()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td.Form = forms.New(nil)
+
+	render.Template(w, r, "long-distance-settings.page.tmpl", td)
+}
+
+// LongDistanceSettingsPost processes the POST request for setting new long distance rates
+func (m *Repository) LongDistanceSettingsPost(w http.ResponseWriter, r *http.Request) {
+	v := models.LongDistanceRate{}
+	err := helpers.ParseFormToLongDistanceRate(r, &v)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	td, err := m.getLongDistanceSettingsTemplateData()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	form := forms.New(r.PostForm)
+	// do form validation checks
+	form.Required("single_day_rate", "multi_day_rate", "valid_from")
+
+	// an empty billing type applies the rate co-op wide, anything else must be a registered billing type
+	if _, ok := models.GetBillingType(v.BillingType); v.BillingType != "" && !ok {
+		form.Errors.Add("billing_type", "Select a billing type")
+	}
+
+	// new rates can only take effect after the latest rate for the same billing type starts
+	rates := td.Data["long-distance-rates"].([]models.LongDistanceRate)
+	for _, existing := range rates {
+		if existing.BillingType == v.BillingType && !v.ValidFrom.After(existing.ValidFrom) {
+			form.Errors.Add("valid_from", fmt.Sprintf("Rates must take effect after %s",
+				existing.ValidFrom.Format(config.DateLayout)))
+			break
+		}
+	}
+
+	// rates can't re-price months that have been billed
+	locked, err := m.DB.GetLatestLockedBillingPeriod()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if locked.IsLocked() && !v.ValidFrom.After(locked.LastDay()) {
+		form.Errors.Add("valid_from", fmt.Sprintf("Rates must take effect after %s, the end of the last finalized billing period",
+			locked.LastDay().Format(config.DateLayout)))
+	}
+
+	if !form.Valid() {
+		td.Form = form
+		td.Data["long-distance-rate"] = v
+
+		render.Template(w, r, "long-distance-settings.page.tmpl", td)
+		return
+	}
+
+	err = m.DB.InsertLongDistanceRate(v)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Updated long distance rates successfully")
+	http.Redirect(w, r, "/settings/long-distance", http.StatusSeeOther)
+}
+
+func (m *Repository) getLongDistanceSettingsTemplateData() (*models.TemplateData, error) {
+	td := models.TemplateData{}
+
+	rates, err := m.DB.AllLongDistanceRates()
+	if err != nil {
+		return &td, err
+	}
+
+	data := make(map[string]interface{})
+	data["long-distance-rates"] = rates
+	data["billingTypes"] = models.BillingTypeNames()
+
+	td.Data = data
+
+	return &td, nil
+}
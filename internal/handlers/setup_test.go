@@ -6,14 +6,18 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/driver"
 	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/mailer"
 	"github.com/cxt314/drvc-go/internal/models"
 	"github.com/cxt314/drvc-go/internal/render"
+	"github.com/cxt314/drvc-go/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/justinas/nosurf"
@@ -48,7 +52,8 @@ func getRoutes() http.Handler {
 	app.TemplateCache = tc
 	app.UseCache = true
 
-	repo := NewRepo(&app)
+	// the routes under test don't use the database, mailer or store
+	repo := NewRepo(&app, &driver.DB{}, mailer.NewFileMailer(os.TempDir(), "drvc@example.com"), storage.NewLocalStore(os.TempDir()))
 	NewHandlers(repo)
 	render.NewRenderer(&app)
 	helpers.NewHelpers(&app)
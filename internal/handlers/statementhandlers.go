@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/mailer"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/statements"
+)
+
+// MemberStatementPDF downloads one member's statement for a year/month as a PDF
+func (m *Repository) MemberStatementPDF(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	year, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	month, err := strconv.Atoi(exploded[3])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	memberID, err := strconv.Atoi(exploded[5])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	memberStatements, err := m.getMemberStatements(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	for _, s := range memberStatements {
+		if s.Member.ID != memberID {
+			continue
+		}
+
+		// Set headers so browser will download the file
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=%s", statements.Filename(s)))
+
+		err = statements.WritePDF(w, s)
+		if err != nil {
+			helpers.ServerError(w, err)
+		}
+		return
+	}
+
+	// the member wasn't billed this period
+	http.NotFound(w, r)
+}
+
+// MemberStatementsZIP downloads a PDF statement for every member billed in a year/month as a single ZIP file
+func (m *Repository) MemberStatementsZIP(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	year, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	month, err := strconv.Atoi(exploded[3])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	memberStatements, err := m.getMemberStatements(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// Set headers so browser will download the file
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=statements-%04d%02d.zip", year, month))
+
+	// the download has started so the error can only be logged
+	err = statements.WriteZIP(w, memberStatements)
+	if err != nil {
+		m.App.ErrorLog.Println("writing statements ZIP:", err)
+	}
+}
+
+// SendStatements emails every member with a non-zero total their statement for the year/month with the PDF attached.
+// Members whose statement was already sent are skipped, so sending again retries only the failures, unless the
+// resend option is checked, e.g. after the period was re-opened and corrected
+func (m *Repository) SendStatements(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	year, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	month, err := strconv.Atoi(exploded[3])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	memberStatements, err := m.getMemberStatements(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	sends, err := m.DB.GetStatementSends(year, month)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+	resend := r.Form.Get("resend") == "1"
+
+	sent, failed, skipped := 0, 0, 0
+
+	for _, s := range memberStatements {
+		if s.Total() == 0 {
+			continue
+		}
+
+		if sends[s.Member.ID].Status == models.StatementSent && !resend {
+			skipped++
+			continue
+		}
+
+		result := models.StatementSend{
+			Year:   year,
+			Month:  month,
+			Member: s.Member,
+			Email:  s.Member.Email,
+			Status: models.StatementSent,
+		}
+
+		err = m.sendStatement(s)
+		if err != nil {
+			result.Status = models.StatementFailed
+			result.Error = err.Error()
+			failed++
+		} else {
+			now := time.Now()
+			result.SentAt = &now
+			sent++
+		}
+
+		err = m.DB.RecordStatementSend(result)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+	}
+
+	msg := fmt.Sprintf("Sent %d statements, %d failed, %d already sent", sent, failed, skipped)
+	if failed > 0 {
+		m.App.Session.Put(r.Context(), "error", msg)
+	} else {
+		m.App.Session.Put(r.Context(), "flash", msg)
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/billings/%04d/%02d", year, month), http.StatusSeeOther)
+}
+
+// sendStatement emails a member their statement with the PDF attached
+func (m *Repository) sendStatement(s models.MemberStatement) error {
+	if s.Member.Email == "" {
+		return errors.New("member has no email address")
+	}
+
+	var pdf bytes.Buffer
+	err := statements.WritePDF(&pdf, s)
+	if err != nil {
+		return err
+	}
+
+	return m.Mailer.Send(mailer.Message{
+		To:      s.Member.Email,
+		Subject: statements.EmailSubject(s),
+		Body:    statements.EmailBody(s),
+		Attachments: []mailer.Attachment{
+			{Filename: statements.Filename(s), ContentType: "application/pdf", Data: pdf.Bytes()},
+		},
+	})
+}
+
+// getMemberStatements returns the statement of every member billed for the year/month
+func (m *Repository) getMemberStatements(year int, month int) ([]models.MemberStatement, error) {
+	billings, err := m.getMonthMileageLogBillings(year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.BuildMemberStatements(billings, year, month), nil
+}
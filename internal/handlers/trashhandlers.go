@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// Trash displays the deleted trips, mileage logs, members and vehicles that can be restored
+func (m *Repository) Trash(w http.ResponseWriter, r *http.Request) {
+	items, err := m.DB.GetTrash()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["items"] = items
+	data["retention"] = m.App.TrashRetention
+
+	intmap := make(map[string]int)
+	intmap["retention-days"] = int(m.App.TrashRetention.Hours() / 24)
+
+	render.Template(w, r, "trash.page.tmpl", &models.TemplateData{
+		Data:   data,
+		IntMap: intmap,
+	})
+}
+
+// TrashRestore takes an item out of the trash. Trips and mileage logs in a finalized billing period can't be restored
+func (m *Repository) TrashRestore(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	entityType := exploded[2]
+	id, err := strconv.Atoi(exploded[3])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	userID := m.App.Session.GetInt(r.Context(), "user_id")
+
+	switch entityType {
+	case models.AuditTrip:
+		var t models.Trip
+		t, err = m.DB.GetTripByID(id)
+		if err != nil {
+			helpers.ServerError(w, err)
+			return
+		}
+
+		if m.tripsLocked(w, r, t.MileageLog.ID, "/trash") {
+			return
+		}
+
+		err = m.DB.RestoreTrip(id, userID)
+	case models.AuditMileageLog:
+		if m.mileageLogLocked(w, r, id, "/trash") {
+			return
+		}
+
+		err = m.DB.RestoreMileageLog(id, userID)
+	case models.AuditMember:
+		err = m.DB.RestoreMember(id, userID)
+	case models.AuditVehicle:
+		err = m.DB.RestoreVehicle(id, userID)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if errors.Is(err, models.ErrCannotRestore) {
+		m.App.Session.Put(r.Context(), "error", errorDetail(err, models.ErrCannotRestore))
+		http.Redirect(w, r, "/trash", http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.App.Session.Put(r.Context(), "flash", "Restored "+strings.ReplaceAll(entityType, "_", " "))
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/helpers"
+	"github.com/cxt314/drvc-go/internal/models"
+	"github.com/cxt314/drvc-go/internal/render"
+)
+
+// Utilization shows how much each active vehicle was used each month over the last UtilizationMonths months,
+// least used vehicle first, with trend charts of miles and days used
+func (m *Repository) Utilization(w http.ResponseWriter, r *http.Request) {
+	report, err := m.getUtilizationReport()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// chart series are plain values so they can be passed straight to the charts as JSON
+	var labels []string
+	for _, month := range report.Months {
+		labels = append(labels, month.Format(logSheetMonthLayout))
+	}
+
+	var miles, daysUsed []map[string]interface{}
+	for _, s := range report.Vehicles {
+		var vehicleMiles, vehicleDays []int
+		for _, u := range s.Months {
+			vehicleMiles = append(vehicleMiles, u.Miles)
+			vehicleDays = append(vehicleDays, u.DaysUsed)
+		}
+
+		miles = append(miles, map[string]interface{}{"label": s.Vehicle.Name, "data": vehicleMiles})
+		daysUsed = append(daysUsed, map[string]interface{}{"label": s.Vehicle.Name, "data": vehicleDays})
+	}
+
+	data := make(map[string]interface{})
+	data["report"] = report
+	data["chart-labels"] = labels
+	data["chart-miles"] = miles
+	data["chart-days-used"] = daysUsed
+
+	intmap := make(map[string]int)
+	intmap["months"] = models.UtilizationMonths
+
+	render.Template(w, r, "utilization.page.tmpl", &models.TemplateData{
+		Data:   data,
+		IntMap: intmap,
+	})
+}
+
+// UtilizationCSV downloads the utilization of each active vehicle for each month of the report as a CSV
+func (m *Repository) UtilizationCSV(w http.ResponseWriter, r *http.Request) {
+	report, err := m.getUtilizationReport()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	// Set headers so browser will download the file
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=vehicle-utilization-%s.csv", time.Now().Format("200601")))
+
+	wr := csv.NewWriter(w)
+	if err := wr.WriteAll(report.CSVRows()); err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+}
+
+// getUtilizationReport returns the utilization of the active vehicles over the last UtilizationMonths months
+func (m *Repository) getUtilizationReport() (models.UtilizationReport, error) {
+	vehicles, err := m.DB.GetVehicleByActive(true)
+	if err != nil {
+		return models.UtilizationReport{}, err
+	}
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-models.UtilizationMonths, 0)
+
+	logs, err := m.DB.GetMileageLogsSince(since.Year(), int(since.Month()))
+	if err != nil {
+		return models.UtilizationReport{}, err
+	}
+
+	return models.NewUtilizationReport(vehicles, logs, now, models.UtilizationMonths), nil
+}
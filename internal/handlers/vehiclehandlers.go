@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/cxt314/drvc-go/internal/config"
 	"github.com/cxt314/drvc-go/internal/forms"
 	"github.com/cxt314/drvc-go/internal/helpers"
 	"github.com/cxt314/drvc-go/internal/models"
@@ -31,7 +33,8 @@ func (m *Repository) VehicleList(w http.ResponseWriter, r *http.Request) {
 func (m *Repository) VehicleCreate(w http.ResponseWriter, r *http.Request) {
 	data := make(map[string]interface{})
 	data["fuelTypes"] = models.FuelTypes
-	data["billingTypes"] = models.BillingTypes
+	data["billingTypes"] = models.AllBillingTypes()
+	data["current-rates"] = models.RateSchedule{}
 
 	render.Template(w, r, "edit-vehicle.page.tmpl", &models.TemplateData{
 		Data: data,
@@ -57,7 +60,8 @@ func (m *Repository) VehicleCreatePost(w http.ResponseWriter, r *http.Request) {
 		data := make(map[string]interface{})
 		data["vehicle"] = v
 		data["fuelTypes"] = models.FuelTypes
-		data["billingTypes"] = models.BillingTypes
+		data["billingTypes"] = models.AllBillingTypes()
+		data["current-rates"] = v.CurrentRates()
 
 		render.Template(w, r, "edit-vehicle.page.tmpl", &models.TemplateData{
 			Form: form,
@@ -94,7 +98,8 @@ func (m *Repository) VehicleEdit(w http.ResponseWriter, r *http.Request) {
 	data := make(map[string]interface{})
 	data["vehicle"] = v
 	data["fuelTypes"] = models.FuelTypes
-	data["billingTypes"] = models.BillingTypes
+	data["billingTypes"] = models.AllBillingTypes()
+	data["current-rates"] = v.CurrentRates()
 
 	render.Template(w, r, "edit-vehicle.page.tmpl", &models.TemplateData{
 		Form: forms.New(nil),
@@ -118,6 +123,9 @@ func (m *Repository) VehicleEditPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// keep the stored vehicle to check if billing rates changed
+	original := v
+
 	// parse form into fetched vehicle
 	err = helpers.ParseFormToVehicle(r, &v)
 	if err != nil {
@@ -125,15 +133,43 @@ func (m *Repository) VehicleEditPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// parse the date new billing rates take effect
+	rates := models.RateSchedule{}
+	err = helpers.ParseFormToRateSchedule(r, &rates, v)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
 	form := forms.New(r.PostForm)
 	// do form validation checks
 	form.Required("name", "make", "model", "year", "fuel_type", "purchase_date", "qbo_class")
 
+	// new rates can only take effect after the latest rate schedule starts
+	ratesChanged := v.RatesChanged(original)
+	if ratesChanged && len(original.RateSchedules) > 0 && !rates.ValidFrom.After(original.RateSchedules[0].ValidFrom) {
+		form.Errors.Add("rates_effective", fmt.Sprintf("Rates must take effect after %s",
+			original.RateSchedules[0].ValidFrom.Format(config.DateLayout)))
+	}
+
+	// and can't re-price months that have already been billed
+	locked, err := m.DB.GetLatestLockedBillingPeriod()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	if ratesChanged && locked.IsLocked() && !rates.ValidFrom.After(locked.LastDay()) {
+		form.Errors.Add("rates_effective", fmt.Sprintf("Rates must take effect after %s, the end of the last finalized billing period",
+			locked.LastDay().Format(config.DateLayout)))
+	}
+
 	if !form.Valid() {
 		data := make(map[string]interface{})
 		data["vehicle"] = v
 		data["fuelTypes"] = models.FuelTypes
-		data["billingTypes"] = models.BillingTypes
+		data["billingTypes"] = models.AllBillingTypes()
+		data["current-rates"] = v.CurrentRates()
 
 		render.Template(w, r, "edit-vehicle.page.tmpl", &models.TemplateData{
 			Form: form,
@@ -142,7 +178,13 @@ func (m *Repository) VehicleEditPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = m.DB.UpdateVehicle(v)
+	// start a new rate schedule so trips before the effective date keep their old rates
+	var newRates *models.RateSchedule
+	if ratesChanged {
+		newRates = &rates
+	}
+
+	err = m.DB.UpdateVehicle(v, newRates, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -162,7 +204,7 @@ func (m *Repository) VehicleDeactivate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// update vehicle set is_active to false
-	err = m.DB.UpdateVehicleActiveByID(id, false)
+	err = m.DB.UpdateVehicleActiveByID(id, false, m.App.Session.GetInt(r.Context(), "user_id"))
 	if err != nil {
 		helpers.ServerError(w, err)
 		return
@@ -172,14 +214,115 @@ func (m *Repository) VehicleDeactivate(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/vehicles", http.StatusSeeOther)
 }
 
-/*// VehicleDelete deletes the vehicle with the given id
+// VehicleDelete lists everything that refers to the vehicle with the given id before it can be deleted, and offers
+// to deactivate it or merge it into another vehicle instead when it has history that deleting would lose
 func (m *Repository) VehicleDelete(w http.ResponseWriter, r *http.Request) {
-	// exploded := strings.Split(r.RequestURI, "/")
-	// id, err := strconv.Atoi(exploded[2])
-	// if err != nil {
-	// 	helpers.ServerError(w, err)
-	// 	return
-	// }
-
-	render.Template(w, r, "vehicle-list.page.tmpl", &models.TemplateData{})
-}*/
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	v, err := m.DB.GetVehicleByID(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	dependents, err := m.DB.GetVehicleDependents(id)
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	vehicles, err := m.DB.AllVehicles()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	var targets []models.Vehicle
+	for _, x := range vehicles {
+		if x.ID != id {
+			targets = append(targets, x)
+		}
+	}
+
+	data := make(map[string]interface{})
+	data["dependents"] = dependents
+	data["merge-targets"] = targets
+	data["active"] = v.Active
+
+	render.Template(w, r, "confirm-delete.page.tmpl", &models.TemplateData{
+		Data:      data,
+		StringMap: map[string]string{"entity": "vehicle", "name": v.Name, "path": fmt.Sprintf("/vehicles/%d", id)},
+	})
+}
+
+// VehicleDeletePost moves the vehicle with the given id to the trash, unless it has history
+func (m *Repository) VehicleDeletePost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = m.DB.DeleteVehicle(id, m.App.Session.GetInt(r.Context(), "user_id"))
+	if errors.Is(err, models.ErrHasHistory) {
+		m.App.Session.Put(r.Context(), "error", errorDetail(err, models.ErrHasHistory)+
+			". Deactivate it or merge it into another vehicle instead")
+		http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/delete", id), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.refreshComplianceAlerts()
+
+	m.App.Session.Put(r.Context(), "flash", "Moved vehicle to the trash. It can be restored from the Trash page")
+	http.Redirect(w, r, "/vehicles", http.StatusSeeOther)
+}
+
+// VehicleMergePost moves everything that refers to the vehicle with the given id over to the vehicle chosen in the
+// form and moves the vehicle to the trash
+func (m *Repository) VehicleMergePost(w http.ResponseWriter, r *http.Request) {
+	exploded := strings.Split(r.RequestURI, "/")
+	id, err := strconv.Atoi(exploded[2])
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	intoID, err := strconv.Atoi(r.Form.Get("into"))
+	if err != nil {
+		m.App.Session.Put(r.Context(), "error", "Choose the vehicle to merge into")
+		http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/delete", id), http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.MergeVehicle(id, intoID, m.App.Session.GetInt(r.Context(), "user_id"))
+	if errors.Is(err, models.ErrCannotMerge) {
+		m.App.Session.Put(r.Context(), "error", errorDetail(err, models.ErrCannotMerge))
+		http.Redirect(w, r, fmt.Sprintf("/vehicles/%d/delete", id), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerError(w, err)
+		return
+	}
+
+	m.refreshComplianceAlerts()
+
+	m.App.Session.Put(r.Context(), "flash", "Merged vehicle and moved it to the trash")
+	http.Redirect(w, r, fmt.Sprintf("/vehicles/%d", intoID), http.StatusSeeOther)
+}
@@ -63,10 +63,72 @@ func ParseFormToVehicle(r *http.Request, v *models.Vehicle) error {
 	// parse SalePrice to uSD
 	v.SalePrice = models.StrToUSD(r.Form.Get("sale_price"))
 
-	// parse billing USD fields
-	v.BasePerMile = models.StrToUSD(r.Form.Get("base_per_mile"))
-	v.SecondaryPerMile = models.StrToUSD(r.Form.Get("secondary_per_mile"))
-	v.MinimumFee = models.StrToUSD(r.Form.Get("minimum_fee"))
+	v.FuelSurchargePerMile = models.StrToUSD(r.Form.Get("fuel_surcharge_per_mile"))
+
+	// parse the params of the selected billing type. Params with their own column are parsed
+	// into the vehicle fields, the rest are kept in BillingParams
+	v.BillingParams = models.BillingParams{}
+	bt, ok := models.GetBillingType(v.BillingType)
+	if ok {
+		for _, p := range bt.Params {
+			switch p.Key {
+			case "base_per_mile":
+				v.BasePerMile = models.StrToUSD(r.Form.Get(p.Key))
+			case "secondary_per_mile":
+				v.SecondaryPerMile = models.StrToUSD(r.Form.Get(p.Key))
+			case "minimum_fee":
+				v.MinimumFee = models.StrToUSD(r.Form.Get(p.Key))
+			default:
+				v.BillingParams.Set(p, r.Form.Get(p.Key))
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseFormToRateSchedule builds a rate schedule from the billing fields of a vehicle
+// and the rates_effective form date. If no date is given the rates are effective today
+func ParseFormToRateSchedule(r *http.Request, v *models.RateSchedule, vehicle models.Vehicle) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	*v = vehicle.CurrentRates()
+
+	if r.Form.Get("rates_effective") == "" {
+		y, m, d := time.Now().Date()
+		v.ValidFrom = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	} else {
+		v.ValidFrom, err = time.Parse(config.DateLayout, r.Form.Get("rates_effective"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseFormToLongDistanceRate parses the long distance settings form into a long distance rate
+func ParseFormToLongDistanceRate(r *http.Request, v *models.LongDistanceRate) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	// an empty billing type applies the rate co-op wide
+	v.BillingType = r.Form.Get("billing_type")
+
+	v.SingleDayRate = models.StrToUSD(r.Form.Get("single_day_rate"))
+	v.MultiDayRate = models.StrToUSD(r.Form.Get("multi_day_rate"))
+
+	if r.Form.Get("valid_from") != "" {
+		v.ValidFrom, err = time.Parse(config.DateLayout, r.Form.Get("valid_from"))
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -185,6 +247,21 @@ func ParseFormToTrip(r *http.Request, v *models.Trip, log models.MileageLog) err
 		return err
 	}
 
+	// hours and days are only on the form for billing types that use them
+	if r.Form.Get("hours") != "" {
+		v.Hours, err = strconv.ParseFloat(r.Form.Get("hours"), 64)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Form.Get("days") != "" {
+		v.Days, err = strconv.Atoi(r.Form.Get("days"))
+		if err != nil {
+			return err
+		}
+	}
+
 	// build TripDate from trip-day form input & mileage log year/month
 	tripDay, err := strconv.Atoi(r.Form.Get("trip-day"))
 	if err != nil {
@@ -201,15 +278,25 @@ func ParseFormToTrip(r *http.Request, v *models.Trip, log models.MileageLog) err
 	//fmt.Println(riders)
 
 	// clear trip riders
-	v.Riders = []models.Member{}
+	v.Riders = []models.Rider{}
 
 	for _, riderID := range riders {
-		newRider := models.Member{}
-		newRider.ID, err = strconv.Atoi(riderID)
+		newRider := models.Rider{}
+		newRider.Member.ID, err = strconv.Atoi(riderID)
 		if err != nil {
 			return err
 		}
 
+		// each rider has its own share weight & exempt inputs, a missing weight is a full share
+		newRider.Weight = 1
+		if r.Form.Get("rider-weight-"+riderID) != "" {
+			newRider.Weight, err = strconv.ParseFloat(r.Form.Get("rider-weight-"+riderID), 64)
+			if err != nil {
+				return err
+			}
+		}
+		newRider.Exempt = r.Form.Get("rider-exempt-"+riderID) != ""
+
 		v.Riders = append(v.Riders, newRider)
 	}
 
@@ -221,3 +308,184 @@ func ParseFormToTrip(r *http.Request, v *models.Trip, log models.MileageLog) err
 	//fmt.Println(v)
 	return nil
 }
+
+// ParseFormToFuelPurchase parses the fuel purchase form for a mileage log.
+// The purchase date is built from the purchase-day input & mileage log year/month.
+// An error wrapping models.ErrInvalidAmount is returned if the quantity or amount isn't greater than zero
+func ParseFormToFuelPurchase(r *http.Request, v *models.FuelPurchase, log models.MileageLog) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	v.Vehicle = log.Vehicle
+
+	purchaseDay, err := strconv.Atoi(r.Form.Get("purchase-day"))
+	if err != nil {
+		return err
+	}
+
+	v.PurchaseDate, err = time.Parse(config.DateLayout, fmt.Sprintf("%d-%02d-%02d", log.Year, log.Month, purchaseDay))
+	if err != nil {
+		return err
+	}
+
+	v.Member.ID, err = strconv.Atoi(r.Form.Get("member"))
+	if err != nil {
+		return err
+	}
+
+	v.Quantity, err = strconv.ParseFloat(r.Form.Get("quantity"), 64)
+	if err != nil {
+		return err
+	}
+	if v.Quantity <= 0 {
+		return fmt.Errorf("%w: fuel quantity must be greater than zero", models.ErrInvalidAmount)
+	}
+
+	v.Unit = r.Form.Get("unit")
+	v.Amount = models.StrToUSD(r.Form.Get("amount"))
+	if v.Amount <= 0 {
+		return fmt.Errorf("%w: fuel amount must be greater than zero", models.ErrInvalidAmount)
+	}
+	v.Notes = r.Form.Get("notes")
+
+	return nil
+}
+
+// ParseFormToLedgerEntry parses the ledger entry form for a member.
+// Payments and credits are entered as positive amounts and stored as negative amounts since they reduce the balance.
+// Adjustments are stored as entered. An error wrapping models.ErrInvalidAmount is returned if the amount can't be posted
+func ParseFormToLedgerEntry(r *http.Request, v *models.LedgerEntry, member models.Member) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	v.Member = member
+	v.EntryType = r.Form.Get("entry-type")
+	v.Description = r.Form.Get("description")
+
+	v.EntryDate, err = time.Parse(config.DateLayout, r.Form.Get("entry-date"))
+	if err != nil {
+		return err
+	}
+
+	v.Amount = models.StrToUSD(r.Form.Get("amount"))
+	err = models.CheckEnteredAmount(v.EntryType, v.Amount)
+	if err != nil {
+		return err
+	}
+
+	if v.EntryType == models.LedgerPayment || v.EntryType == models.LedgerCredit {
+		v.Amount = -v.Amount
+	}
+
+	return nil
+}
+
+// ParseFormToServiceRecord parses the service record form for a vehicle
+func ParseFormToServiceRecord(r *http.Request, v *models.ServiceRecord, vehicle models.Vehicle) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	v.Vehicle = vehicle
+	v.ServiceType = r.Form.Get("service-type")
+	v.Vendor = r.Form.Get("vendor")
+	v.Notes = r.Form.Get("notes")
+
+	v.ServiceDate, err = time.Parse(config.DateLayout, r.Form.Get("service-date"))
+	if err != nil {
+		return err
+	}
+
+	v.Odometer, err = strconv.Atoi(r.Form.Get("odometer"))
+	if err != nil {
+		return err
+	}
+
+	v.Cost = models.StrToUSD(r.Form.Get("cost"))
+
+	return nil
+}
+
+// ParseFormToMaintenanceSchedule parses the maintenance schedule form for a vehicle. A blank interval doesn't recur
+func ParseFormToMaintenanceSchedule(r *http.Request, v *models.MaintenanceSchedule, vehicle models.Vehicle) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	v.Vehicle = vehicle
+	v.ServiceType = r.Form.Get("service-type")
+
+	if r.Form.Get("interval-miles") != "" {
+		v.IntervalMiles, err = strconv.Atoi(r.Form.Get("interval-miles"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Form.Get("interval-months") != "" {
+		v.IntervalMonths, err = strconv.Atoi(r.Form.Get("interval-months"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseFormToVehicleExpense parses the expense form for a vehicle. A blank coverage covers one month
+func ParseFormToVehicleExpense(r *http.Request, v *models.VehicleExpense, vehicle models.Vehicle) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	v.Vehicle = vehicle
+	v.Category = r.Form.Get("category")
+	v.Notes = r.Form.Get("notes")
+	v.Amount = models.StrToUSD(r.Form.Get("amount"))
+
+	v.ExpenseDate, err = time.Parse(config.DateLayout, r.Form.Get("expense-date"))
+	if err != nil {
+		return err
+	}
+
+	v.CoverageMonths = 1
+	if r.Form.Get("coverage-months") != "" {
+		v.CoverageMonths, err = strconv.Atoi(r.Form.Get("coverage-months"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParseFormToComplianceDocument parses the compliance document form for a vehicle. The issued date is optional
+func ParseFormToComplianceDocument(r *http.Request, v *models.ComplianceDocument, vehicle models.Vehicle) error {
+	v.Vehicle = vehicle
+	v.DocumentType = r.Form.Get("document-type")
+	v.Reference = r.Form.Get("reference")
+	v.Notes = r.Form.Get("notes")
+
+	expires, err := time.Parse(config.DateLayout, r.Form.Get("expires-date"))
+	if err != nil {
+		return err
+	}
+	v.ExpiresDate = expires
+
+	if r.Form.Get("issued-date") != "" {
+		issued, err := time.Parse(config.DateLayout, r.Form.Get("issued-date"))
+		if err != nil {
+			return err
+		}
+		v.IssuedDate = &issued
+	}
+
+	return nil
+}
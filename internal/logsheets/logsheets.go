@@ -0,0 +1,171 @@
+// Package logsheets renders blank paper mileage logs as PDFs, one page per vehicle, to print and keep in the cars.
+// The columns match the mileage log CSV so a filled in sheet can be typed up and imported as is
+package logsheets
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// columnWidths are the widths of models.MileageLogColumns in mm, filling a landscape Letter page
+var columnWidths = []float64{24, 36, 16, 60, 48, 75.4}
+
+const (
+	margin         = 10.0
+	rowHeight      = 8.0
+	minRows        = 10
+	legendColumns  = 5
+	legendLine     = 4.0
+	legendFontSize = 7.0
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Filename returns the file name for a month of log sheets, e.g. log-sheets-202611-prius.pdf for one vehicle
+// or log-sheets-202611.pdf for several
+func Filename(sheets []models.LogSheet) string {
+	if len(sheets) == 0 {
+		return "log-sheets.pdf"
+	}
+
+	name := fmt.Sprintf("log-sheets-%04d%02d", sheets[0].Year, sheets[0].Month)
+	if len(sheets) == 1 {
+		name += "-" + strings.Trim(unsafeFilenameChars.ReplaceAllString(strings.ToLower(sheets[0].Vehicle.Name), "-"), "-")
+	}
+
+	return name + ".pdf"
+}
+
+// WritePDF writes the log sheets as one PDF. The trip table takes as many rows as fit above the member legend
+func WritePDF(w io.Writer, sheets []models.LogSheet) error {
+	pdf := fpdf.New("L", "mm", "Letter", "")
+	pdf.SetTitle("DRVC Mileage Log Sheets", true)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+
+	// core fonts only cover cp1252, so names are translated from UTF-8
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for _, s := range sheets {
+		writeSheet(pdf, tr, s)
+	}
+
+	return pdf.Output(w)
+}
+
+func writeSheet(pdf *fpdf.Fpdf, tr func(string) string, s models.LogSheet) {
+	pdf.AddPage()
+	_, pageHeight := pdf.GetPageSize()
+
+	// title and odometer, in the same order as the first rows of the CSV
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(140, 9, fitText(pdf, tr(s.Vehicle.Name), 140), "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 9, fmt.Sprintf("%s %d", time.Month(s.Month), s.Year), "", 1, "R", false, 0, "")
+
+	start := "__________"
+	if s.StartOdometer > 0 {
+		start = strconv.Itoa(s.StartOdometer)
+	}
+
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(35, 8, "Starting Mileage:", "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(45, 8, start, "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(35, 8, "Ending Mileage:", "", 0, "L", false, 0, "")
+	pdf.CellFormat(45, 8, "__________", "", 0, "L", false, 0, "")
+	pdf.CellFormat(25, 8, "Total Miles:", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 8, "__________", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	// fit as many trip rows as leave room for the legend, but never fewer than minRows
+	legendHeight := 0.0
+	perColumn := 0
+	if len(s.Members) > 0 {
+		perColumn = int(math.Ceil(float64(len(s.Members)) / legendColumns))
+		legendHeight = 8 + float64(perColumn)*legendLine
+	}
+
+	tableTop := pdf.GetY()
+	rows := int((pageHeight - margin - tableTop - rowHeight - legendHeight) / rowHeight)
+	if rows < minRows {
+		rows = minRows
+	}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(220, 220, 220)
+	for i, title := range models.MileageLogColumns {
+		pdf.CellFormat(columnWidths[i], rowHeight, title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	for r := 0; r < rows; r++ {
+		for i := range models.MileageLogColumns {
+			pdf.CellFormat(columnWidths[i], rowHeight, "", "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if len(s.Members) == 0 {
+		return
+	}
+
+	// the legend goes on its own page if the table left no room for it
+	if pdf.GetY()+legendHeight > pageHeight-margin {
+		pdf.AddPage()
+	}
+	writeLegend(pdf, tr, s.Members, perColumn)
+}
+
+// writeLegend lists the members down legendColumns columns with their aliases in brackets
+func writeLegend(pdf *fpdf.Fpdf, tr func(string) string, members []models.Member, perColumn int) {
+	pageWidth, _ := pdf.GetPageSize()
+	columnWidth := (pageWidth - 2*margin) / legendColumns
+
+	pdf.Ln(3)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(0, 5, "Riders (write a name or alias)", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", legendFontSize)
+	top := pdf.GetY()
+	for i, m := range members {
+		name := m.Name
+
+		var aliases []string
+		for _, a := range m.Aliases {
+			aliases = append(aliases, a.Name)
+		}
+		if len(aliases) > 0 {
+			name += " (" + strings.Join(aliases, ", ") + ")"
+		}
+
+		pdf.SetXY(margin+float64(i/perColumn)*columnWidth, top+float64(i%perColumn)*legendLine)
+		pdf.CellFormat(columnWidth, legendLine, fitText(pdf, tr(name), columnWidth), "", 0, "L", false, 0, "")
+	}
+}
+
+// fitText shortens text with an ellipsis so it fits in a cell of the given width
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	// leave room for the cell's padding
+	maxWidth := width - 2
+
+	if pdf.GetStringWidth(text) <= maxWidth {
+		return text
+	}
+
+	// text has already been translated to a single byte code page, so it is safe to cut on any byte
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > maxWidth {
+		text = text[:len(text)-1]
+	}
+
+	return text + "..."
+}
@@ -0,0 +1,71 @@
+package logsheets
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+func testMembers(n int) []models.Member {
+	var members []models.Member
+	for i := 1; i <= n; i++ {
+		members = append(members, models.Member{
+			ID:      i,
+			Name:    fmt.Sprintf("Member %d", i),
+			Aliases: []models.MemberAlias{{Name: fmt.Sprintf("M%d", i)}},
+		})
+	}
+
+	return members
+}
+
+func TestNewLogSheet(t *testing.T) {
+	v := models.Vehicle{ID: 1, Name: "Prius"}
+
+	s := models.NewLogSheet(v, 2026, 11, models.MileageLog{}, 45210, nil)
+	if s.StartOdometer != 45210 {
+		t.Errorf("expected the previous month's odometer 45210 but got %d", s.StartOdometer)
+	}
+
+	s = models.NewLogSheet(v, 2026, 11, models.MileageLog{ID: 4, StartOdometer: 45300}, 45210, nil)
+	if s.StartOdometer != 45300 {
+		t.Errorf("expected the log's starting odometer 45300 but got %d", s.StartOdometer)
+	}
+}
+
+func TestFilename(t *testing.T) {
+	one := []models.LogSheet{{Vehicle: models.Vehicle{Name: "Blue Prius"}, Year: 2026, Month: 11}}
+	if got := Filename(one); got != "log-sheets-202611-blue-prius.pdf" {
+		t.Errorf("unexpected filename %s", got)
+	}
+
+	two := append(one, models.LogSheet{Vehicle: models.Vehicle{Name: "Leaf"}, Year: 2026, Month: 11})
+	if got := Filename(two); got != "log-sheets-202611.pdf" {
+		t.Errorf("unexpected filename %s", got)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	sheets := []models.LogSheet{
+		{Vehicle: models.Vehicle{Name: "Prius"}, Year: 2026, Month: 11, StartOdometer: 45210, Members: testMembers(12)},
+		// a roster too long to fit under the table puts the legend on its own page
+		{Vehicle: models.Vehicle{Name: "Leaf"}, Year: 2026, Month: 11, Members: testMembers(200)},
+		{Vehicle: models.Vehicle{Name: "Zoë's Car"}, Year: 2026, Month: 11},
+	}
+
+	var buf bytes.Buffer
+	err := WritePDF(&buf, sheets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+
+	if pages := bytes.Count(buf.Bytes(), []byte("/Type /Page\n")); pages != 4 {
+		t.Errorf("expected 4 pages but got %d", pages)
+	}
+}
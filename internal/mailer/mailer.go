@@ -0,0 +1,198 @@
+// Package mailer sends email. Mailer has an SMTP implementation for production and
+// file and in-memory implementations for development and tests
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Message is an email to one recipient
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Mailer sends messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// SMTPMailer sends messages through an SMTP server. Username and Password are optional
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPMailer creates a mailer that sends through the SMTP server at host:port
+func NewSMTPMailer(host string, port int, username string, password string, from string) *SMTPMailer {
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+// Send sends the message through the SMTP server
+func (m *SMTPMailer) Send(msg Message) error {
+	data, err := Build(m.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+strconv.Itoa(m.Port), auth, m.From, []string{msg.To}, data)
+}
+
+// FileMailer writes each message as an .eml file in Dir instead of sending it
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// NewFileMailer creates a mailer that writes messages to dir, creating it if needed
+func NewFileMailer(dir string, from string) *FileMailer {
+	return &FileMailer{
+		Dir:  dir,
+		From: from,
+	}
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9@.]+`)
+
+// Send writes the message to a file named by the time and recipient
+func (m *FileMailer) Send(msg Message) error {
+	now := time.Now()
+
+	data, err := Build(m.From, msg, now)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(m.Dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405.000000000"), unsafeFilenameChars.ReplaceAllString(msg.To, "_"))
+
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0644)
+}
+
+// MemoryMailer keeps sent messages in memory. If Err is set, Send returns it and the message is not kept
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Message
+	Err  error
+}
+
+// Send keeps the message
+func (m *MemoryMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent returns the messages sent so far
+func (m *MemoryMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.sent...)
+}
+
+// Build returns the message as a MIME email. Messages with attachments are sent as multipart/mixed
+func Build(from string, msg Message, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(msg.Attachments) == 0 {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64(&buf, []byte(msg.Body))
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(part, []byte(msg.Body))
+
+	for _, a := range msg.Attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", a.ContentType, a.Filename)},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Filename)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, a.Data)
+	}
+
+	err = mw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes data base64 encoded in 76 character lines
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"errors"
+	"net/mail"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testMessage = Message{
+	To:      "member@example.com",
+	Subject: "Your statement for September 2026",
+	Body:    "Total Due: $12.34",
+	Attachments: []Attachment{
+		{Filename: "statement.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.3 test")},
+	},
+}
+
+func TestBuild(t *testing.T) {
+	data, err := Build("billing@example.com", testMessage, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("built message could not be parsed: %s", err)
+	}
+
+	if msg.Header.Get("To") != testMessage.To || msg.Header.Get("Subject") != testMessage.Subject {
+		t.Errorf("unexpected headers %v", msg.Header)
+	}
+
+	if !strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/mixed") {
+		t.Errorf("expected multipart message but got %s", msg.Header.Get("Content-Type"))
+	}
+
+	if !strings.Contains(string(data), `filename="statement.pdf"`) {
+		t.Error("attachment is missing")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFileMailer(dir, "billing@example.com")
+
+	err := m.Send(testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || !strings.HasSuffix(files[0].Name(), "member@example.com.eml") {
+		t.Errorf("expected one .eml file but got %v", files)
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := &MemoryMailer{}
+
+	err := m.Send(testMessage)
+	if err != nil || len(m.Sent()) != 1 {
+		t.Fatalf("expected message to be kept, err %v", err)
+	}
+
+	m.Err = errors.New("connection refused")
+	err = m.Send(testMessage)
+	if err == nil || len(m.Sent()) != 1 {
+		t.Error("expected failed send to return the error and not keep the message")
+	}
+}
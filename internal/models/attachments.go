@@ -0,0 +1,34 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Attachment is a scan or photo of a paper mileage log sheet. The file itself is kept in storage under Key
+type Attachment struct {
+	ID           int
+	MileageLogID int
+	Key          string
+	Filename     string
+	ContentType  string
+	Size         int
+	UploadedBy   User
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// IsImage returns true if the attachment can be shown in an img tag
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+// DisplaySize returns the size of the file in KB or MB
+func (a Attachment) DisplaySize() string {
+	if a.Size < 1<<20 {
+		return fmt.Sprintf("%d KB", (a.Size+1023)/1024)
+	}
+
+	return fmt.Sprintf("%.1f MB", float64(a.Size)/(1<<20))
+}
@@ -0,0 +1,314 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditInsert  = "insert"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditMerge   = "merge"
+)
+
+// Audited entity types
+const (
+	AuditTrip       = "trip"
+	AuditMileageLog = "mileage_log"
+	AuditMember     = "member"
+	AuditVehicle    = "vehicle"
+	AuditAttachment = "attachment"
+)
+
+// AuditEntry records one change to a trip, mileage log, member, vehicle or attachment. Before and After hold
+// JSON snapshots of the record, Before is empty for an insert or restore and After is empty for a delete
+type AuditEntry struct {
+	ID         int
+	EntityType string
+	EntityID   int
+	// MileageLogID is the mileage log a trip, mileage log or attachment change belongs to, 0 for members and vehicles
+	MileageLogID int
+	Action       string
+	User         User
+	Before       string
+	After        string
+	CreatedAt    time.Time
+}
+
+// AuditFieldChange is one field that differs between an audit entry's before and after snapshots
+type AuditFieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Changes returns the fields that differ between the before and after snapshots, ordered by field name.
+// For an insert every field is returned with an empty Before, for a delete every field with an empty After
+func (e AuditEntry) Changes() []AuditFieldChange {
+	before := decodeAuditSnapshot(e.Before)
+	after := decodeAuditSnapshot(e.After)
+
+	fields := make(map[string]bool)
+	for k := range before {
+		fields[k] = true
+	}
+	for k := range after {
+		fields[k] = true
+	}
+
+	var names []string
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var changes []AuditFieldChange
+	for _, k := range names {
+		b, a := formatAuditValue(before[k]), formatAuditValue(after[k])
+		if b == a {
+			continue
+		}
+
+		changes = append(changes, AuditFieldChange{Field: k, Before: b, After: a})
+	}
+
+	return changes
+}
+
+// Describe returns a short description of what was changed, e.g. "Updated trip 12"
+func (e AuditEntry) Describe() string {
+	action := map[string]string{
+		AuditInsert:  "Created",
+		AuditUpdate:  "Updated",
+		AuditDelete:  "Deleted",
+		AuditRestore: "Restored",
+		AuditMerge:   "Merged",
+	}[e.Action]
+	entity := strings.ReplaceAll(e.EntityType, "_", " ")
+
+	return fmt.Sprintf("%s %s %d", action, entity, e.EntityID)
+}
+
+func decodeAuditSnapshot(s string) map[string]interface{} {
+	values := make(map[string]interface{})
+	if s == "" {
+		return values
+	}
+
+	// a snapshot that can't be decoded is shown as having no fields
+	_ = json.Unmarshal([]byte(s), &values)
+
+	return values
+}
+
+func formatAuditValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case []interface{}:
+		var parts []string
+		for _, i := range x {
+			parts = append(parts, formatAuditValue(i))
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		b, _ := json.Marshal(x)
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+// TripAuditSnapshot is the part of a trip recorded in its audit history
+type TripAuditSnapshot struct {
+	ID               int
+	MileageLogID     int
+	TripDate         string
+	StartMileage     int
+	EndMileage       int
+	LongDistanceDays int
+	BillingRate      string
+	Destination      string
+	Purpose          string
+	Hours            float64
+	Days             int
+	Riders           []string
+}
+
+// NewTripAuditSnapshot returns the audit snapshot of a trip. Riders are listed by name with their share weight
+// if it isn't a full share
+func NewTripAuditSnapshot(t Trip) TripAuditSnapshot {
+	s := TripAuditSnapshot{
+		ID:               t.ID,
+		MileageLogID:     t.MileageLog.ID,
+		TripDate:         t.TripDate.Format("2006-01-02"),
+		StartMileage:     t.StartMileage,
+		EndMileage:       t.EndMileage,
+		LongDistanceDays: t.LongDistanceDays,
+		BillingRate:      t.BillingRate,
+		Destination:      t.Destination,
+		Purpose:          t.Purpose,
+		Hours:            t.Hours,
+		Days:             t.Days,
+	}
+
+	for _, r := range t.Riders {
+		name := r.Member.Name
+		if name == "" {
+			name = fmt.Sprintf("member %d", r.Member.ID)
+		}
+
+		switch {
+		case r.Exempt:
+			name += " (exempt)"
+		case r.Weight != 1:
+			name += fmt.Sprintf(" x%g", r.Weight)
+		}
+
+		s.Riders = append(s.Riders, name)
+	}
+
+	return s
+}
+
+// MileageLogAuditSnapshot is the part of a mileage log recorded in its audit history.
+// Trips are only included when the whole log is deleted
+type MileageLogAuditSnapshot struct {
+	ID            int
+	VehicleID     int
+	Name          string
+	Year          int
+	Month         int
+	StartOdometer int
+	EndOdometer   int
+	Status        string
+	Trips         []TripAuditSnapshot `json:",omitempty"`
+}
+
+// NewMileageLogAuditSnapshot returns the audit snapshot of a mileage log without its trips
+func NewMileageLogAuditSnapshot(l MileageLog) MileageLogAuditSnapshot {
+	return MileageLogAuditSnapshot{
+		ID:            l.ID,
+		VehicleID:     l.Vehicle.ID,
+		Name:          l.Name,
+		Year:          l.Year,
+		Month:         l.Month,
+		StartOdometer: l.StartOdometer,
+		EndOdometer:   l.EndOdometer,
+		Status:        l.Status,
+	}
+}
+
+// MemberAuditSnapshot is the part of a member recorded in their audit history
+type MemberAuditSnapshot struct {
+	ID      int
+	Name    string
+	QBOName string
+	Email   string
+	Aliases []string
+	Active  bool
+}
+
+// NewMemberAuditSnapshot returns the audit snapshot of a member
+func NewMemberAuditSnapshot(m Member) MemberAuditSnapshot {
+	s := MemberAuditSnapshot{
+		ID:      m.ID,
+		Name:    m.Name,
+		QBOName: m.QBOName,
+		Email:   m.Email,
+		Active:  m.Active,
+	}
+
+	for _, a := range m.Aliases {
+		s.Aliases = append(s.Aliases, a.Name)
+	}
+
+	return s
+}
+
+// VehicleAuditSnapshot is the part of a vehicle recorded in its audit history
+type VehicleAuditSnapshot struct {
+	ID                   int
+	Name                 string
+	QBOClass             string
+	Year                 int
+	Make                 string
+	Model                string
+	FuelType             string
+	PurchasePrice        string
+	PurchaseDate         string
+	Vin                  string
+	LicensePlate         string
+	Active               bool
+	SalePrice            string
+	SaleDate             string
+	BillingType          string
+	BasePerMile          string
+	SecondaryPerMile     string
+	MinimumFee           string
+	FuelSurchargePerMile string
+	BillingParams        BillingParams
+}
+
+// NewVehicleAuditSnapshot returns the audit snapshot of a vehicle. Amounts are recorded as formatted dollars
+func NewVehicleAuditSnapshot(v Vehicle) VehicleAuditSnapshot {
+	date := func(d *time.Time) string {
+		if d == nil {
+			return ""
+		}
+		return d.Format("2006-01-02")
+	}
+
+	return VehicleAuditSnapshot{
+		ID:                   v.ID,
+		Name:                 v.Name,
+		QBOClass:             v.QBOClass,
+		Year:                 v.Year,
+		Make:                 v.Make,
+		Model:                v.Model,
+		FuelType:             v.FuelType,
+		PurchasePrice:        v.PurchasePrice.String(),
+		PurchaseDate:         date(v.PurchaseDate),
+		Vin:                  v.Vin,
+		LicensePlate:         v.LicensePlate,
+		Active:               v.Active,
+		SalePrice:            v.SalePrice.String(),
+		SaleDate:             date(v.SaleDate),
+		BillingType:          v.BillingType,
+		BasePerMile:          v.BasePerMile.String(),
+		SecondaryPerMile:     v.SecondaryPerMile.String(),
+		MinimumFee:           v.MinimumFee.String(),
+		FuelSurchargePerMile: v.FuelSurchargePerMile.String(),
+		BillingParams:        v.BillingParams,
+	}
+}
+
+// AttachmentAuditSnapshot is the part of a mileage log attachment recorded in its audit history
+type AttachmentAuditSnapshot struct {
+	ID           int
+	MileageLogID int
+	Filename     string
+	ContentType  string
+	Size         int
+	Key          string
+}
+
+// NewAttachmentAuditSnapshot returns the audit snapshot of an attachment
+func NewAttachmentAuditSnapshot(a Attachment) AttachmentAuditSnapshot {
+	return AttachmentAuditSnapshot{
+		ID:           a.ID,
+		MileageLogID: a.MileageLogID,
+		Filename:     a.Filename,
+		ContentType:  a.ContentType,
+		Size:         a.Size,
+		Key:          a.Key,
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func auditSnapshotJSON(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestAuditEntryChanges(t *testing.T) {
+	trip := Trip{
+		ID:           7,
+		MileageLog:   MileageLog{ID: 3},
+		TripDate:     time.Date(2026, 9, 4, 0, 0, 0, 0, time.UTC),
+		StartMileage: 100,
+		EndMileage:   120,
+		Destination:  "Town",
+		Riders: []Rider{
+			{Member: Member{Name: "Jane"}, Weight: 1},
+			{Member: Member{Name: "Bob"}, Weight: 0.5},
+		},
+	}
+	before := NewTripAuditSnapshot(trip)
+
+	trip.EndMileage = 125
+	trip.Riders[1].Exempt = true
+	after := NewTripAuditSnapshot(trip)
+
+	e := AuditEntry{Before: auditSnapshotJSON(t, before), After: auditSnapshotJSON(t, after)}
+	got := e.Changes()
+
+	want := []AuditFieldChange{
+		{Field: "EndMileage", Before: "120", After: "125"},
+		{Field: "Riders", Before: "Jane, Bob x0.5", After: "Jane, Bob (exempt)"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestAuditEntryChangesInsert(t *testing.T) {
+	e := AuditEntry{
+		EntityType: AuditMember,
+		EntityID:   4,
+		Action:     AuditInsert,
+		After:      auditSnapshotJSON(t, NewMemberAuditSnapshot(Member{ID: 4, Name: "Jane", Active: true})),
+	}
+
+	for _, c := range e.Changes() {
+		if c.Before != "" {
+			t.Errorf("expected no before value for %s, got %q", c.Field, c.Before)
+		}
+	}
+
+	if d := e.Describe(); d != "Created member 4" {
+		t.Errorf("expected description %q, got %q", "Created member 4", d)
+	}
+}
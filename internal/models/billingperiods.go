@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrBillingPeriodStatus is returned when a billing period isn't in a status that allows the change,
+// e.g. it was finalized by someone else in the meantime
+var ErrBillingPeriodStatus = errors.New("billing period status does not allow this change")
+
+// Billing period statuses. A period moves from open to finalized when its charges are snapshotted,
+// and from finalized to exported when its QBO invoices are downloaded
+const (
+	BillingPeriodOpen      = "open"
+	BillingPeriodFinalized = "finalized"
+	BillingPeriodExported  = "exported"
+)
+
+// Billing period event actions
+const (
+	BillingPeriodActionFinalize = "finalize"
+	BillingPeriodActionExport   = "export"
+	BillingPeriodActionReopen   = "reopen"
+)
+
+// BillingPeriod is the billing state of one year/month.
+// ID is 0 for a period that has never been finalized
+type BillingPeriod struct {
+	ID          int
+	Year        int
+	Month       int
+	Status      string
+	FinalizedAt *time.Time
+	ExportedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Charges     []BillingPeriodCharge
+	Events      []BillingPeriodEvent
+}
+
+// IsLocked returns true if mileage logs in the period can no longer be edited
+func (p BillingPeriod) IsLocked() bool {
+	return p.Status == BillingPeriodFinalized || p.Status == BillingPeriodExported
+}
+
+// LastDay returns midnight UTC on the last day of the period's month
+func (p BillingPeriod) LastDay() time.Time {
+	return time.Date(p.Year, time.Month(p.Month)+1, 0, 0, 0, 0, 0, time.UTC)
+}
+
+// ChargesTotal returns the total of all snapshotted charges after fuel credits
+func (p BillingPeriod) ChargesTotal() USD {
+	var total USD
+	for _, c := range p.Charges {
+		total += c.Total()
+	}
+
+	return total
+}
+
+// BillingPeriodCharge is a snapshot of what a member owed for one mileage log when the period was finalized
+type BillingPeriodCharge struct {
+	ID                    int
+	BillingPeriodID       int
+	Member                Member
+	Vehicle               Vehicle
+	MileageLogID          int
+	RegularTripsCost      USD
+	LongDistanceTripsCost USD
+	FuelSurchargeCost     USD
+	FuelCredit            USD
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// Total returns the amount the member owed for the log after fuel credits
+func (c BillingPeriodCharge) Total() USD {
+	return c.RegularTripsCost + c.LongDistanceTripsCost + c.FuelSurchargeCost - c.FuelCredit
+}
+
+// BillingPeriodEvent records who changed a billing period's status and why
+type BillingPeriodEvent struct {
+	ID              int
+	BillingPeriodID int
+	User            User
+	Action          string
+	Reason          string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
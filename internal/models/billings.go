@@ -1,21 +1,44 @@
 package models
 
+import "time"
+
 type MileageLogBilling struct {
 	Log MileageLog
 	TotalTripCost USD
 	TotalMemberBillings USD
+	TotalExemptCost USD // cost of trips where every rider is exempt, not billed to anyone
+	TotalFuelSurcharge USD
+	TotalFuelCredit USD
 	MemberBills map[int]MemberMileageLogBilling
 }
 
+// MemberMileageLogBilling is what a member owes for one mileage log.
+// FuelCredit is what the member paid for fuel and is subtracted from the total
 type MemberMileageLogBilling struct {
 	Member                Member
 	RegularTripsCost      USD
 	LongDistanceTripsCost USD
+	FuelSurchargeCost     USD
+	FuelCredit            USD
+}
+
+// Total returns the amount the member owes for the log after fuel credits
+func (b MemberMileageLogBilling) Total() USD {
+	return b.RegularTripsCost + b.LongDistanceTripsCost + b.FuelSurchargeCost - b.FuelCredit
+}
+
+// TripUsage is what a billing method needs to know about a trip to price it
+type TripUsage struct {
+	Miles            float64
+	Hours            float64
+	Days             int
+	LongDistanceDays int
+	UseSecondaryRate bool
 }
 
 type BillingMethod interface {
 	Name() string
-	TripCost(multiplier float64, UseSecondaryRate bool) USD
+	TripCost(u TripUsage) USD
 }
 
 type SimplePerMileBilling struct {
@@ -27,12 +50,12 @@ func (b *SimplePerMileBilling) Name() string {
 	return b.BillingName
 }
 
-func (b *SimplePerMileBilling) TripCost(multiplier float64, UseSecondaryRate bool) USD {
+func (b *SimplePerMileBilling) TripCost(u TripUsage) USD {
 	// if trip is less than 1 mile, trip = 1 mile
-	if multiplier == 0.0 {
+	if u.Miles == 0.0 {
 		return b.BasePerMile.Multiply(1.0)
 	}
-	return b.BasePerMile.Multiply(multiplier)
+	return b.BasePerMile.Multiply(u.Miles)
 }
 
 type TruckBilling struct {
@@ -46,26 +69,51 @@ func (b *TruckBilling) Name() string {
 	return b.BillingName
 }
 
-func (b *TruckBilling) TripCost(multiplier float64, UseSecondaryRate bool) USD {
+func (b *TruckBilling) TripCost(u TripUsage) USD {
 	rate := b.BasePerMile
 
-	if UseSecondaryRate {
+	if u.UseSecondaryRate {
 		rate = b.SecondaryPerMile
 	}
 
-	cost := rate.Multiply(multiplier)
+	cost := rate.Multiply(u.Miles)
 	// if trip is less than 1 mile, trip = 1 mile
-	if multiplier == 0.0 {
+	if u.Miles == 0.0 {
 		cost = rate.Multiply(1.0)
 	}
 
-	if cost.Float64() < b.MinimumFee.Float64() && !UseSecondaryRate {
+	if cost.Float64() < b.MinimumFee.Float64() && !u.UseSecondaryRate {
 		return b.MinimumFee
 	} else {
 		return cost
 	}
 }
 
+// LongDistanceRate is the long distance pricing in effect over a range of dates.
+// An empty BillingType applies co-op wide, otherwise it only applies to vehicles with that billing type
+type LongDistanceRate struct {
+	ID            int
+	BillingType   string
+	SingleDayRate USD
+	MultiDayRate  USD
+	ValidFrom     time.Time
+	ValidTo       *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// InEffect returns true if the long distance rate applies on the given date
+func (r LongDistanceRate) InEffect(d time.Time) bool {
+	return inDateRange(d, r.ValidFrom, r.ValidTo)
+}
+
+// defaultLongDistanceRate is only used when no configured long distance rate covers a trip date.
+// The long_distance_rates migration seeds a co-op wide rate with the same values
+var defaultLongDistanceRate = LongDistanceRate{
+	SingleDayRate: ToUSD(85.0),
+	MultiDayRate:  ToUSD(50.0),
+}
+
 type LongDistanceBilling struct {
 	BillingName   string
 	SingleDayRate USD
@@ -76,10 +124,69 @@ func (b *LongDistanceBilling) Name() string {
 	return b.BillingName
 }
 
-func (b *LongDistanceBilling) TripCost(multiplier float64, UseSecondaryRate bool) USD {
-	if multiplier == 1 {
+func (b *LongDistanceBilling) TripCost(u TripUsage) USD {
+	if u.LongDistanceDays == 1 {
 		return b.SingleDayRate
 	} else {
-		return b.MultiDayRate.Multiply(multiplier)
+		return b.MultiDayRate.Multiply(float64(u.LongDistanceDays))
 	}
 }
+
+// TieredPerMileBilling charges one rate for the first TierMiles of a trip and another rate for the rest
+type TieredPerMileBilling struct {
+	BillingName      string
+	TierMiles        float64
+	FirstTierPerMile USD
+	PerMile          USD
+}
+
+func (b *TieredPerMileBilling) Name() string {
+	return b.BillingName
+}
+
+func (b *TieredPerMileBilling) TripCost(u TripUsage) USD {
+	miles := u.Miles
+	// if trip is less than 1 mile, trip = 1 mile
+	if miles == 0.0 {
+		miles = 1.0
+	}
+
+	if miles <= b.TierMiles {
+		return b.FirstTierPerMile.Multiply(miles)
+	}
+
+	return b.FirstTierPerMile.Multiply(b.TierMiles) + b.PerMile.Multiply(miles-b.TierMiles)
+}
+
+// PerDayBilling charges a flat rate for each day the vehicle is out, regardless of miles
+type PerDayBilling struct {
+	BillingName string
+	DayRate     USD
+}
+
+func (b *PerDayBilling) Name() string {
+	return b.BillingName
+}
+
+func (b *PerDayBilling) TripCost(u TripUsage) USD {
+	// every trip is at least one day
+	if u.Days < 1 {
+		return b.DayRate
+	}
+	return b.DayRate.Multiply(float64(u.Days))
+}
+
+// HourlyPlusMileageBilling charges for the hours the vehicle is out plus the miles driven
+type HourlyPlusMileageBilling struct {
+	BillingName string
+	PerHour     USD
+	PerMile     USD
+}
+
+func (b *HourlyPlusMileageBilling) Name() string {
+	return b.BillingName
+}
+
+func (b *HourlyPlusMileageBilling) TripCost(u TripUsage) USD {
+	return b.PerHour.Multiply(u.Hours) + b.PerMile.Multiply(u.Miles)
+}
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// BillingParamKind describes how a billing parameter is entered and stored
+type BillingParamKind int
+
+const (
+	// ParamUSD is a dollar amount, stored in cents
+	ParamUSD BillingParamKind = iota
+	// ParamNumber is a plain number such as a count of miles
+	ParamNumber
+)
+
+// BillingParam describes one rate a billing type needs configured on a vehicle.
+// Key is used as the form field name. The keys base_per_mile, secondary_per_mile and minimum_fee
+// are stored in their own columns, all other keys are stored in BillingParams
+type BillingParam struct {
+	Key   string
+	Label string
+	Kind  BillingParamKind
+}
+
+// BillingType is a billing style that vehicles can be billed with.
+// New builds the BillingMethod for a trip from the rate schedule in effect on the trip date.
+// UsesHours and UsesDays add the matching inputs to the trip form
+type BillingType struct {
+	Name      string
+	Params    []BillingParam
+	UsesHours bool
+	UsesDays  bool
+	New       func(r RateSchedule) BillingMethod
+}
+
+// ErrUnknownBillingType is returned when a trip's rates name a billing type that isn't registered
+var ErrUnknownBillingType = errors.New("unknown billing type")
+
+// billingTypes holds the registered billing types in registration order
+var billingTypes []BillingType
+
+// RegisterBillingType adds a billing type to the registry, replacing any type with the same name
+func RegisterBillingType(bt BillingType) {
+	for i, existing := range billingTypes {
+		if existing.Name == bt.Name {
+			billingTypes[i] = bt
+			return
+		}
+	}
+
+	billingTypes = append(billingTypes, bt)
+}
+
+// GetBillingType returns the registered billing type with the given name
+func GetBillingType(name string) (BillingType, bool) {
+	for _, bt := range billingTypes {
+		if bt.Name == name {
+			return bt, true
+		}
+	}
+
+	return BillingType{}, false
+}
+
+// AllBillingTypes returns all registered billing types in registration order
+func AllBillingTypes() []BillingType {
+	return billingTypes
+}
+
+// BillingTypeNames returns the names of all registered billing types in registration order
+func BillingTypeNames() []string {
+	var names []string
+	for _, bt := range billingTypes {
+		names = append(names, bt.Name)
+	}
+
+	return names
+}
+
+// BillingParams holds the values of billing params that don't have their own column.
+// USD params are stored in cents. It is stored in the database as JSONB
+type BillingParams map[string]float64
+
+// USD returns the value of a USD param
+func (p BillingParams) USD(key string) USD {
+	return USD(p[key])
+}
+
+// Number returns the value of a number param
+func (p BillingParams) Number(key string) float64 {
+	return p[key]
+}
+
+// Set parses a form value into the param. Invalid numbers are stored as 0
+func (p BillingParams) Set(param BillingParam, value string) {
+	if param.Kind == ParamUSD {
+		p[param.Key] = float64(StrToUSD(value))
+		return
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		f = 0
+	}
+	p[param.Key] = f
+}
+
+// Value implements driver.Valuer so BillingParams can be written to a JSONB column
+func (p BillingParams) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner so BillingParams can be read from a JSONB column
+func (p *BillingParams) Scan(src interface{}) error {
+	var b []byte
+
+	switch v := src.(type) {
+	case nil:
+		*p = BillingParams{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("unsupported type for BillingParams")
+	}
+
+	params := BillingParams{}
+	err := json.Unmarshal(b, &params)
+	if err != nil {
+		return err
+	}
+
+	*p = params
+	return nil
+}
+
+// ParamValue returns the display value of a billing param for the rate schedule
+func (r RateSchedule) ParamValue(p BillingParam) string {
+	var value float64
+
+	switch p.Key {
+	case "base_per_mile":
+		value = float64(r.BasePerMile)
+	case "secondary_per_mile":
+		value = float64(r.SecondaryPerMile)
+	case "minimum_fee":
+		value = float64(r.MinimumFee)
+	default:
+		value = r.Params[p.Key]
+	}
+
+	if p.Kind == ParamUSD {
+		return USD(value).String()
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Describe returns the params of the rate schedule's billing type and their values
+func (r RateSchedule) Describe() string {
+	bt, ok := GetBillingType(r.BillingType)
+	if !ok {
+		return ""
+	}
+
+	var parts []string
+	for _, p := range bt.Params {
+		parts = append(parts, fmt.Sprintf("%s: %s", p.Label, r.ParamValue(p)))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// cloneParams returns a copy of the params so rate schedules don't share a map with the vehicle
+func cloneParams(p BillingParams) BillingParams {
+	if p == nil {
+		return BillingParams{}
+	}
+
+	return maps.Clone(p)
+}
+
+func init() {
+	RegisterBillingType(BillingType{
+		Name: "Basic",
+		Params: []BillingParam{
+			{Key: "base_per_mile", Label: "Base Rate (per mile)", Kind: ParamUSD},
+		},
+		New: func(r RateSchedule) BillingMethod {
+			return &SimplePerMileBilling{
+				BillingName: "Simple Per Mile",
+				BasePerMile: r.BasePerMile,
+			}
+		},
+	})
+
+	RegisterBillingType(BillingType{
+		Name: "Truck",
+		Params: []BillingParam{
+			{Key: "base_per_mile", Label: "Base Rate (per mile)", Kind: ParamUSD},
+			{Key: "secondary_per_mile", Label: "Secondary Rate (per mile)", Kind: ParamUSD},
+			{Key: "minimum_fee", Label: "Minimum Use Fee", Kind: ParamUSD},
+		},
+		New: func(r RateSchedule) BillingMethod {
+			return &TruckBilling{
+				BillingName:      "Truck",
+				BasePerMile:      r.BasePerMile,
+				SecondaryPerMile: r.SecondaryPerMile,
+				MinimumFee:       r.MinimumFee,
+			}
+		},
+	})
+
+	RegisterBillingType(BillingType{
+		Name: "Tiered",
+		Params: []BillingParam{
+			{Key: "tier_miles", Label: "First Tier Miles", Kind: ParamNumber},
+			{Key: "base_per_mile", Label: "First Tier Rate (per mile)", Kind: ParamUSD},
+			{Key: "secondary_per_mile", Label: "Remaining Miles Rate (per mile)", Kind: ParamUSD},
+		},
+		New: func(r RateSchedule) BillingMethod {
+			return &TieredPerMileBilling{
+				BillingName:      "Tiered Per Mile",
+				TierMiles:        r.Params.Number("tier_miles"),
+				FirstTierPerMile: r.BasePerMile,
+				PerMile:          r.SecondaryPerMile,
+			}
+		},
+	})
+
+	RegisterBillingType(BillingType{
+		Name: "Daily",
+		Params: []BillingParam{
+			{Key: "day_rate", Label: "Day Rate", Kind: ParamUSD},
+		},
+		UsesDays: true,
+		New: func(r RateSchedule) BillingMethod {
+			return &PerDayBilling{
+				BillingName: "Per Day Flat Rate",
+				DayRate:     r.Params.USD("day_rate"),
+			}
+		},
+	})
+
+	RegisterBillingType(BillingType{
+		Name: "Hourly",
+		Params: []BillingParam{
+			{Key: "hourly_rate", Label: "Hourly Rate", Kind: ParamUSD},
+			{Key: "base_per_mile", Label: "Mileage Rate (per mile)", Kind: ParamUSD},
+		},
+		UsesHours: true,
+		New: func(r RateSchedule) BillingMethod {
+			return &HourlyPlusMileageBilling{
+				BillingName: "Hourly Plus Mileage",
+				PerHour:     r.Params.USD("hourly_rate"),
+				PerMile:     r.BasePerMile,
+			}
+		},
+	})
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBillingTypeTripCost(t *testing.T) {
+	tiered := &TieredPerMileBilling{TierMiles: 20, FirstTierPerMile: ToUSD(0.50), PerMile: ToUSD(0.30)}
+	daily := &PerDayBilling{DayRate: ToUSD(40)}
+	hourly := &HourlyPlusMileageBilling{PerHour: ToUSD(8), PerMile: ToUSD(0.25)}
+	truck := &TruckBilling{BasePerMile: ToUSD(0.60), SecondaryPerMile: ToUSD(0.40), MinimumFee: ToUSD(10)}
+
+	tests := []struct {
+		name   string
+		bm     BillingMethod
+		u      TripUsage
+		expect USD
+	}{
+		{"tiered under the tier", tiered, TripUsage{Miles: 10}, ToUSD(5)},
+		{"tiered on the tier boundary", tiered, TripUsage{Miles: 20}, ToUSD(10)},
+		{"tiered one mile past the tier", tiered, TripUsage{Miles: 21}, ToUSD(10.30)},
+		{"tiered zero miles counts as one", tiered, TripUsage{}, ToUSD(0.50)},
+		{"daily zero days counts as one", daily, TripUsage{Miles: 50}, ToUSD(40)},
+		{"daily one day", daily, TripUsage{Days: 1}, ToUSD(40)},
+		{"daily three days", daily, TripUsage{Days: 3, Miles: 300}, ToUSD(120)},
+		{"hourly zero hours is mileage only", hourly, TripUsage{Miles: 20}, ToUSD(5)},
+		{"hourly hours and miles", hourly, TripUsage{Hours: 2.5, Miles: 20}, ToUSD(25)},
+		{"hourly zero hours and miles", hourly, TripUsage{}, 0},
+		{"truck under the minimum fee", truck, TripUsage{Miles: 10}, ToUSD(10)},
+		{"truck over the minimum fee", truck, TripUsage{Miles: 20}, ToUSD(12)},
+		{"truck secondary rate ignores the minimum fee", truck, TripUsage{Miles: 10, UseSecondaryRate: true}, ToUSD(4)},
+	}
+
+	for _, tt := range tests {
+		got := tt.bm.TripCost(tt.u)
+		if got != tt.expect {
+			t.Errorf("%s: expected %s but got %s", tt.name, tt.expect, got)
+		}
+	}
+}
+
+func TestTripBillingMethodUnknownType(t *testing.T) {
+	trip := Trip{
+		MileageLog:   MileageLog{Vehicle: Vehicle{BillingType: "Retired"}},
+		TripDate:     time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		StartMileage: 100,
+		EndMileage:   110,
+	}
+
+	_, err := trip.BillingMethod()
+	if !errors.Is(err, ErrUnknownBillingType) {
+		t.Errorf("expected ErrUnknownBillingType but got %v", err)
+	}
+
+	if trip.Cost() != 0 {
+		t.Errorf("expected an unpriced trip to cost nothing but got %s", trip.Cost())
+	}
+
+	trip.MileageLog.Vehicle.BillingType = "Tiered"
+	if _, err := trip.BillingMethod(); err != nil {
+		t.Errorf("expected a registered billing type to price the trip but got %v", err)
+	}
+}
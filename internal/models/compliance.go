@@ -0,0 +1,183 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+)
+
+// ComplianceTypes contains the list of documents a vehicle has to keep current
+var ComplianceTypes = []string{"Insurance", "Registration", "Inspection"}
+
+// DefaultComplianceWarningDays is how many days before a document expires it is warned about
+const DefaultComplianceWarningDays = 30
+
+// Compliance statuses, from most to least urgent
+const (
+	ComplianceExpired  = "expired"
+	ComplianceExpiring = "expiring"
+	ComplianceCurrent  = "current"
+	// ComplianceReplaced is a document that has been renewed, so its expiry no longer matters
+	ComplianceReplaced = "replaced"
+)
+
+// ComplianceDocument is a vehicle's insurance policy, registration or inspection, valid until ExpiresDate.
+// File is the uploaded copy of the document, with an empty Key if there isn't one
+type ComplianceDocument struct {
+	ID           int
+	Vehicle      Vehicle
+	DocumentType string
+	// Reference is the policy, registration or certificate number
+	Reference   string
+	IssuedDate  *time.Time
+	ExpiresDate time.Time
+	File        Attachment
+	Notes       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// HasFile returns true if a copy of the document has been uploaded
+func (d ComplianceDocument) HasFile() bool {
+	return d.File.Key != ""
+}
+
+// DaysLeft returns the days from today until the document's expiry date, negative once it has expired.
+// A document is still valid on its expiry date
+func (d ComplianceDocument) DaysLeft(today time.Time) int {
+	expires := time.Date(d.ExpiresDate.Year(), d.ExpiresDate.Month(), d.ExpiresDate.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(math.Round(expires.Sub(day).Hours() / 24))
+}
+
+// Status returns whether the document has expired or expires within warningDays of today
+func (d ComplianceDocument) Status(today time.Time, warningDays int) string {
+	switch left := d.DaysLeft(today); {
+	case left < 0:
+		return ComplianceExpired
+	case left <= warningDays:
+		return ComplianceExpiring
+	default:
+		return ComplianceCurrent
+	}
+}
+
+// ComplianceAlert is a vehicle document that has expired or is expiring soon
+type ComplianceAlert struct {
+	Document ComplianceDocument
+	DaysLeft int
+	Status   string
+}
+
+// IsExpired returns true if the document has expired
+func (a ComplianceAlert) IsExpired() bool {
+	return a.Status == ComplianceExpired
+}
+
+// LatestComplianceDocuments returns the document of each type for each vehicle that expires last.
+// Older documents replaced by a renewal are left out
+func LatestComplianceDocuments(docs []ComplianceDocument) []ComplianceDocument {
+	type vehicleType struct {
+		vehicleID    int
+		documentType string
+	}
+
+	latest := make(map[vehicleType]int)
+	var order []vehicleType
+	for i, d := range docs {
+		key := vehicleType{d.Vehicle.ID, d.DocumentType}
+		j, ok := latest[key]
+		if !ok {
+			order = append(order, key)
+		}
+		if !ok || d.ExpiresDate.After(docs[j].ExpiresDate) {
+			latest[key] = i
+		}
+	}
+
+	var current []ComplianceDocument
+	for _, key := range order {
+		current = append(current, docs[latest[key]])
+	}
+
+	return current
+}
+
+// NewComplianceAlerts returns the latest documents that have expired or expire within warningDays of today,
+// soonest to expire first
+func NewComplianceAlerts(docs []ComplianceDocument, today time.Time, warningDays int) []ComplianceAlert {
+	var alerts []ComplianceAlert
+	for _, d := range LatestComplianceDocuments(docs) {
+		status := d.Status(today, warningDays)
+		if status == ComplianceCurrent {
+			continue
+		}
+
+		alerts = append(alerts, ComplianceAlert{Document: d, DaysLeft: d.DaysLeft(today), Status: status})
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].Document.ExpiresDate.Before(alerts[j].Document.ExpiresDate)
+	})
+
+	return alerts
+}
+
+// ComplianceDigestDue returns true if the weekly compliance digest hasn't been sent yet this week, given when it was
+// last sent. Weeks start on Monday, so a digest missed on Monday while the app was down goes out the next day instead
+func ComplianceDigestDue(lastSent time.Time, now time.Time) bool {
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	weekStart := time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, now.Location())
+
+	return lastSent.Before(weekStart)
+}
+
+// ComplianceDigestSubject returns the subject line for an email digest of compliance alerts
+func ComplianceDigestSubject(alerts []ComplianceAlert) string {
+	expired := 0
+	for _, a := range alerts {
+		if a.IsExpired() {
+			expired++
+		}
+	}
+
+	return fmt.Sprintf("DRVC vehicle documents: %d expired, %d expiring soon", expired, len(alerts)-expired)
+}
+
+// ComplianceDigestBody returns a plain text list of compliance alerts for the body of the digest email
+func ComplianceDigestBody(alerts []ComplianceAlert, warningDays int) string {
+	var b strings.Builder
+
+	if len(alerts) == 0 {
+		fmt.Fprintf(&b, "No vehicle documents have expired or expire in the next %d days.\n", warningDays)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "These vehicle documents have expired or expire in the next %d days:\n\n", warningDays)
+	for _, a := range alerts {
+		when := fmt.Sprintf("expires in %d days", a.DaysLeft)
+		switch {
+		case a.IsExpired():
+			when = "EXPIRED"
+		case a.DaysLeft == 0:
+			when = "expires today"
+		}
+
+		reference := ""
+		if a.Document.Reference != "" {
+			reference = " (" + a.Document.Reference + ")"
+		}
+
+		fmt.Fprintf(&b, "  %s  %s %s%s  %s\n", a.Document.ExpiresDate.Format(config.DateLayout), a.Document.Vehicle.Name,
+			a.Document.DocumentType, reference, when)
+	}
+
+	b.WriteString("\nUpload the renewed documents on each vehicle's Documents page.\n")
+
+	return b.String()
+}
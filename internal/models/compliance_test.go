@@ -0,0 +1,91 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComplianceDocumentStatus(t *testing.T) {
+	today := time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expires time.Time
+		left    int
+		want    string
+	}{
+		{time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), -1, ComplianceExpired},
+		{time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), 0, ComplianceExpiring},
+		{time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC), 30, ComplianceExpiring},
+		{time.Date(2026, 11, 18, 0, 0, 0, 0, time.UTC), 31, ComplianceCurrent},
+	}
+
+	for _, tt := range tests {
+		d := ComplianceDocument{ExpiresDate: tt.expires}
+		if d.DaysLeft(today) != tt.left {
+			t.Errorf("%s: expected %d days left but got %d", tt.expires.Format("2006-01-02"), tt.left, d.DaysLeft(today))
+		}
+		if d.Status(today, 30) != tt.want {
+			t.Errorf("%s: expected %q but got %q", tt.expires.Format("2006-01-02"), tt.want, d.Status(today, 30))
+		}
+	}
+}
+
+func TestNewComplianceAlerts(t *testing.T) {
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	prius := Vehicle{ID: 1, Name: "Prius"}
+	leaf := Vehicle{ID: 2, Name: "Leaf"}
+
+	docs := []ComplianceDocument{
+		// renewed, so the lapsed registration isn't an alert
+		{Vehicle: prius, DocumentType: "Registration", ExpiresDate: time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)},
+		{Vehicle: prius, DocumentType: "Registration", ExpiresDate: time.Date(2027, 9, 30, 0, 0, 0, 0, time.UTC)},
+		{Vehicle: prius, DocumentType: "Insurance", ExpiresDate: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Reference: "P-123"},
+		{Vehicle: leaf, DocumentType: "Registration", ExpiresDate: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{Vehicle: leaf, DocumentType: "Inspection", ExpiresDate: time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	alerts := NewComplianceAlerts(docs, today, 30)
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts but got %d", len(alerts))
+	}
+	if alerts[0].Document.Vehicle.Name != "Leaf" || !alerts[0].IsExpired() {
+		t.Errorf("expected the Leaf's expired registration first but got %+v", alerts[0])
+	}
+	if alerts[1].Document.Reference != "P-123" || alerts[1].Status != ComplianceExpiring || alerts[1].DaysLeft != 14 {
+		t.Errorf("expected the Prius insurance expiring in 14 days but got %+v", alerts[1])
+	}
+
+	if subject := ComplianceDigestSubject(alerts); subject != "DRVC vehicle documents: 1 expired, 1 expiring soon" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	body := ComplianceDigestBody(alerts, 30)
+	if !strings.Contains(body, "Leaf Registration  EXPIRED") || !strings.Contains(body, "Prius Insurance (P-123)  expires in 14 days") {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
+
+func TestComplianceDigestDue(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		lastSent time.Time
+		now      time.Time
+		due      bool
+	}{
+		{"never sent", time.Time{}, monday, true},
+		{"sent last week", monday.AddDate(0, 0, -7), monday, true},
+		{"sent this morning", monday.Add(-time.Hour), monday, false},
+		{"restarted later on monday", monday, monday.Add(6 * time.Hour), false},
+		{"sent on monday, now sunday", monday, monday.AddDate(0, 0, 6), false},
+		{"missed monday", monday.AddDate(0, 0, -7), monday.AddDate(0, 0, 1), true},
+		{"sent sunday, now monday", monday.AddDate(0, 0, -1), monday, true},
+	}
+
+	for _, tt := range tests {
+		if got := ComplianceDigestDue(tt.lastSent, tt.now); got != tt.due {
+			t.Errorf("%s: expected due %t but got %t", tt.name, tt.due, got)
+		}
+	}
+}
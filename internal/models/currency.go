@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -9,10 +11,10 @@ import (
 // USD represents US dollar amount in terms of cents
 type USD int64
 
-// ToUSD converts a float64 to USD
-// e.g. 1.23 to $1.23, 1.345 to $1.35
+// ToUSD converts a float64 to USD, rounding half away from zero so negative amounts round the same as positive
+// e.g. 1.23 to $1.23, -1.23 to -$1.23
 func ToUSD(f float64) USD {
-	return USD((f * 100) + 0.5)
+	return USD(math.Round(f * 100))
 }
 
 func StrToUSD(s string) USD {
@@ -45,12 +47,72 @@ func (m USD) Divide(f float64) USD {
 }
 
 
-// AddUSD safely adds two USD prices together
+// AddUSD safely adds two USD prices together. USD is a whole number of cents,
+// so integer addition never loses a cent to float rounding
 func (m USD) AddUSD(a USD) USD {
-	x := m.Float64()
-	y := a.Float64()
+	return m + a
+}
+
+// Allocate splits m into shares proportional to weights so that the shares always sum to m exactly.
+// Each share is rounded down to the cent and the leftover cents go one at a time to the shares with
+// the largest remainders, ties going to the earlier share. Zero or negative weights get nothing.
+// If no weight is positive, every share is zero
+func (m USD) Allocate(weights []float64) []USD {
+	shares := make([]USD, len(weights))
+
+	totalWeight := 0.0
+	for _, w := range weights {
+		if w > 0 {
+			totalWeight += w
+		}
+	}
+	if totalWeight == 0 {
+		return shares
+	}
+
+	// allocate the absolute amount so leftover cents always round away from zero
+	amount := m
+	if amount < 0 {
+		amount = -amount
+	}
+
+	remainders := make([]float64, len(weights))
+	allocated := USD(0)
+
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+
+		exact := float64(amount) * w / totalWeight
+		shares[i] = USD(math.Floor(exact))
+		remainders[i] = exact - float64(shares[i])
+		allocated += shares[i]
+	}
 
-	return ToUSD(x + y)
+	// hand out the leftover cents by largest remainder
+	order := make([]int, 0, len(weights))
+	for i, w := range weights {
+		if w > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+
+	for i := 0; allocated < amount; i++ {
+		shares[order[i%len(order)]]++
+		allocated++
+	}
+
+	if m < 0 {
+		for i := range shares {
+			shares[i] = -shares[i]
+		}
+	}
+
+	return shares
 }
 
 // String returns a formatted USD value
@@ -59,3 +121,13 @@ func (m USD) String() string {
 	x = x / 100
 	return fmt.Sprintf("$%.2f", x)
 }
+
+// SignedString returns a formatted USD value with the sign before the dollar sign, e.g. -$1.23
+// as QBO and statements expect
+func (m USD) SignedString() string {
+	if m < 0 {
+		return "-" + (-m).String()
+	}
+
+	return m.String()
+}
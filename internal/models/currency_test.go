@@ -0,0 +1,92 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+var allocateTests = []struct {
+	name     string
+	amount   USD
+	weights  []float64
+	expected []USD
+}{
+	{"3-way even", 300, []float64{1, 1, 1}, []USD{100, 100, 100}},
+	{"3-way one leftover cent", 100, []float64{1, 1, 1}, []USD{34, 33, 33}},
+	{"3-way two leftover cents", 101, []float64{1, 1, 1}, []USD{34, 34, 33}},
+	{"3-way $85 long distance", 8500, []float64{1, 1, 1}, []USD{2834, 2833, 2833}},
+	{"3-way single cent", 1, []float64{1, 1, 1}, []USD{1, 0, 0}},
+	{"7-way even", 700, []float64{1, 1, 1, 1, 1, 1, 1}, []USD{100, 100, 100, 100, 100, 100, 100}},
+	{"7-way one leftover cent", 1000, []float64{1, 1, 1, 1, 1, 1, 1}, []USD{143, 143, 143, 143, 143, 143, 142}},
+	{"7-way six leftover cents", 1385, []float64{1, 1, 1, 1, 1, 1, 1}, []USD{198, 198, 198, 198, 198, 198, 197}},
+	{"7-way less than a cent each", 5, []float64{1, 1, 1, 1, 1, 1, 1}, []USD{1, 1, 1, 1, 1, 0, 0}},
+	{"weighted half share", 1000, []float64{1, 1, 0.5}, []USD{400, 400, 200}},
+	{"weighted largest remainder", 1000, []float64{2, 1, 0}, []USD{667, 333, 0}},
+	{"zero weight skipped", 100, []float64{1, 0, 1}, []USD{50, 0, 50}},
+	{"no positive weights", 100, []float64{0, 0}, []USD{0, 0}},
+	{"negative amount", -100, []float64{1, 1, 1}, []USD{-34, -33, -33}},
+	{"zero amount", 0, []float64{1, 1, 1}, []USD{0, 0, 0}},
+	{"no riders", 100, []float64{}, []USD{}},
+}
+
+func TestAllocate(t *testing.T) {
+	for _, e := range allocateTests {
+		shares := e.amount.Allocate(e.weights)
+
+		if !slices.Equal(shares, e.expected) {
+			t.Errorf("for %s, expected %v but got %v", e.name, e.expected, shares)
+		}
+
+		// shares must always reconcile to the amount when anyone is billed
+		total := USD(0)
+		for _, s := range shares {
+			total += s
+		}
+		if slices.ContainsFunc(e.weights, func(w float64) bool { return w > 0 }) && total != e.amount {
+			t.Errorf("for %s, expected shares to sum to %d but got %d", e.name, e.amount, total)
+		}
+	}
+}
+
+func TestAllocateReconciles(t *testing.T) {
+	for _, riders := range []int{3, 7} {
+		weights := make([]float64, riders)
+		for i := range weights {
+			weights[i] = 1
+		}
+
+		for amount := USD(0); amount <= 10000; amount++ {
+			shares := amount.Allocate(weights)
+
+			total := USD(0)
+			for _, s := range shares {
+				total += s
+			}
+
+			if total != amount {
+				t.Fatalf("for %d-way split of %d, shares summed to %d", riders, amount, total)
+			}
+
+			// equal weights never differ by more than one cent
+			if slices.Max(shares)-slices.Min(shares) > 1 {
+				t.Fatalf("for %d-way split of %d, shares %v differ by more than a cent", riders, amount, shares)
+			}
+		}
+	}
+}
+
+func TestStrToUSD(t *testing.T) {
+	for s, expected := range map[string]USD{"1.23": 123, "$25.00": 2500, "-25.00": -2500, "-1.5": -150, "abc": 0} {
+		if got := StrToUSD(s); got != expected {
+			t.Errorf("for %q, expected %d but got %d", s, expected, got)
+		}
+	}
+}
+
+func TestUSDSignedString(t *testing.T) {
+	for m, expected := range map[USD]string{123: "$1.23", 0: "$0.00", -123: "-$1.23", -5: "-$0.05"} {
+		if got := m.SignedString(); got != expected {
+			t.Errorf("for %d, expected %s but got %s", m, expected, got)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrHasHistory is returned when a member or vehicle can't be deleted because trips, fuel purchases
+// or billing records still refer to it
+var ErrHasHistory = errors.New("has history")
+
+// ErrCannotMerge is returned when a member or vehicle can't be merged into another as things are now
+var ErrCannotMerge = errors.New("cannot merge")
+
+// DependentMileageLog is a mileage log whose trips or riders refer to a member or vehicle
+type DependentMileageLog struct {
+	ID    int
+	Name  string
+	Year  int
+	Month int
+	// Trips counts the log's trips that refer to the member or vehicle, Riders the rider rows on them
+	Trips  int
+	Riders int
+	// Billed is true if the log's billing period has been finalized or exported
+	Billed bool
+}
+
+// Label returns the log's month as YYYY-MM
+func (l DependentMileageLog) Label() string {
+	return fmt.Sprintf("%04d-%02d", l.Year, l.Month)
+}
+
+// Dependents lists the records that refer to a member or vehicle and would be lost with it, since the foreign keys
+// cascade deletes. Trips and mileage logs in the trash aren't counted, they are purged before the member or vehicle
+type Dependents struct {
+	MileageLogs    []DependentMileageLog
+	FuelPurchases  int
+	BillingCharges int
+	LedgerEntries  int
+	QBOInvoices    int
+	Statements     int
+	// ServiceRecords, Expenses and ComplianceDocuments are a vehicle's maintenance, cost and registration history
+	// and stop it being deleted. MaintenanceSchedules are only settings and are deleted with the vehicle
+	ServiceRecords       int
+	MaintenanceSchedules int
+	Expenses             int
+	ComplianceDocuments  int
+}
+
+// Trips returns the number of dependent trips across the mileage logs
+func (d Dependents) Trips() int {
+	n := 0
+	for _, l := range d.MileageLogs {
+		n += l.Trips
+	}
+
+	return n
+}
+
+// Riders returns the number of dependent rider rows across the mileage logs
+func (d Dependents) Riders() int {
+	n := 0
+	for _, l := range d.MileageLogs {
+		n += l.Riders
+	}
+
+	return n
+}
+
+// BilledMileageLogs returns the number of dependent mileage logs in a finalized or exported billing period
+func (d Dependents) BilledMileageLogs() int {
+	n := 0
+	for _, l := range d.MileageLogs {
+		if l.Billed {
+			n++
+		}
+	}
+
+	return n
+}
+
+// HasBilledHistory returns true if the member or vehicle has been billed: it appears on a mileage log in a
+// finalized billing period, in billing period charges, the ledger, QuickBooks invoices or sent statements
+func (d Dependents) HasBilledHistory() bool {
+	return d.BilledMileageLogs() > 0 || d.BillingCharges > 0 || d.LedgerEntries > 0 || d.QBOInvoices > 0 || d.Statements > 0
+}
+
+// CanDelete returns true if nothing that would be lost refers to the member or vehicle. Unbilled mileage logs,
+// trips, riders and fuel purchases also stop a delete, since they are billed once their month is finalized
+func (d Dependents) CanDelete() bool {
+	return len(d.MileageLogs) == 0 && d.FuelPurchases == 0 && !d.HasBilledHistory() && !d.HasVehicleRecords()
+}
+
+// HasVehicleRecords returns true if the vehicle has service records, expenses or compliance documents
+func (d Dependents) HasVehicleRecords() bool {
+	return d.ServiceRecords > 0 || d.Expenses > 0 || d.ComplianceDocuments > 0
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestDependentsCanDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		d      Dependents
+		billed bool
+		delete bool
+	}{
+		{"nothing refers to it", Dependents{}, false, true},
+		{"maintenance schedules deleted with the vehicle", Dependents{MaintenanceSchedules: 2}, false, true},
+		{"service records", Dependents{ServiceRecords: 2}, false, false},
+		{"expenses", Dependents{Expenses: 1}, false, false},
+		{"compliance documents", Dependents{ComplianceDocuments: 3}, false, false},
+		{"unbilled trips", Dependents{MileageLogs: []DependentMileageLog{{ID: 1, Trips: 2, Riders: 3}}}, false, false},
+		{"unbilled fuel purchase", Dependents{FuelPurchases: 1}, false, false},
+		{"finalized mileage log", Dependents{MileageLogs: []DependentMileageLog{{ID: 1, Trips: 1, Riders: 1, Billed: true}}}, true, false},
+		{"ledger entries", Dependents{LedgerEntries: 1}, true, false},
+		{"sent statements", Dependents{Statements: 1}, true, false},
+	}
+
+	for _, tt := range tests {
+		if tt.d.HasBilledHistory() != tt.billed {
+			t.Errorf("%s: expected billed history %t", tt.name, tt.billed)
+		}
+		if tt.d.CanDelete() != tt.delete {
+			t.Errorf("%s: expected can delete %t", tt.name, tt.delete)
+		}
+	}
+}
+
+func TestDependentsTotals(t *testing.T) {
+	d := Dependents{MileageLogs: []DependentMileageLog{
+		{ID: 1, Trips: 2, Riders: 3, Billed: true},
+		{ID: 2, Trips: 4, Riders: 4},
+	}}
+
+	if d.Trips() != 6 {
+		t.Errorf("expected 6 trips but got %d", d.Trips())
+	}
+	if d.Riders() != 7 {
+		t.Errorf("expected 7 riders but got %d", d.Riders())
+	}
+	if d.BilledMileageLogs() != 1 {
+		t.Errorf("expected 1 billed mileage log but got %d", d.BilledMileageLogs())
+	}
+}
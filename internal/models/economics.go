@@ -0,0 +1,245 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ExpenseCategories contains the list of kinds of running costs a vehicle expense can be for
+var ExpenseCategories = []string{"Insurance", "Registration", "Parking", "Other"}
+
+// DefaultDepreciationMonths is how long a vehicle that hasn't been sold is depreciated over, down to nothing
+const DefaultDepreciationMonths = 96
+
+// VehicleExpense is a running cost of a vehicle other than fuel and maintenance, such as insurance or registration.
+// The Amount is spread evenly over CoverageMonths starting with the month of the ExpenseDate
+type VehicleExpense struct {
+	ID             int
+	Vehicle        Vehicle
+	ExpenseDate    time.Time
+	Category       string
+	Amount         USD
+	CoverageMonths int
+	Notes          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// EconomicsPeriod is what a vehicle cost and what it billed over a month, or over a year when Month is 0
+type EconomicsPeriod struct {
+	Year         int
+	Month        int
+	Miles        int
+	Depreciation USD
+	Maintenance  USD
+	Fuel         USD
+	Expenses     USD
+	Revenue      USD
+}
+
+// Label returns the period as YYYY-MM, or YYYY for a year
+func (p EconomicsPeriod) Label() string {
+	if p.Month == 0 {
+		return fmt.Sprintf("%04d", p.Year)
+	}
+
+	return fmt.Sprintf("%04d-%02d", p.Year, p.Month)
+}
+
+// TotalCost returns everything the vehicle cost over the period
+func (p EconomicsPeriod) TotalCost() USD {
+	return p.Depreciation + p.Maintenance + p.Fuel + p.Expenses
+}
+
+// Net returns the revenue left after costs, negative if the vehicle cost more than it billed
+func (p EconomicsPeriod) Net() USD {
+	return p.Revenue - p.TotalCost()
+}
+
+// CostPerMile returns the actual cost per mile driven, 0 if the vehicle wasn't driven
+func (p EconomicsPeriod) CostPerMile() USD {
+	if p.Miles == 0 {
+		return 0
+	}
+
+	return p.TotalCost().Divide(float64(p.Miles))
+}
+
+// BilledPerMile returns the revenue billed per mile driven, 0 if the vehicle wasn't driven
+func (p EconomicsPeriod) BilledPerMile() USD {
+	if p.Miles == 0 {
+		return 0
+	}
+
+	return p.Revenue.Divide(float64(p.Miles))
+}
+
+// add adds the costs, revenue and miles of o to p
+func (p *EconomicsPeriod) add(o EconomicsPeriod) {
+	p.Miles += o.Miles
+	p.Depreciation += o.Depreciation
+	p.Maintenance += o.Maintenance
+	p.Fuel += o.Fuel
+	p.Expenses += o.Expenses
+	p.Revenue += o.Revenue
+}
+
+// VehicleEconomics is a vehicle's cost of ownership against what it billed, by month and by year
+type VehicleEconomics struct {
+	Vehicle Vehicle
+	// Months and Years are oldest first and only include periods with miles, costs or revenue
+	Months []EconomicsPeriod
+	Years  []EconomicsPeriod
+	Total  EconomicsPeriod
+}
+
+// MonthsOf returns the months of a year, oldest first
+func (e VehicleEconomics) MonthsOf(year int) []EconomicsPeriod {
+	var months []EconomicsPeriod
+	for _, p := range e.Months {
+		if p.Year == year {
+			months = append(months, p)
+		}
+	}
+
+	return months
+}
+
+// firstOfMonth returns midnight UTC on the first of t's month
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// spreadMonthly splits amount evenly over months starting with start's month, keyed by the first of each month.
+// The shares add up to amount exactly
+func spreadMonthly(amount USD, start time.Time, months int) map[time.Time]USD {
+	if months < 1 {
+		months = 1
+	}
+
+	weights := make([]float64, months)
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	spread := make(map[time.Time]USD)
+	for i, share := range amount.Allocate(weights) {
+		spread[firstOfMonth(start).AddDate(0, i, 0)] += share
+	}
+
+	return spread
+}
+
+// MonthlyDepreciation returns the straight line depreciation of a vehicle for each month it is owned, keyed by the
+// first of the month. A sold vehicle loses PurchasePrice less SalePrice from the month it was bought up to the month
+// it was sold. A vehicle that hasn't been sold loses its whole PurchasePrice over DefaultDepreciationMonths.
+// Without a purchase date or price there is nothing to depreciate
+func MonthlyDepreciation(v Vehicle) map[time.Time]USD {
+	if v.PurchaseDate == nil || v.PurchasePrice == 0 {
+		return map[time.Time]USD{}
+	}
+
+	if v.SaleDate == nil {
+		return spreadMonthly(v.PurchasePrice, *v.PurchaseDate, DefaultDepreciationMonths)
+	}
+
+	months := (v.SaleDate.Year()*12 + int(v.SaleDate.Month())) - (v.PurchaseDate.Year()*12 + int(v.PurchaseDate.Month()))
+
+	return spreadMonthly(v.PurchasePrice-v.SalePrice, *v.PurchaseDate, months)
+}
+
+// mileageLogMiles returns the miles driven on a log from its odometer readings, or from its trips
+// if the log doesn't have both readings yet
+func mileageLogMiles(l MileageLog) int {
+	if l.Distance > 0 {
+		return l.Distance
+	}
+
+	miles := 0
+	for _, t := range l.Trips {
+		miles += int(t.Distance())
+	}
+
+	return miles
+}
+
+// mileageLogRevenue returns what the log's trips billed riders
+func mileageLogRevenue(l MileageLog) USD {
+	var revenue USD
+	for _, t := range l.Trips {
+		revenue += tripRevenue(t)
+	}
+
+	return revenue
+}
+
+// NewVehicleEconomics totals a vehicle's depreciation, maintenance, fuel and other expenses against the miles driven
+// and revenue billed on its mileage logs, by month and by year. Months after through are left out, so depreciation
+// and prepaid expenses only count up to then
+func NewVehicleEconomics(v Vehicle, logs []MileageLog, fuel []FuelPurchase, records []ServiceRecord, expenses []VehicleExpense, through time.Time) VehicleEconomics {
+	months := make(map[time.Time]*EconomicsPeriod)
+	period := func(t time.Time) *EconomicsPeriod {
+		key := firstOfMonth(t)
+		if months[key] == nil {
+			months[key] = &EconomicsPeriod{Year: key.Year(), Month: int(key.Month())}
+		}
+
+		return months[key]
+	}
+
+	for month, amount := range MonthlyDepreciation(v) {
+		period(month).Depreciation += amount
+	}
+
+	for _, l := range logs {
+		p := period(time.Date(l.Year, time.Month(l.Month), 1, 0, 0, 0, 0, time.UTC))
+		p.Miles += mileageLogMiles(l)
+		p.Revenue += mileageLogRevenue(l)
+	}
+
+	for _, f := range fuel {
+		period(f.PurchaseDate).Fuel += f.Amount
+	}
+
+	for _, r := range records {
+		period(r.ServiceDate).Maintenance += r.Cost
+	}
+
+	for _, x := range expenses {
+		for month, amount := range spreadMonthly(x.Amount, x.ExpenseDate, x.CoverageMonths) {
+			period(month).Expenses += amount
+		}
+	}
+
+	e := VehicleEconomics{Vehicle: v}
+	last := firstOfMonth(through)
+	years := make(map[int]*EconomicsPeriod)
+
+	for key, p := range months {
+		if key.After(last) || *p == (EconomicsPeriod{Year: p.Year, Month: p.Month}) {
+			continue
+		}
+
+		e.Months = append(e.Months, *p)
+
+		if years[p.Year] == nil {
+			years[p.Year] = &EconomicsPeriod{Year: p.Year}
+		}
+		years[p.Year].add(*p)
+		e.Total.add(*p)
+	}
+
+	sort.Slice(e.Months, func(i, j int) bool {
+		return e.Months[i].Label() < e.Months[j].Label()
+	})
+
+	for _, y := range years {
+		e.Years = append(e.Years, *y)
+	}
+	sort.Slice(e.Years, func(i, j int) bool {
+		return e.Years[i].Year < e.Years[j].Year
+	})
+
+	return e
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonthlyDepreciation(t *testing.T) {
+	bought := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	sold := time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+
+	v := Vehicle{PurchasePrice: 1000_00, PurchaseDate: &bought, SalePrice: 900_00, SaleDate: &sold}
+	dep := MonthlyDepreciation(v)
+	if len(dep) != 3 {
+		t.Fatalf("expected 3 months of depreciation but got %d", len(dep))
+	}
+
+	var total USD
+	for _, d := range dep {
+		total += d
+	}
+	if total != 100_00 {
+		t.Errorf("expected depreciation to total $100.00 but got %s", total)
+	}
+	if dep[time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)] != 33_34 {
+		t.Errorf("expected the leftover cent in the first month but got %s", dep[time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)])
+	}
+
+	v.SaleDate = nil
+	if len(MonthlyDepreciation(v)) != DefaultDepreciationMonths {
+		t.Errorf("expected an unsold vehicle to depreciate over %d months", DefaultDepreciationMonths)
+	}
+
+	if len(MonthlyDepreciation(Vehicle{PurchasePrice: 1000_00})) != 0 {
+		t.Error("expected no depreciation without a purchase date")
+	}
+}
+
+func TestNewVehicleEconomics(t *testing.T) {
+	bought := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	v := Vehicle{Name: "Prius", BillingType: "Basic", BasePerMile: 33, PurchasePrice: 9600_00, PurchaseDate: &bought}
+
+	log := MileageLog{Vehicle: v, Year: 2026, Month: 9, StartOdometer: 1000, EndOdometer: 1200, Distance: 200}
+	log.Trips = []Trip{
+		{MileageLog: log, TripDate: time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC), StartMileage: 1000, EndMileage: 1100,
+			Riders: []Rider{{Member: Member{ID: 1}, Weight: 1}}},
+		{MileageLog: log, TripDate: time.Date(2026, 9, 9, 0, 0, 0, 0, time.UTC), StartMileage: 1100, EndMileage: 1200,
+			Riders: []Rider{{Member: Member{ID: 2}, Exempt: true}}},
+	}
+
+	fuel := []FuelPurchase{{PurchaseDate: time.Date(2026, 9, 5, 0, 0, 0, 0, time.UTC), Amount: 40_00}}
+	records := []ServiceRecord{{ServiceDate: time.Date(2026, 9, 20, 0, 0, 0, 0, time.UTC), Cost: 60_00}}
+	expenses := []VehicleExpense{{ExpenseDate: time.Date(2026, 8, 10, 0, 0, 0, 0, time.UTC), Amount: 1200_00, CoverageMonths: 12}}
+
+	e := NewVehicleEconomics(v, []MileageLog{log}, fuel, records, expenses, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))
+
+	if len(e.Months) != 3 {
+		t.Fatalf("expected August through October but got %d months", len(e.Months))
+	}
+
+	sep := e.Months[1]
+	if sep.Label() != "2026-09" {
+		t.Fatalf("expected September second but got %s", sep.Label())
+	}
+	if sep.Miles != 200 || sep.Revenue != 33_00 {
+		t.Errorf("expected 200 miles billing $33.00 but got %d miles billing %s", sep.Miles, sep.Revenue)
+	}
+	if sep.Depreciation != 100_00 || sep.Expenses != 100_00 || sep.Fuel != 40_00 || sep.Maintenance != 60_00 {
+		t.Errorf("unexpected September costs %+v", sep)
+	}
+	if sep.CostPerMile() != 1_50 || sep.BilledPerMile() != 17 {
+		t.Errorf("expected $1.50 cost and $0.17 billed per mile but got %s and %s", sep.CostPerMile(), sep.BilledPerMile())
+	}
+
+	if len(e.Years) != 1 || e.Years[0].Label() != "2026" {
+		t.Fatalf("expected one year but got %+v", e.Years)
+	}
+	if e.Total.Depreciation != 300_00 || e.Total.Expenses != 300_00 {
+		t.Errorf("expected depreciation and expenses only up to October but got %s and %s", e.Total.Depreciation, e.Total.Expenses)
+	}
+	if e.Total.Net() != 33_00-(300_00+300_00+40_00+60_00) {
+		t.Errorf("unexpected net %s", e.Total.Net())
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// FuelUnits contains the list of units a fuel purchase can be measured in
+var FuelUnits = map[string]string{
+	"gal": "Gallons",
+	"kWh": "Kilowatt Hours",
+}
+
+// FuelPurchase is a fill-up or charge for a vehicle paid for by a member.
+// The member is credited the Amount on the billing for the month of the PurchaseDate
+type FuelPurchase struct {
+	ID           int
+	Vehicle      Vehicle
+	Member       Member
+	PurchaseDate time.Time
+	Quantity     float64
+	Unit         string
+	Amount       USD
+	Notes        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidAmount is returned when a ledger entry entered by hand has an amount that can't be posted
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Ledger entry types. Charges are generated from billings, the other types are entered by hand
+const (
+	LedgerCharge     = "charge"
+	LedgerPayment    = "payment"
+	LedgerCredit     = "credit"
+	LedgerAdjustment = "adjustment"
+)
+
+// LedgerEntryTypes contains the entry types that can be entered by hand and their display names
+var LedgerEntryTypes = map[string]string{
+	LedgerPayment:    "Payment",
+	LedgerCredit:     "Credit",
+	LedgerAdjustment: "Adjustment",
+}
+
+// LedgerEntry is one line on a member's account.
+// Amount is positive when it increases what the member owes and negative when it reduces it.
+// Entries generated from a month's billing have PeriodYear and PeriodMonth set
+type LedgerEntry struct {
+	ID          int
+	Member      Member
+	EntryDate   time.Time
+	EntryType   string
+	Amount      USD
+	Description string
+	PeriodYear  int
+	PeriodMonth int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// Balance is the running balance after this entry. It is calculated, not stored
+	Balance USD
+}
+
+// CheckEnteredAmount checks the amount of an entry entered by hand, before payments and credits are negated.
+// Payments and credits must be greater than zero, a negative one would increase the balance instead of reducing it.
+// Adjustments can be either sign but not zero
+func CheckEnteredAmount(entryType string, amount USD) error {
+	if entryType == LedgerAdjustment {
+		if amount == 0 {
+			return fmt.Errorf("%w: enter a non-zero adjustment", ErrInvalidAmount)
+		}
+
+		return nil
+	}
+
+	if amount <= 0 {
+		return fmt.Errorf("%w: enter an amount greater than zero", ErrInvalidAmount)
+	}
+
+	return nil
+}
+
+// IsGenerated returns true if the entry was generated from a month's billing
+func (e LedgerEntry) IsGenerated() bool {
+	return e.PeriodYear != 0
+}
+
+// LedgerEntriesFromBilling returns the ledger entries for each member's charges and fuel credits on a mileage log.
+// Entries are dated the last day of the log's month, the same as the QBO invoice date
+func LedgerEntriesFromBilling(b MileageLogBilling) []LedgerEntry {
+	var entries []LedgerEntry
+
+	entryDate := time.Date(b.Log.Year, time.Month(b.Log.Month+1), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+
+	for _, v := range b.MemberBills {
+		charge := v.RegularTripsCost + v.LongDistanceTripsCost + v.FuelSurchargeCost
+		if charge != 0 {
+			entries = append(entries, LedgerEntry{
+				Member:      v.Member,
+				EntryDate:   entryDate,
+				EntryType:   LedgerCharge,
+				Amount:      charge,
+				Description: fmt.Sprintf("%s charges %04d-%02d", b.Log.Vehicle.Name, b.Log.Year, b.Log.Month),
+				PeriodYear:  b.Log.Year,
+				PeriodMonth: b.Log.Month,
+			})
+		}
+
+		if v.FuelCredit != 0 {
+			entries = append(entries, LedgerEntry{
+				Member:      v.Member,
+				EntryDate:   entryDate,
+				EntryType:   LedgerCredit,
+				Amount:      -v.FuelCredit,
+				Description: fmt.Sprintf("%s fuel reimbursement %04d-%02d", b.Log.Vehicle.Name, b.Log.Year, b.Log.Month),
+				PeriodYear:  b.Log.Year,
+				PeriodMonth: b.Log.Month,
+			})
+		}
+	}
+
+	return entries
+}
+
+// AgingBuckets are the upper day limits of each aging bucket. Anything older goes in the last bucket
+var AgingBuckets = [...]int{30, 60, 90}
+
+// AgingLabels are the display names of the aging buckets, including the final over 90 days bucket
+var AgingLabels = [...]string{"Current", "31-60 Days", "61-90 Days", "Over 90 Days"}
+
+// MemberBalance is a member's ledger balance with the unpaid amount split by age
+type MemberBalance struct {
+	Member  Member
+	Balance USD
+	// Aging holds the unpaid amount in each bucket of AgingLabels
+	Aging [len(AgingLabels)]USD
+	// Unapplied is money paid or credited beyond all charges, shown as a negative balance
+	Unapplied USD
+}
+
+// CalcMemberBalance totals the member's ledger entries and ages what is still owed as of asOf.
+// Payments, credits and negative adjustments are applied to the oldest charges first.
+// Entries must be sorted by date
+func CalcMemberBalance(member Member, entries []LedgerEntry, asOf time.Time) MemberBalance {
+	mb := MemberBalance{Member: member}
+
+	type openCharge struct {
+		date   time.Time
+		amount USD
+	}
+	var open []openCharge
+	var unapplied USD
+
+	for _, e := range entries {
+		mb.Balance += e.Amount
+
+		if e.Amount > 0 {
+			amount := e.Amount
+
+			// money already received is applied to the new charge
+			if unapplied > 0 {
+				applied := min(unapplied, amount)
+				unapplied -= applied
+				amount -= applied
+			}
+
+			if amount > 0 {
+				open = append(open, openCharge{date: e.EntryDate, amount: amount})
+			}
+			continue
+		}
+
+		// apply the payment to the oldest open charges
+		remaining := -e.Amount
+		for i := range open {
+			if remaining == 0 {
+				break
+			}
+
+			applied := min(remaining, open[i].amount)
+			open[i].amount -= applied
+			remaining -= applied
+		}
+		unapplied += remaining
+	}
+
+	for _, c := range open {
+		if c.amount == 0 {
+			continue
+		}
+
+		mb.Aging[agingBucket(c.date, asOf)] += c.amount
+	}
+
+	mb.Unapplied = unapplied
+
+	return mb
+}
+
+// agingBucket returns the index of the aging bucket for a charge dated d
+func agingBucket(d time.Time, asOf time.Time) int {
+	days := int(asOf.Sub(d).Hours() / 24)
+
+	for i, limit := range AgingBuckets {
+		if days <= limit {
+			return i
+		}
+	}
+
+	return len(AgingBuckets)
+}
+
+// CalcRunningBalances sets the Balance of each entry to the balance after it. Entries must be sorted by date
+func CalcRunningBalances(entries []LedgerEntry) {
+	var balance USD
+	for i := range entries {
+		balance += entries[i].Amount
+		entries[i].Balance = balance
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func ledgerDate(month int, day int) time.Time {
+	return time.Date(2026, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+var memberBalanceTests = []struct {
+	name      string
+	entries   []LedgerEntry
+	balance   USD
+	aging     [len(AgingLabels)]USD
+	unapplied USD
+}{
+	{"no entries", nil, 0, [4]USD{}, 0},
+	{"current charge", []LedgerEntry{
+		{EntryDate: ledgerDate(9, 30), Amount: 5000},
+	}, 5000, [4]USD{5000, 0, 0, 0}, 0},
+	{"each bucket", []LedgerEntry{
+		{EntryDate: ledgerDate(6, 30), Amount: 400},
+		{EntryDate: ledgerDate(7, 31), Amount: 300},
+		{EntryDate: ledgerDate(8, 31), Amount: 200},
+		{EntryDate: ledgerDate(9, 30), Amount: 100},
+	}, 1000, [4]USD{100, 200, 300, 400}, 0},
+	{"payment applied to oldest charge first", []LedgerEntry{
+		{EntryDate: ledgerDate(7, 31), Amount: 300},
+		{EntryDate: ledgerDate(8, 31), Amount: 200},
+		{EntryDate: ledgerDate(9, 5), Amount: -400},
+		{EntryDate: ledgerDate(9, 30), Amount: 100},
+	}, 200, [4]USD{100, 100, 0, 0}, 0},
+	{"overpayment carried to later charge", []LedgerEntry{
+		{EntryDate: ledgerDate(8, 31), Amount: 200},
+		{EntryDate: ledgerDate(9, 5), Amount: -300},
+		{EntryDate: ledgerDate(9, 30), Amount: 250},
+	}, 150, [4]USD{150, 0, 0, 0}, 0},
+	{"overpayment left unapplied", []LedgerEntry{
+		{EntryDate: ledgerDate(8, 31), Amount: 200},
+		{EntryDate: ledgerDate(9, 5), Amount: -300},
+	}, -100, [4]USD{}, 100},
+}
+
+func TestCalcMemberBalance(t *testing.T) {
+	asOf := ledgerDate(10, 15)
+
+	for _, tt := range memberBalanceTests {
+		mb := CalcMemberBalance(Member{}, tt.entries, asOf)
+
+		if mb.Balance != tt.balance {
+			t.Errorf("%s: expected balance %s but got %s", tt.name, tt.balance, mb.Balance)
+		}
+		if mb.Aging != tt.aging {
+			t.Errorf("%s: expected aging %v but got %v", tt.name, tt.aging, mb.Aging)
+		}
+		if mb.Unapplied != tt.unapplied {
+			t.Errorf("%s: expected unapplied %s but got %s", tt.name, tt.unapplied, mb.Unapplied)
+		}
+	}
+}
+
+func TestCheckEnteredAmount(t *testing.T) {
+	tests := []struct {
+		entryType string
+		amount    USD
+		valid     bool
+	}{
+		{LedgerPayment, 2500, true},
+		{LedgerPayment, 0, false},
+		{LedgerPayment, -2500, false},
+		{LedgerCredit, 100, true},
+		{LedgerCredit, -100, false},
+		{LedgerAdjustment, 100, true},
+		{LedgerAdjustment, -100, true},
+		{LedgerAdjustment, 0, false},
+	}
+
+	for _, tt := range tests {
+		err := CheckEnteredAmount(tt.entryType, tt.amount)
+		if tt.valid && err != nil {
+			t.Errorf("%s of %d: unexpected error %v", tt.entryType, tt.amount, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("%s of %d: expected ErrInvalidAmount, got %v", tt.entryType, tt.amount, err)
+		}
+	}
+}
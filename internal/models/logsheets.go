@@ -0,0 +1,33 @@
+package models
+
+// MileageLogColumns are the columns of a paper mileage log sheet in order. The mileage log CSV download
+// and the CSV import use the same layout, with one rider per column from the last column on
+var MileageLogColumns = []string{"Date", "End mileage 3-digits", "Miles", "Destination", "Purpose", "Riders"}
+
+// LogSheet is a blank paper mileage log for a vehicle and month, printed to keep in the car
+type LogSheet struct {
+	Vehicle Vehicle
+	Year    int
+	Month   int
+	// StartOdometer is the reading the first trip of the month starts at, 0 if it isn't known
+	StartOdometer int
+	// Members are listed on the sheet with their aliases so riders can be written the way they'll be matched
+	Members []Member
+}
+
+// NewLogSheet returns the log sheet for a vehicle and month. The starting odometer comes from the month's
+// mileage log if it has one, otherwise from where the vehicle's previous log ended
+func NewLogSheet(v Vehicle, year int, month int, log MileageLog, previousOdometer int, members []Member) LogSheet {
+	start := previousOdometer
+	if log.ID != 0 && log.StartOdometer > 0 {
+		start = log.StartOdometer
+	}
+
+	return LogSheet{
+		Vehicle:       v,
+		Year:          year,
+		Month:         month,
+		StartOdometer: start,
+		Members:       members,
+	}
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// ServiceTypes contains the list of kinds of maintenance a service record or schedule can be for
+var ServiceTypes = []string{"Oil Change", "Tire Rotation", "Inspection", "Brakes", "Tires", "Battery", "Repair", "Other"}
+
+// DefaultMaintenanceSchedules are the schedules a vehicle can be given in one step
+var DefaultMaintenanceSchedules = []MaintenanceSchedule{
+	{ServiceType: "Oil Change", IntervalMiles: 5000, IntervalMonths: 6},
+	{ServiceType: "Tire Rotation", IntervalMiles: 7500},
+	{ServiceType: "Inspection", IntervalMonths: 12},
+}
+
+// Maintenance statuses, from most to least urgent
+const (
+	MaintenanceOverdue  = "overdue"
+	MaintenanceDueSoon  = "due soon"
+	MaintenanceNoRecord = "no record"
+	MaintenanceOK       = "ok"
+)
+
+// Service is due soon when it is this close by miles or by days
+const (
+	DueSoonMiles = 500
+	DueSoonDays  = 30
+)
+
+// ServiceRecord is maintenance done on a vehicle
+type ServiceRecord struct {
+	ID          int
+	Vehicle     Vehicle
+	ServiceDate time.Time
+	Odometer    int
+	ServiceType string
+	Cost        USD
+	Vendor      string
+	Notes       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// MaintenanceSchedule is maintenance that recurs every IntervalMiles or every IntervalMonths, whichever comes first.
+// Either interval can be 0 if the service only recurs by the other
+type MaintenanceSchedule struct {
+	ID             int
+	Vehicle        Vehicle
+	ServiceType    string
+	IntervalMiles  int
+	IntervalMonths int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// MaintenanceDue is when a vehicle's scheduled maintenance is next due
+type MaintenanceDue struct {
+	Vehicle  Vehicle
+	Schedule MaintenanceSchedule
+	// LastService is the most recent service of the schedule's type, nil if there isn't one
+	LastService *ServiceRecord
+	// Odometer is the vehicle's latest odometer reading
+	Odometer int
+	// DueOdometer is 0 if the schedule doesn't recur by miles
+	DueOdometer int
+	// DueDate is nil if the schedule doesn't recur by months
+	DueDate *time.Time
+	Status  string
+}
+
+// MilesLeft returns the miles until the service is due, negative if it is overdue
+func (d MaintenanceDue) MilesLeft() int {
+	return d.DueOdometer - d.Odometer
+}
+
+// IsOverdue returns true if the service is past due by miles or by date
+func (d MaintenanceDue) IsOverdue() bool {
+	return d.Status == MaintenanceOverdue
+}
+
+// IsDueSoon returns true if the service is due within DueSoonMiles or DueSoonDays
+func (d MaintenanceDue) IsDueSoon() bool {
+	return d.Status == MaintenanceDueSoon
+}
+
+// NewMaintenanceDue works out when a schedule is next due from the vehicle's service records and latest odometer reading.
+// Without a service of the schedule's type on record there is nothing to count from, so the status is MaintenanceNoRecord
+func NewMaintenanceDue(v Vehicle, s MaintenanceSchedule, records []ServiceRecord, odometer int, today time.Time) MaintenanceDue {
+	d := MaintenanceDue{
+		Vehicle:  v,
+		Schedule: s,
+		Odometer: odometer,
+		Status:   MaintenanceNoRecord,
+	}
+
+	for i, r := range records {
+		if r.ServiceType != s.ServiceType {
+			continue
+		}
+		if d.LastService == nil || r.ServiceDate.After(d.LastService.ServiceDate) ||
+			(r.ServiceDate.Equal(d.LastService.ServiceDate) && r.Odometer > d.LastService.Odometer) {
+			d.LastService = &records[i]
+		}
+	}
+
+	if d.LastService == nil {
+		return d
+	}
+
+	d.Status = MaintenanceOK
+
+	if s.IntervalMiles > 0 {
+		d.DueOdometer = d.LastService.Odometer + s.IntervalMiles
+
+		switch {
+		case odometer >= d.DueOdometer:
+			d.Status = MaintenanceOverdue
+		case d.DueOdometer-odometer <= DueSoonMiles:
+			d.Status = MaintenanceDueSoon
+		}
+	}
+
+	if s.IntervalMonths > 0 {
+		due := d.LastService.ServiceDate.AddDate(0, s.IntervalMonths, 0)
+		d.DueDate = &due
+
+		switch {
+		case !today.Before(due):
+			d.Status = MaintenanceOverdue
+		case d.Status != MaintenanceOverdue && due.Sub(today) <= DueSoonDays*24*time.Hour:
+			d.Status = MaintenanceDueSoon
+		}
+	}
+
+	return d
+}
+
+// maintenanceStatusOrder sorts statuses from most to least urgent
+var maintenanceStatusOrder = map[string]int{
+	MaintenanceOverdue:  0,
+	MaintenanceDueSoon:  1,
+	MaintenanceNoRecord: 2,
+	MaintenanceOK:       3,
+}
+
+// SortMaintenanceDue sorts the most urgent maintenance first, then by vehicle name and service type
+func SortMaintenanceDue(due []MaintenanceDue) {
+	sort.SliceStable(due, func(i, j int) bool {
+		a, b := due[i], due[j]
+		if maintenanceStatusOrder[a.Status] != maintenanceStatusOrder[b.Status] {
+			return maintenanceStatusOrder[a.Status] < maintenanceStatusOrder[b.Status]
+		}
+		if a.Vehicle.Name != b.Vehicle.Name {
+			return a.Vehicle.Name < b.Vehicle.Name
+		}
+
+		return a.Schedule.ServiceType < b.Schedule.ServiceType
+	})
+}
+
+// LatestOdometer returns the highest odometer reading of the mileage logs' reading and the service records
+func LatestOdometer(logOdometer int, records []ServiceRecord) int {
+	latest := logOdometer
+	for _, r := range records {
+		if r.Odometer > latest {
+			latest = r.Odometer
+		}
+	}
+
+	return latest
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMaintenanceDue(t *testing.T) {
+	v := Vehicle{ID: 1, Name: "Prius"}
+	oil := MaintenanceSchedule{ServiceType: "Oil Change", IntervalMiles: 5000, IntervalMonths: 6}
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	records := []ServiceRecord{
+		{ServiceType: "Oil Change", ServiceDate: time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC), Odometer: 40000},
+		{ServiceType: "Oil Change", ServiceDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), Odometer: 44000},
+		{ServiceType: "Tire Rotation", ServiceDate: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), Odometer: 48000},
+	}
+
+	tests := []struct {
+		name     string
+		odometer int
+		today    time.Time
+		records  []ServiceRecord
+		want     string
+	}{
+		{"well within both intervals", 45000, today, records, MaintenanceOK},
+		{"close to the mileage", 48600, today, records, MaintenanceDueSoon},
+		{"past the mileage", 49000, today, records, MaintenanceOverdue},
+		{"close to the date", 45000, time.Date(2026, 12, 15, 0, 0, 0, 0, time.UTC), records, MaintenanceDueSoon},
+		{"past the date", 45000, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), records, MaintenanceOverdue},
+		{"never serviced", 45000, today, records[2:], MaintenanceNoRecord},
+	}
+
+	for _, tt := range tests {
+		d := NewMaintenanceDue(v, oil, tt.records, tt.odometer, tt.today)
+		if d.Status != tt.want {
+			t.Errorf("%s: expected %q but got %q", tt.name, tt.want, d.Status)
+		}
+	}
+
+	d := NewMaintenanceDue(v, oil, records, 45000, today)
+	if d.LastService == nil || d.LastService.Odometer != 44000 {
+		t.Fatalf("expected the latest oil change as the last service but got %+v", d.LastService)
+	}
+	if d.DueOdometer != 49000 || d.MilesLeft() != 4000 {
+		t.Errorf("expected due at 49000 with 4000 miles left but got %d and %d", d.DueOdometer, d.MilesLeft())
+	}
+	if d.DueDate == nil || !d.DueDate.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected due on 2027-01-01 but got %v", d.DueDate)
+	}
+}
+
+func TestSortMaintenanceDue(t *testing.T) {
+	due := []MaintenanceDue{
+		{Vehicle: Vehicle{Name: "Leaf"}, Status: MaintenanceOK},
+		{Vehicle: Vehicle{Name: "Prius"}, Status: MaintenanceOverdue},
+		{Vehicle: Vehicle{Name: "Bolt"}, Status: MaintenanceNoRecord},
+		{Vehicle: Vehicle{Name: "Leaf"}, Status: MaintenanceDueSoon},
+	}
+
+	SortMaintenanceDue(due)
+
+	want := []string{MaintenanceOverdue, MaintenanceDueSoon, MaintenanceNoRecord, MaintenanceOK}
+	for i, w := range want {
+		if due[i].Status != w {
+			t.Errorf("position %d: expected %q but got %q", i, w, due[i].Status)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -16,6 +17,12 @@ type MileageLog struct {
 	EndOdometer   int
 	Distance      int // calculated by EndOdometer - StartOdometer
 	Trips         []Trip
+	FuelPurchases []FuelPurchase // fuel purchases for the vehicle during the log's month
+	Status        string
+	SubmittedBy   User
+	SubmittedAt   *time.Time
+	ApprovedBy    User
+	ApprovedAt    *time.Time
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
@@ -39,10 +46,12 @@ type Trip struct {
 	StartMileage     int
 	EndMileage       int
 	LongDistanceDays int
+	Hours            float64 // only used by billing types that bill by the hour
+	Days             int     // only used by billing types that bill by the day
 	BillingRate      string
 	Destination      string
 	Purpose          string
-	Riders           []Member
+	Riders           []Rider
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 }
@@ -52,49 +61,62 @@ func (t Trip) Distance() float64 {
 	return float64(t.EndMileage - t.StartMileage)
 }
 
-// BillingMethod returns the billing method for a trip based on the vehicle. If the trip was Long Distance then long distance billing is used
-func (t Trip) BillingMethod() BillingMethod {
+// BillingMethod returns the billing method for a trip based on the vehicle rates in effect on the trip date.
+// If the trip was Long Distance then long distance billing is used. An error wrapping ErrUnknownBillingType
+// is returned if the rates name a billing type that isn't registered
+func (t Trip) BillingMethod() (BillingMethod, error) {
 	// if this is a long distance trip, we use long distance billing regardless of vehicle
 	if t.LongDistanceDays != 0 {
+		ldRates := t.MileageLog.Vehicle.LongDistanceRatesOn(t.TripDate)
 		return &LongDistanceBilling{
 			BillingName:   "Long Distance",
-			SingleDayRate: ToUSD(85.0),
-			MultiDayRate:  ToUSD(50.0),
-		}
+			SingleDayRate: ldRates.SingleDayRate,
+			MultiDayRate:  ldRates.MultiDayRate,
+		}, nil
 	}
 
-	if t.MileageLog.Vehicle.BillingType == "Basic" {
-		return &SimplePerMileBilling{
-			BillingName: "Simple Per Mile",
-			BasePerMile: t.MileageLog.Vehicle.BasePerMile,
-		}
-	}
+	rates := t.MileageLog.Vehicle.RatesOn(t.TripDate)
 
-	if t.MileageLog.Vehicle.BillingType == "Truck" {
-		return &TruckBilling{
-			BillingName:      "Truck",
-			BasePerMile:      t.MileageLog.Vehicle.BasePerMile,
-			SecondaryPerMile: t.MileageLog.Vehicle.SecondaryPerMile,
-			MinimumFee:       t.MileageLog.Vehicle.MinimumFee,
-		}
+	bt, ok := GetBillingType(rates.BillingType)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownBillingType, rates.BillingType)
 	}
 
-	return nil
+	return bt.New(rates), nil
+}
+
+// Usage returns what the billing method needs to know about the trip to price it
+func (t Trip) Usage() TripUsage {
+	return TripUsage{
+		Miles:            t.Distance(),
+		Hours:            t.Hours,
+		Days:             t.Days,
+		LongDistanceDays: t.LongDistanceDays,
+		UseSecondaryRate: t.BillingRate == "Secondary",
+	}
 }
 
+// Cost returns the cost of the trip using its billing method. A trip that can't be priced costs nothing,
+// ReconcileMileageLog reports it as an error so the billing period can't be finalized with it
 func (t Trip) Cost() USD {
-	if t.LongDistanceDays != 0 {
-		return t.BillingMethod().TripCost(float64(t.LongDistanceDays), false)
+	bm, err := t.BillingMethod()
+	if err != nil {
+		return ToUSD(0.0)
 	}
 
-	if t.BillingMethod() != nil {
-		if t.BillingMethod().Name() == "Truck" && t.BillingRate == "Secondary" {
-			return t.BillingMethod().TripCost(t.Distance(), true)
-		}
-		return t.BillingMethod().TripCost(t.Distance(), false)
+	return bm.TripCost(t.Usage())
+}
+
+// FuelSurcharge returns the fuel surcharge for the miles driven on the trip,
+// using the surcharge in effect on the trip date. It applies to long distance trips as well
+func (t Trip) FuelSurcharge() USD {
+	miles := t.Distance()
+	// if trip is less than 1 mile, trip = 1 mile
+	if miles == 0.0 {
+		miles = 1.0
 	}
 
-	return ToUSD(0.0)
+	return t.MileageLog.Vehicle.RatesOn(t.TripDate).FuelSurchargePerMile.Multiply(miles)
 }
 
 // Rider describes the rider model
@@ -104,6 +126,45 @@ type Rider struct {
 	ID        int
 	Trip      Trip
 	Member    Member
+	Weight    float64 // share of the trip relative to other riders, 1 is a full share
+	Exempt    bool    // exempt riders, such as the DRVC house account, are never billed
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// BillingWeight returns the weight used to split the trip cost, exempt riders have no weight
+func (r Rider) BillingWeight() float64 {
+	if r.Exempt {
+		return 0
+	}
+	return r.Weight
+}
+
+// RiderWeights returns the billing weight of each rider in the same order as Riders
+func (t Trip) RiderWeights() []float64 {
+	weights := make([]float64, len(t.Riders))
+	for i, r := range t.Riders {
+		weights[i] = r.BillingWeight()
+	}
+
+	return weights
+}
+
+// RiderShares returns each rider's share of the trip cost and fuel surcharge in the same order as Riders.
+// Shares are in proportion to the riders' billing weights and always add up to the trip totals
+func (t Trip) RiderShares() (cost []USD, fuelSurcharge []USD) {
+	weights := t.RiderWeights()
+
+	return t.Cost().Allocate(weights), t.FuelSurcharge().Allocate(weights)
+}
+
+// IsBillable returns true if at least one rider pays a share of the trip
+func (t Trip) IsBillable() bool {
+	for _, w := range t.RiderWeights() {
+		if w > 0 {
+			return true
+		}
+	}
+
+	return false
+}
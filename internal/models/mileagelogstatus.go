@@ -0,0 +1,65 @@
+package models
+
+import "fmt"
+
+// Mileage log review statuses. A log is a draft while its trips are being entered, is submitted by the
+// vehicle's steward once it is complete and is approved by the treasurer once it has been checked
+const (
+	MileageLogDraft     = "draft"
+	MileageLogSubmitted = "submitted"
+	MileageLogApproved  = "approved"
+)
+
+// MileageLogStatuses lists the review statuses in workflow order
+var MileageLogStatuses = []string{MileageLogDraft, MileageLogSubmitted, MileageLogApproved}
+
+// IsApproved returns true if the treasurer has approved the log
+func (m MileageLog) IsApproved() bool {
+	return m.Status == MileageLogApproved
+}
+
+// CanChangeStatus returns an error if the log can't move to status. A draft can be submitted and a
+// submitted log approved, and a submitted or approved log can be sent back to draft for changes
+func (m MileageLog) CanChangeStatus(status string) error {
+	allowed := map[string][]string{
+		MileageLogDraft:     {MileageLogSubmitted},
+		MileageLogSubmitted: {MileageLogApproved, MileageLogDraft},
+		MileageLogApproved:  {MileageLogDraft},
+	}
+
+	for _, s := range allowed[m.Status] {
+		if s == status {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("a %s mileage log can't be changed to %s", m.Status, status)
+}
+
+// FilterMileageLogsByStatus returns the logs with the given review status, or all of them if status is empty
+func FilterMileageLogsByStatus(logs []MileageLog, status string) []MileageLog {
+	if status == "" {
+		return logs
+	}
+
+	var filtered []MileageLog
+	for _, l := range logs {
+		if l.Status == status {
+			filtered = append(filtered, l)
+		}
+	}
+
+	return filtered
+}
+
+// UnapprovedMileageLogs returns the logs that haven't been approved yet
+func UnapprovedMileageLogs(logs []MileageLog) []MileageLog {
+	var unapproved []MileageLog
+	for _, l := range logs {
+		if !l.IsApproved() {
+			unapproved = append(unapproved, l)
+		}
+	}
+
+	return unapproved
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+var statusChangeTests = []struct {
+	from    string
+	to      string
+	allowed bool
+}{
+	{MileageLogDraft, MileageLogSubmitted, true},
+	{MileageLogDraft, MileageLogApproved, false},
+	{MileageLogSubmitted, MileageLogApproved, true},
+	{MileageLogSubmitted, MileageLogDraft, true},
+	{MileageLogApproved, MileageLogDraft, true},
+	{MileageLogApproved, MileageLogSubmitted, false},
+	{MileageLogDraft, "finished", false},
+}
+
+func TestMileageLogCanChangeStatus(t *testing.T) {
+	for _, tt := range statusChangeTests {
+		err := MileageLog{Status: tt.from}.CanChangeStatus(tt.to)
+		if (err == nil) != tt.allowed {
+			t.Errorf("%s to %s: expected allowed %t, got error %v", tt.from, tt.to, tt.allowed, err)
+		}
+	}
+}
+
+func TestFilterMileageLogsByStatus(t *testing.T) {
+	logs := []MileageLog{
+		{ID: 1, Status: MileageLogDraft},
+		{ID: 2, Status: MileageLogApproved},
+		{ID: 3, Status: MileageLogSubmitted},
+		{ID: 4, Status: MileageLogApproved},
+	}
+
+	if got := FilterMileageLogsByStatus(logs, ""); len(got) != 4 {
+		t.Errorf("expected all 4 logs without a status, got %d", len(got))
+	}
+
+	got := FilterMileageLogsByStatus(logs, MileageLogApproved)
+	if len(got) != 2 || got[0].ID != 2 || got[1].ID != 4 {
+		t.Errorf("expected approved logs 2 and 4, got %+v", got)
+	}
+
+	unapproved := UnapprovedMileageLogs(logs)
+	if len(unapproved) != 2 || unapproved[0].ID != 1 || unapproved[1].ID != 3 {
+		t.Errorf("expected unapproved logs 1 and 3, got %+v", unapproved)
+	}
+}
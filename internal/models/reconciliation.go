@@ -0,0 +1,183 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Reconciliation issue severities. Errors block finalizing a billing period unless overridden
+const (
+	ReconcileError   = "error"
+	ReconcileWarning = "warning"
+)
+
+// Reconciliation issue kinds
+const (
+	IssueGap           = "gap"
+	IssueOverlap       = "overlap"
+	IssueNoRiders      = "no-riders"
+	IssueDiscontinuity = "discontinuity"
+	IssueUnbilledMiles = "unbilled-miles"
+	IssueUnpriced      = "unpriced"
+)
+
+// ReconciliationIssue is one problem found in a mileage log's odometer readings or trips
+type ReconciliationIssue struct {
+	Severity string
+	Kind     string
+	// Trip is the trip the issue was found on, if any
+	Trip    *Trip
+	Miles   int
+	Message string
+}
+
+// IsError returns true if the issue blocks finalizing billing
+func (i ReconciliationIssue) IsError() bool {
+	return i.Severity == ReconcileError
+}
+
+// LogReconciliation is the result of checking a mileage log's trips against its odometer readings
+// and against the previous month's log for the vehicle
+type LogReconciliation struct {
+	Log MileageLog
+	// Previous is the vehicle's previous mileage log, or nil if there isn't one
+	Previous *MileageLog
+	Issues   []ReconciliationIssue
+	// UnbilledMiles are miles on the odometer that no billed trip covers
+	UnbilledMiles int
+}
+
+// ErrorCount returns the number of issues that block finalizing billing
+func (l LogReconciliation) ErrorCount() int {
+	count := 0
+	for _, i := range l.Issues {
+		if i.IsError() {
+			count++
+		}
+	}
+
+	return count
+}
+
+// WarningCount returns the number of issues that don't block finalizing billing
+func (l LogReconciliation) WarningCount() int {
+	return len(l.Issues) - l.ErrorCount()
+}
+
+// LastOdometer returns the highest odometer reading recorded on the log, from its end odometer or its trips
+func (m MileageLog) LastOdometer() int {
+	last := m.EndOdometer
+	for _, t := range m.Trips {
+		if t.EndMileage > last {
+			last = t.EndMileage
+		}
+	}
+
+	return last
+}
+
+// ReconcileMileageLog checks a mileage log for gaps and overlaps between its odometer readings and trips,
+// trips with no riders or no billed riders, trips that can't be priced, and a start odometer that doesn't continue from the previous log.
+// prev is the vehicle's previous mileage log, or nil if there isn't one
+func ReconcileMileageLog(log MileageLog, prev *MileageLog) LogReconciliation {
+	rec := LogReconciliation{Log: log, Previous: prev}
+
+	add := func(severity string, kind string, t *Trip, miles int, msg string) {
+		rec.Issues = append(rec.Issues, ReconciliationIssue{
+			Severity: severity,
+			Kind:     kind,
+			Trip:     t,
+			Miles:    miles,
+			Message:  msg,
+		})
+	}
+
+	if prev != nil && prev.LastOdometer() != log.StartOdometer {
+		diff := log.StartOdometer - prev.LastOdometer()
+		add(ReconcileError, IssueDiscontinuity, nil, diff,
+			fmt.Sprintf("Start odometer %d doesn't continue from %s, which ended at %d (%+d miles)",
+				log.StartOdometer, prev.Name, prev.LastOdometer(), diff))
+	}
+
+	// trips are stored latest first, check them in odometer order
+	trips := tripsByOdometer(log.Trips)
+
+	prevEnd := log.StartOdometer
+	for i := range trips {
+		t := &trips[i]
+		label := fmt.Sprintf("Trip %d-%d on %s", t.StartMileage, t.EndMileage, t.TripDate.Format("2006-01-02"))
+
+		switch {
+		case t.StartMileage > prevEnd:
+			add(ReconcileError, IssueGap, t, t.StartMileage-prevEnd,
+				fmt.Sprintf("%s starts %d miles after the previous reading of %d", label, t.StartMileage-prevEnd, prevEnd))
+			rec.UnbilledMiles += t.StartMileage - prevEnd
+		case t.StartMileage < prevEnd:
+			add(ReconcileError, IssueOverlap, t, prevEnd-t.StartMileage,
+				fmt.Sprintf("%s overlaps the previous reading of %d by %d miles", label, prevEnd, prevEnd-t.StartMileage))
+		}
+
+		if t.EndMileage < t.StartMileage {
+			add(ReconcileError, IssueOverlap, t, t.StartMileage-t.EndMileage,
+				fmt.Sprintf("%s ends before it starts", label))
+		}
+
+		if len(t.Riders) == 0 {
+			add(ReconcileError, IssueNoRiders, t, int(t.Distance()), fmt.Sprintf("%s has no riders", label))
+			rec.UnbilledMiles += int(t.Distance())
+		} else if !t.IsBillable() {
+			add(ReconcileWarning, IssueUnbilledMiles, t, int(t.Distance()),
+				fmt.Sprintf("%s has no billed riders", label))
+		}
+
+		if _, err := t.BillingMethod(); err != nil {
+			add(ReconcileError, IssueUnpriced, t, int(t.Distance()), fmt.Sprintf("%s can't be priced: %s", label, err))
+			rec.UnbilledMiles += int(t.Distance())
+		}
+
+		if t.EndMileage > prevEnd {
+			prevEnd = t.EndMileage
+		}
+	}
+
+	// an end odometer equal to the start odometer hasn't been filled in yet
+	switch {
+	case log.EndOdometer > prevEnd:
+		add(ReconcileError, IssueUnbilledMiles, nil, log.EndOdometer-prevEnd,
+			fmt.Sprintf("End odometer %d is %d miles past the last trip, which ended at %d", log.EndOdometer, log.EndOdometer-prevEnd, prevEnd))
+		rec.UnbilledMiles += log.EndOdometer - prevEnd
+	case log.EndOdometer < prevEnd && log.EndOdometer != log.StartOdometer:
+		add(ReconcileWarning, IssueOverlap, nil, prevEnd-log.EndOdometer,
+			fmt.Sprintf("End odometer %d is before the last trip, which ended at %d", log.EndOdometer, prevEnd))
+	}
+
+	return rec
+}
+
+// ReconcileMileageLogs reconciles a vehicle's mileage logs, checking each against the one before it.
+// Logs are returned in year/month order
+func ReconcileMileageLogs(logs []MileageLog) []LogReconciliation {
+	sorted := make([]MileageLog, len(logs))
+	copy(sorted, logs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Year != sorted[j].Year {
+			return sorted[i].Year < sorted[j].Year
+		}
+		if sorted[i].Month != sorted[j].Month {
+			return sorted[i].Month < sorted[j].Month
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var recs []LogReconciliation
+	for i, l := range sorted {
+		var prev *MileageLog
+		if i > 0 {
+			prev = &sorted[i-1]
+		}
+
+		recs = append(recs, ReconcileMileageLog(l, prev))
+	}
+
+	return recs
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func reconcileTrip(start int, end int, riders ...Rider) Trip {
+	return Trip{
+		MileageLog:   MileageLog{Vehicle: Vehicle{BillingType: "Basic"}},
+		TripDate:     time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		StartMileage: start,
+		EndMileage:   end,
+		Riders:       riders,
+	}
+}
+
+var rider = Rider{Member: Member{ID: 1, Name: "Jane"}, Weight: 1}
+
+var reconcileTests = []struct {
+	name     string
+	log      MileageLog
+	prev     *MileageLog
+	kinds    []string
+	errors   int
+	unbilled int
+}{
+	{"continuous", MileageLog{StartOdometer: 100, EndOdometer: 130, Trips: []Trip{
+		reconcileTrip(110, 130, rider),
+		reconcileTrip(100, 110, rider),
+	}}, &MileageLog{EndOdometer: 100}, nil, 0, 0},
+	{"gap between trips", MileageLog{StartOdometer: 100, EndOdometer: 130, Trips: []Trip{
+		reconcileTrip(115, 130, rider),
+		reconcileTrip(100, 110, rider),
+	}}, nil, []string{IssueGap}, 1, 5},
+	{"overlapping trips", MileageLog{StartOdometer: 100, EndOdometer: 130, Trips: []Trip{
+		reconcileTrip(105, 130, rider),
+		reconcileTrip(100, 110, rider),
+	}}, nil, []string{IssueOverlap}, 1, 0},
+	{"trip with no riders", MileageLog{StartOdometer: 100, EndOdometer: 110, Trips: []Trip{
+		reconcileTrip(100, 110),
+	}}, nil, []string{IssueNoRiders}, 1, 10},
+	{"only exempt riders", MileageLog{StartOdometer: 100, EndOdometer: 110, Trips: []Trip{
+		reconcileTrip(100, 110, Rider{Weight: 1, Exempt: true}),
+	}}, nil, []string{IssueUnbilledMiles}, 0, 0},
+	{"miles after last trip", MileageLog{StartOdometer: 100, EndOdometer: 150, Trips: []Trip{
+		reconcileTrip(100, 110, rider),
+	}}, nil, []string{IssueUnbilledMiles}, 1, 40},
+	{"end odometer not filled in", MileageLog{StartOdometer: 100, EndOdometer: 100, Trips: []Trip{
+		reconcileTrip(100, 110, rider),
+	}}, nil, nil, 0, 0},
+	{"unknown billing type", MileageLog{StartOdometer: 100, EndOdometer: 110, Trips: []Trip{
+		{MileageLog: MileageLog{Vehicle: Vehicle{BillingType: "Retired"}}, StartMileage: 100, EndMileage: 110, Riders: []Rider{rider}},
+	}}, nil, []string{IssueUnpriced}, 1, 10},
+	{"discontinuity with previous log", MileageLog{StartOdometer: 120, EndOdometer: 120},
+		&MileageLog{EndOdometer: 100, Trips: []Trip{reconcileTrip(100, 112, rider)}},
+		[]string{IssueDiscontinuity}, 1, 0},
+}
+
+func TestReconcileMileageLog(t *testing.T) {
+	for _, tt := range reconcileTests {
+		rec := ReconcileMileageLog(tt.log, tt.prev)
+
+		if len(rec.Issues) != len(tt.kinds) {
+			t.Errorf("%s: got %d issues %v, expected %v", tt.name, len(rec.Issues), rec.Issues, tt.kinds)
+			continue
+		}
+		for i, issue := range rec.Issues {
+			if issue.Kind != tt.kinds[i] {
+				t.Errorf("%s: issue %d is %s, expected %s", tt.name, i, issue.Kind, tt.kinds[i])
+			}
+		}
+		if rec.ErrorCount() != tt.errors {
+			t.Errorf("%s: got %d errors, expected %d", tt.name, rec.ErrorCount(), tt.errors)
+		}
+		if rec.UnbilledMiles != tt.unbilled {
+			t.Errorf("%s: got %d unbilled miles, expected %d", tt.name, rec.UnbilledMiles, tt.unbilled)
+		}
+	}
+}
+
+func TestReconcileMileageLogs(t *testing.T) {
+	logs := []MileageLog{
+		{ID: 2, Year: 2026, Month: 9, StartOdometer: 200, EndOdometer: 200},
+		{ID: 1, Year: 2026, Month: 8, StartOdometer: 100, EndOdometer: 200},
+	}
+
+	recs := ReconcileMileageLogs(logs)
+	if len(recs) != 2 || recs[0].Log.ID != 1 || recs[1].Log.ID != 2 {
+		t.Fatalf("expected logs in year/month order, got %v", recs)
+	}
+	if recs[0].Previous != nil || recs[1].Previous == nil || recs[1].Previous.ID != 1 {
+		t.Errorf("expected each log to be checked against the one before it")
+	}
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// MemberStatement is the breakdown of what a member was charged for a year/month
+type MemberStatement struct {
+	Member        Member
+	Year          int
+	Month         int
+	Lines         []StatementLine
+	FuelPurchases []FuelPurchase
+	// Billings holds the member's billing for each mileage log they were charged on
+	Billings []MemberMileageLogBilling
+}
+
+// StatementLine is one trip a member rode on
+type StatementLine struct {
+	TripDate      time.Time
+	Vehicle       string
+	Miles         float64
+	Destination   string
+	CoRiders      []string
+	LongDistance  bool
+	Exempt        bool
+	Share         USD
+	FuelSurcharge USD
+}
+
+// RegularTripsCost returns the member's total for regular trips
+func (s MemberStatement) RegularTripsCost() USD {
+	var total USD
+	for _, b := range s.Billings {
+		total += b.RegularTripsCost
+	}
+
+	return total
+}
+
+// LongDistanceTripsCost returns the member's total for long distance trips
+func (s MemberStatement) LongDistanceTripsCost() USD {
+	var total USD
+	for _, b := range s.Billings {
+		total += b.LongDistanceTripsCost
+	}
+
+	return total
+}
+
+// FuelSurchargeCost returns the member's total fuel surcharge
+func (s MemberStatement) FuelSurchargeCost() USD {
+	var total USD
+	for _, b := range s.Billings {
+		total += b.FuelSurchargeCost
+	}
+
+	return total
+}
+
+// FuelCredit returns the member's total credit for fuel they paid for
+func (s MemberStatement) FuelCredit() USD {
+	var total USD
+	for _, b := range s.Billings {
+		total += b.FuelCredit
+	}
+
+	return total
+}
+
+// Total returns what the member owes for the month after fuel credits
+func (s MemberStatement) Total() USD {
+	var total USD
+	for _, b := range s.Billings {
+		total += b.Total()
+	}
+
+	return total
+}
+
+// BuildMemberStatements returns a statement for every member billed for a trip or credited for fuel
+// in the billings, ordered by member name. Lines and totals both come from the members in each log's
+// member billings, and trip shares are split the same way, so each statement adds up to what the member
+// was billed. Members with no trips and nothing to pay, e.g. the house account, get no statement
+func BuildMemberStatements(billings []MileageLogBilling, year int, month int) []MemberStatement {
+	statements := make(map[int]*MemberStatement)
+
+	statementFor := func(member Member) *MemberStatement {
+		s, ok := statements[member.ID]
+		if !ok {
+			s = &MemberStatement{Member: member, Year: year, Month: month}
+			statements[member.ID] = s
+		}
+
+		return s
+	}
+
+	for _, b := range billings {
+		// totals come from the member billings so the statement always matches the invoice
+		for _, mb := range b.MemberBills {
+			s := statementFor(mb.Member)
+			if mb.RegularTripsCost != 0 || mb.LongDistanceTripsCost != 0 || mb.FuelSurchargeCost != 0 || mb.FuelCredit != 0 {
+				s.Billings = append(s.Billings, mb)
+			}
+		}
+
+		for _, t := range b.Log.Trips {
+			tripShares, fuelShares := t.RiderShares()
+
+			for i, r := range t.Riders {
+				if _, ok := b.MemberBills[r.Member.ID]; !ok {
+					continue
+				}
+
+				var coRiders []string
+				for j, o := range t.Riders {
+					if j != i {
+						coRiders = append(coRiders, o.Member.Name)
+					}
+				}
+
+				s := statementFor(r.Member)
+				s.Lines = append(s.Lines, StatementLine{
+					TripDate:      t.TripDate,
+					Vehicle:       b.Log.Vehicle.Name,
+					Miles:         t.Distance(),
+					Destination:   t.Destination,
+					CoRiders:      coRiders,
+					LongDistance:  t.LongDistanceDays > 0,
+					Exempt:        r.Exempt,
+					Share:         tripShares[i],
+					FuelSurcharge: fuelShares[i],
+				})
+			}
+		}
+
+		for _, f := range b.Log.FuelPurchases {
+			if _, ok := b.MemberBills[f.Member.ID]; ok {
+				s := statementFor(f.Member)
+				s.FuelPurchases = append(s.FuelPurchases, f)
+			}
+		}
+	}
+
+	var result []MemberStatement
+	for _, s := range statements {
+		if len(s.Lines) == 0 && s.Total() == 0 {
+			continue
+		}
+
+		sort.SliceStable(s.Lines, func(i, j int) bool {
+			return s.Lines[i].TripDate.Before(s.Lines[j].TripDate)
+		})
+
+		result = append(result, *s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Member.Name < result[j].Member.Name
+	})
+
+	return result
+}
+
+// Statement send statuses
+const (
+	StatementSent   = "sent"
+	StatementFailed = "failed"
+)
+
+// StatementSend records the last attempt to email a member their statement for a year/month
+type StatementSend struct {
+	ID        int
+	Year      int
+	Month     int
+	Member    Member
+	Email     string
+	Status    string
+	Error     string
+	Attempts  int
+	SentAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildMemberStatements(t *testing.T) {
+	vehicle := Vehicle{Name: "Prius", BillingType: "Basic", BasePerMile: 33}
+	log := MileageLog{Vehicle: vehicle, Year: 2026, Month: 9}
+
+	alice := Member{ID: 1, Name: "Alice"}
+	bob := Member{ID: 2, Name: "Bob"}
+	carol := Member{ID: 3, Name: "Carol"}
+
+	trip := func(day int, miles int, ldDays int, riders ...Rider) Trip {
+		return Trip{
+			MileageLog:       log,
+			TripDate:         time.Date(2026, 9, day, 0, 0, 0, 0, time.UTC),
+			StartMileage:     1000,
+			EndMileage:       1000 + miles,
+			LongDistanceDays: ldDays,
+			Riders:           riders,
+		}
+	}
+
+	log.Trips = []Trip{
+		trip(20, 10, 0, Rider{Member: bob, Weight: 1}),
+		trip(3, 101, 0, Rider{Member: alice, Weight: 1}, Rider{Member: bob, Weight: 1}, Rider{Member: carol, Weight: 1}),
+		trip(12, 250, 1, Rider{Member: alice, Weight: 1}, Rider{Member: carol, Weight: 0, Exempt: true}),
+	}
+
+	// member billings built the same way calcPerMemberBillings does
+	bills := make(map[int]MemberMileageLogBilling)
+	for _, tr := range log.Trips {
+		shares, _ := tr.RiderShares()
+		for i, r := range tr.Riders {
+			b := bills[r.Member.ID]
+			b.Member = r.Member
+			if tr.LongDistanceDays > 0 {
+				b.LongDistanceTripsCost += shares[i]
+			} else {
+				b.RegularTripsCost += shares[i]
+			}
+			bills[r.Member.ID] = b
+		}
+	}
+
+	statements := BuildMemberStatements([]MileageLogBilling{{Log: log, MemberBills: bills}}, 2026, 9)
+
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements but got %d", len(statements))
+	}
+
+	for i, name := range []string{"Alice", "Bob", "Carol"} {
+		if statements[i].Member.Name != name {
+			t.Errorf("expected statement %d to be for %s but got %s", i, name, statements[i].Member.Name)
+		}
+	}
+
+	for _, s := range statements {
+		var regular, ld USD
+		for i, l := range s.Lines {
+			if i > 0 && l.TripDate.Before(s.Lines[i-1].TripDate) {
+				t.Errorf("%s: lines are not in date order", s.Member.Name)
+			}
+
+			if l.LongDistance {
+				ld += l.Share
+			} else {
+				regular += l.Share
+			}
+		}
+
+		if regular != s.RegularTripsCost() || ld != s.LongDistanceTripsCost() {
+			t.Errorf("%s: lines add up to %s/%s but billed %s/%s", s.Member.Name, regular, ld,
+				s.RegularTripsCost(), s.LongDistanceTripsCost())
+		}
+	}
+
+	bobLines := statements[1].Lines
+	if len(bobLines) != 2 || len(bobLines[0].CoRiders) != 2 || len(bobLines[1].CoRiders) != 0 {
+		t.Errorf("expected Bob's shared trip first with 2 co-riders, got %+v", bobLines)
+	}
+
+	carolLD := statements[2].Lines[1]
+	if !carolLD.Exempt || carolLD.Share != 0 {
+		t.Errorf("expected Carol's long distance trip to be exempt with no share, got %+v", carolLD)
+	}
+}
+
+func TestBuildMemberStatementsMemberSet(t *testing.T) {
+	log := MileageLog{Vehicle: Vehicle{Name: "Prius", BillingType: "Basic", BasePerMile: 33}, Year: 2026, Month: 9}
+
+	alice := Member{ID: 1, Name: "Alice"}
+	dan := Member{ID: 4, Name: "Dan"}
+	house := Member{ID: 5, Name: "House"}
+
+	log.Trips = []Trip{{
+		MileageLog:   log,
+		TripDate:     time.Date(2026, 9, 5, 0, 0, 0, 0, time.UTC),
+		StartMileage: 1000,
+		EndMileage:   1020,
+		Riders:       []Rider{{Member: alice, Weight: 1}, {Member: dan, Weight: 1}},
+	}}
+
+	// Dan rode but has no member billing, the house account was billed nothing
+	shares, _ := log.Trips[0].RiderShares()
+	bills := map[int]MemberMileageLogBilling{
+		alice.ID: {Member: alice, RegularTripsCost: shares[0]},
+		house.ID: {Member: house},
+	}
+
+	statements := BuildMemberStatements([]MileageLogBilling{{Log: log, MemberBills: bills}}, 2026, 9)
+
+	if len(statements) != 1 || statements[0].Member.ID != alice.ID {
+		t.Fatalf("expected only Alice's statement but got %+v", statements)
+	}
+
+	if len(statements[0].Lines) != 1 || statements[0].Lines[0].Share != statements[0].Total() {
+		t.Errorf("expected Alice's line to match her total, got %+v", statements[0].Lines)
+	}
+}
@@ -14,4 +14,6 @@ type TemplateData struct {
 	Error           string
 	Form            *forms.Form
 	IsAuthenticated int
+	// ComplianceAlerts is how many vehicle documents have expired or are expiring soon
+	ComplianceAlerts int
 }
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCannotRestore is returned when an item can't be taken out of the trash as things are now
+var ErrCannotRestore = errors.New("cannot restore")
+
+// TrashItem is a deleted trip, mileage log, member or vehicle that can still be restored.
+// EntityType is one of the audited entity types
+type TrashItem struct {
+	EntityType string
+	ID         int
+	Name       string
+	// MileageLogID is the mileage log a trip belongs to, 0 for other types
+	MileageLogID int
+	// MileageLogDeleted is true if a trip's mileage log is also in the trash, in which case
+	// the log has to be restored first
+	MileageLogDeleted bool
+	DeletedAt         time.Time
+}
+
+// PurgeAt returns when the item will be permanently deleted for the given retention period
+func (t TrashItem) PurgeAt(retention time.Duration) time.Time {
+	return t.DeletedAt.Add(retention)
+}
+
+// TrashPurgeResult counts the items permanently deleted from the trash
+type TrashPurgeResult struct {
+	Trips       int64
+	MileageLogs int64
+	Members     int64
+	Vehicles    int64
+	// Skipped counts members and vehicles left in the trash because billing records still refer to them
+	Skipped int64
+	// UnusedAttachmentKeys are the stored files of purged mileage log attachments and compliance documents
+	// that nothing refers to anymore
+	UnusedAttachmentKeys []string
+}
+
+// Total returns the number of items purged
+func (r TrashPurgeResult) Total() int64 {
+	return r.Trips + r.MileageLogs + r.Members + r.Vehicles
+}
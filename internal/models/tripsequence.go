@@ -0,0 +1,243 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Trip change actions
+const (
+	TripInserted = "insert"
+	TripUpdated  = "update"
+	TripDeleted  = "delete"
+)
+
+// TripChange is one trip affected by inserting, updating or deleting a trip in a mileage log
+type TripChange struct {
+	Action string
+	// Trip is the trip after the change, or the trip being removed for a delete
+	Trip            Trip
+	OldStartMileage int
+	OldEndMileage   int
+}
+
+// TripPlan is every change needed to insert, update or delete a trip while keeping the
+// mileage log's trips continuous. Target is the trip the user changed, Cascades are the
+// neighbouring trips whose start or end mileage moves along with it
+type TripPlan struct {
+	Target   TripChange
+	Cascades []TripChange
+}
+
+// HasCascades returns true if the plan changes any trip other than the target
+func (p TripPlan) HasCascades() bool {
+	return len(p.Cascades) > 0
+}
+
+// Key returns a string describing every change in the plan, used to check that a previewed plan
+// is the one being confirmed
+func (p TripPlan) Key() string {
+	var parts []string
+	for _, c := range append([]TripChange{p.Target}, p.Cascades...) {
+		parts = append(parts, fmt.Sprintf("%s:%d:%d-%d", c.Action, c.Trip.ID, c.Trip.StartMileage, c.Trip.EndMileage))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// PlanTripInsert plans inserting a trip between existing trips, e.g. one that was left off the paper log.
+// Neighbouring trips that overlap the new trip give up the overlapping miles: an earlier trip ends where
+// the new trip starts and a later trip starts where the new trip ends
+func PlanTripInsert(trips []Trip, t Trip) (TripPlan, error) {
+	plan := TripPlan{Target: TripChange{Action: TripInserted, Trip: t, OldStartMileage: t.StartMileage, OldEndMileage: t.EndMileage}}
+
+	if t.EndMileage < t.StartMileage {
+		return plan, fmt.Errorf("end mileage (%d) cannot be less than start mileage (%d)", t.EndMileage, t.StartMileage)
+	}
+
+	cascades, err := carveTrips(tripsByOdometer(trips), t)
+	if err != nil {
+		return plan, err
+	}
+	plan.Cascades = cascades
+
+	return plan, nil
+}
+
+// PlanTripUpdate plans changing a trip's start or end mileage. Trips that ended where it started or started
+// where it ended move with it, so an end mileage can be reduced as well as increased. A change of exactly
+// a multiple of 1000 to the end mileage is an odometer roll-over and moves every later trip by the same amount
+func PlanTripUpdate(trips []Trip, t Trip) (TripPlan, error) {
+	plan := TripPlan{Target: TripChange{Action: TripUpdated, Trip: t}}
+
+	sorted := tripsByOdometer(trips)
+	idx := tripIndex(sorted, t.ID)
+	if idx < 0 {
+		return plan, fmt.Errorf("trip %d is not in the mileage log", t.ID)
+	}
+
+	old := sorted[idx]
+	plan.Target.OldStartMileage = old.StartMileage
+	plan.Target.OldEndMileage = old.EndMileage
+
+	if t.EndMileage < t.StartMileage {
+		return plan, fmt.Errorf("end mileage (%d) cannot be less than start mileage (%d)", t.EndMileage, t.StartMileage)
+	}
+
+	others := append(append([]Trip{}, sorted[:idx]...), sorted[idx+1:]...)
+
+	// roll-over: every trip from this one's old end onwards moves by the same amount
+	diff := t.EndMileage - old.EndMileage
+	if t.StartMileage == old.StartMileage && diff != 0 && diff%1000 == 0 {
+		for _, o := range others {
+			if o.StartMileage >= old.EndMileage {
+				plan.Cascades = append(plan.Cascades, shiftTrip(o, diff))
+			}
+		}
+
+		return plan, nil
+	}
+
+	moved := make(map[int]bool)
+
+	// the trip before this one ends where it now starts
+	if t.StartMileage != old.StartMileage {
+		for i := len(others) - 1; i >= 0; i-- {
+			o := others[i]
+			if o.EndMileage != old.StartMileage || o.StartMileage > old.StartMileage {
+				continue
+			}
+
+			if t.StartMileage < o.StartMileage {
+				return plan, fmt.Errorf("start mileage must be at least the previous trip's start mileage: %d", o.StartMileage)
+			}
+
+			plan.Cascades = append(plan.Cascades, moveTrip(o, o.StartMileage, t.StartMileage))
+			moved[o.ID] = true
+			break
+		}
+	}
+
+	// the trip after this one starts where it now ends
+	if t.EndMileage != old.EndMileage {
+		for _, o := range others {
+			if o.StartMileage != old.EndMileage || o.EndMileage < old.EndMileage || moved[o.ID] {
+				continue
+			}
+
+			if t.EndMileage > o.EndMileage {
+				return plan, fmt.Errorf("end mileage must be less than next trip's end mileage: %d", o.EndMileage)
+			}
+
+			plan.Cascades = append(plan.Cascades, moveTrip(o, t.EndMileage, o.EndMileage))
+			moved[o.ID] = true
+			break
+		}
+	}
+
+	// any other trips the new mileages run into give up the overlapping miles
+	var rest []Trip
+	for _, o := range others {
+		if !moved[o.ID] {
+			rest = append(rest, o)
+		}
+	}
+
+	cascades, err := carveTrips(rest, t)
+	if err != nil {
+		return plan, err
+	}
+	plan.Cascades = append(plan.Cascades, cascades...)
+
+	return plan, nil
+}
+
+// PlanTripDelete plans deleting a trip. If the next trip started where the deleted trip ended,
+// it now starts where the deleted trip started so no miles are left off the log
+func PlanTripDelete(trips []Trip, t Trip) (TripPlan, error) {
+	plan := TripPlan{Target: TripChange{Action: TripDeleted, Trip: t, OldStartMileage: t.StartMileage, OldEndMileage: t.EndMileage}}
+
+	sorted := tripsByOdometer(trips)
+	idx := tripIndex(sorted, t.ID)
+	if idx < 0 {
+		return plan, fmt.Errorf("trip %d is not in the mileage log", t.ID)
+	}
+
+	old := sorted[idx]
+	for i := idx + 1; i < len(sorted); i++ {
+		o := sorted[i]
+		if o.StartMileage == old.EndMileage && old.EndMileage != old.StartMileage {
+			plan.Cascades = append(plan.Cascades, moveTrip(o, old.StartMileage, o.EndMileage))
+			break
+		}
+	}
+
+	return plan, nil
+}
+
+// carveTrips shortens trips that overlap t so they end where t starts or start where t ends.
+// It is an error for t to fall inside a trip or to cover one entirely
+func carveTrips(trips []Trip, t Trip) ([]TripChange, error) {
+	var changes []TripChange
+
+	for _, o := range trips {
+		// no overlap
+		if o.EndMileage <= t.StartMileage || o.StartMileage >= t.EndMileage {
+			continue
+		}
+
+		switch {
+		case o.StartMileage < t.StartMileage && o.EndMileage > t.EndMileage:
+			return nil, fmt.Errorf("mileage %d-%d falls inside the trip from %d to %d", t.StartMileage, t.EndMileage, o.StartMileage, o.EndMileage)
+		case o.StartMileage < t.StartMileage:
+			changes = append(changes, moveTrip(o, o.StartMileage, t.StartMileage))
+		case o.EndMileage > t.EndMileage:
+			changes = append(changes, moveTrip(o, t.EndMileage, o.EndMileage))
+		default:
+			return nil, fmt.Errorf("mileage %d-%d covers the whole trip from %d to %d", t.StartMileage, t.EndMileage, o.StartMileage, o.EndMileage)
+		}
+	}
+
+	return changes, nil
+}
+
+func moveTrip(t Trip, start int, end int) TripChange {
+	c := TripChange{Action: TripUpdated, OldStartMileage: t.StartMileage, OldEndMileage: t.EndMileage}
+	t.StartMileage = start
+	t.EndMileage = end
+	c.Trip = t
+
+	return c
+}
+
+func shiftTrip(t Trip, miles int) TripChange {
+	return moveTrip(t, t.StartMileage+miles, t.EndMileage+miles)
+}
+
+func tripIndex(trips []Trip, id int) int {
+	for i, t := range trips {
+		if t.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// tripsByOdometer returns a copy of the trips in the order they were driven
+func tripsByOdometer(trips []Trip) []Trip {
+	sorted := make([]Trip, len(trips))
+	copy(sorted, trips)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].StartMileage != sorted[j].StartMileage {
+			return sorted[i].StartMileage < sorted[j].StartMileage
+		}
+		if sorted[i].EndMileage != sorted[j].EndMileage {
+			return sorted[i].EndMileage < sorted[j].EndMileage
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	return sorted
+}
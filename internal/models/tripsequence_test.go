@@ -0,0 +1,114 @@
+package models
+
+import (
+	"testing"
+)
+
+func seqTrip(id int, start int, end int) Trip {
+	return Trip{ID: id, StartMileage: start, EndMileage: end}
+}
+
+// seqTrips are stored latest first, the same as a mileage log's trips
+var seqTrips = []Trip{
+	seqTrip(3, 130, 150),
+	seqTrip(2, 110, 130),
+	seqTrip(1, 100, 110),
+}
+
+func checkCascades(t *testing.T, name string, plan TripPlan, expected []Trip) {
+	t.Helper()
+
+	if len(plan.Cascades) != len(expected) {
+		t.Errorf("%s: got %d cascades %v, expected %v", name, len(plan.Cascades), plan.Cascades, expected)
+		return
+	}
+
+	for i, c := range plan.Cascades {
+		e := expected[i]
+		if c.Trip.ID != e.ID || c.Trip.StartMileage != e.StartMileage || c.Trip.EndMileage != e.EndMileage {
+			t.Errorf("%s: cascade %d is trip %d %d-%d, expected trip %d %d-%d", name, i,
+				c.Trip.ID, c.Trip.StartMileage, c.Trip.EndMileage, e.ID, e.StartMileage, e.EndMileage)
+		}
+	}
+}
+
+func TestPlanTripInsert(t *testing.T) {
+	plan, err := PlanTripInsert(seqTrips, seqTrip(0, 110, 118))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "forgotten trip", plan, []Trip{seqTrip(2, 118, 130)})
+
+	plan, err = PlanTripInsert(seqTrips, seqTrip(0, 105, 115))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "overlaps both neighbours", plan, []Trip{seqTrip(1, 100, 105), seqTrip(2, 115, 130)})
+
+	plan, err = PlanTripInsert(seqTrips, seqTrip(0, 150, 160))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "after last trip", plan, nil)
+
+	if _, err := PlanTripInsert(seqTrips, seqTrip(0, 112, 118)); err == nil {
+		t.Error("expected error inserting inside a trip")
+	}
+	if _, err := PlanTripInsert(seqTrips, seqTrip(0, 105, 135)); err == nil {
+		t.Error("expected error covering a whole trip")
+	}
+}
+
+func TestPlanTripUpdate(t *testing.T) {
+	plan, err := PlanTripUpdate(seqTrips, seqTrip(2, 110, 125))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "reduce end mileage", plan, []Trip{seqTrip(3, 125, 150)})
+	if plan.Target.OldEndMileage != 130 {
+		t.Errorf("expected old end mileage of 130, got %d", plan.Target.OldEndMileage)
+	}
+
+	plan, err = PlanTripUpdate(seqTrips, seqTrip(2, 110, 140))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "increase end mileage", plan, []Trip{seqTrip(3, 140, 150)})
+
+	plan, err = PlanTripUpdate(seqTrips, seqTrip(1, 100, 1110))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "roll-over", plan, []Trip{seqTrip(2, 1110, 1130), seqTrip(3, 1130, 1150)})
+
+	plan, err = PlanTripUpdate(seqTrips, seqTrip(3, 130, 160))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "last trip", plan, nil)
+
+	if _, err := PlanTripUpdate(seqTrips, seqTrip(2, 110, 155)); err == nil {
+		t.Error("expected error for end mileage past the next trip's end")
+	}
+	if _, err := PlanTripUpdate(seqTrips, seqTrip(2, 110, 105)); err == nil {
+		t.Error("expected error for end mileage before start mileage")
+	}
+}
+
+func TestPlanTripDelete(t *testing.T) {
+	plan, err := PlanTripDelete(seqTrips, seqTrips[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "middle trip", plan, []Trip{seqTrip(3, 110, 150)})
+
+	plan, err = PlanTripDelete(seqTrips, seqTrips[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCascades(t, "last trip", plan, nil)
+
+	if plan.Key() != "delete:3:130-150" {
+		t.Errorf("unexpected plan key %q", plan.Key())
+	}
+}
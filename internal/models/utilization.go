@@ -0,0 +1,219 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// UtilizationMonths is how many months the utilization report covers
+const UtilizationMonths = 24
+
+// UtilizationColumns are the column headings of the utilization CSV, matching VehicleUtilization.CSVRow
+var UtilizationColumns = []string{"Vehicle", "Month", "Trips", "Miles", "Riders", "Days Used", "Days", "% Days Used",
+	"Long Distance Days", "Revenue"}
+
+// VehicleUtilization is how much a vehicle was used over a month, or over the whole report when Month is 0
+type VehicleUtilization struct {
+	Vehicle Vehicle
+	Year    int
+	Month   int
+	Trips   int
+	Miles   int
+	// Riders counts the distinct members who rode
+	Riders int
+	// DaysUsed counts the distinct days the vehicle was out, including every day of a multi-day trip
+	DaysUsed int
+	// Days is how many days the period has
+	Days             int
+	LongDistanceDays int
+	Revenue          USD
+}
+
+// Label returns the month as YYYY-MM, or "Total" for the whole report
+func (u VehicleUtilization) Label() string {
+	if u.Month == 0 {
+		return "Total"
+	}
+
+	return fmt.Sprintf("%04d-%02d", u.Year, u.Month)
+}
+
+// DaysUsedPercent returns the share of the period's days the vehicle was used
+func (u VehicleUtilization) DaysUsedPercent() float64 {
+	if u.Days == 0 {
+		return 0
+	}
+
+	return float64(u.DaysUsed) / float64(u.Days) * 100
+}
+
+// CSVRow returns the utilization as a row of the utilization CSV
+func (u VehicleUtilization) CSVRow() []string {
+	return []string{
+		u.Vehicle.Name,
+		u.Label(),
+		strconv.Itoa(u.Trips),
+		strconv.Itoa(u.Miles),
+		strconv.Itoa(u.Riders),
+		strconv.Itoa(u.DaysUsed),
+		strconv.Itoa(u.Days),
+		strconv.FormatFloat(u.DaysUsedPercent(), 'f', 1, 64),
+		strconv.Itoa(u.LongDistanceDays),
+		fmt.Sprintf("%.2f", u.Revenue.Float64()),
+	}
+}
+
+// VehicleUtilizationSeries is a vehicle's utilization for each month of the report, oldest first, and in total
+type VehicleUtilizationSeries struct {
+	Vehicle Vehicle
+	Months  []VehicleUtilization
+	Total   VehicleUtilization
+}
+
+// UtilizationReport is the utilization of each vehicle over the same months, least used vehicle first
+type UtilizationReport struct {
+	// Months are the first of each month of the report, oldest first
+	Months   []time.Time
+	Vehicles []VehicleUtilizationSeries
+}
+
+// tripDays returns the days a trip kept the vehicle out: the long distance days or billed days of a
+// multi-day trip, otherwise just the trip date
+func tripDays(t Trip) []time.Time {
+	n := max(t.LongDistanceDays, t.Days, 1)
+	start := time.Date(t.TripDate.Year(), t.TripDate.Month(), t.TripDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	days := make([]time.Time, n)
+	for i := range days {
+		days[i] = start.AddDate(0, 0, i)
+	}
+
+	return days
+}
+
+// tripRevenue returns what a trip billed its riders, trip cost plus fuel surcharge.
+// A trip where every rider is exempt bills nothing
+func tripRevenue(t Trip) USD {
+	if !t.IsBillable() {
+		return 0
+	}
+
+	return t.Cost() + t.FuelSurcharge()
+}
+
+// vehicleUtilizationAccumulator collects the distinct riders and days while a period's utilization is totalled
+type vehicleUtilizationAccumulator struct {
+	VehicleUtilization
+	riders map[int]bool
+	days   map[time.Time]bool
+}
+
+// add adds a trip to the period. Only the trip's days from start up to end count towards DaysUsed
+func (a *vehicleUtilizationAccumulator) add(t Trip, start time.Time, end time.Time) {
+	a.Trips++
+	a.Miles += int(t.Distance())
+	a.LongDistanceDays += t.LongDistanceDays
+	a.Revenue += tripRevenue(t)
+
+	for _, r := range t.Riders {
+		a.riders[r.Member.ID] = true
+	}
+	for _, d := range tripDays(t) {
+		if !d.Before(start) && d.Before(end) {
+			a.days[d] = true
+		}
+	}
+
+	a.Riders = len(a.riders)
+	a.DaysUsed = len(a.days)
+}
+
+func newVehicleUtilizationAccumulator(u VehicleUtilization) *vehicleUtilizationAccumulator {
+	return &vehicleUtilizationAccumulator{
+		VehicleUtilization: u,
+		riders:             make(map[int]bool),
+		days:               make(map[time.Time]bool),
+	}
+}
+
+// NewUtilizationReport totals the trips on each vehicle's mileage logs for the months up to and including
+// through's month. Trips count towards the month of their mileage log. Vehicles are sorted by days used,
+// then miles, so the least used come first
+func NewUtilizationReport(vehicles []Vehicle, logs []MileageLog, through time.Time, months int) UtilizationReport {
+	var report UtilizationReport
+
+	last := firstOfMonth(through)
+	for i := months - 1; i >= 0; i-- {
+		report.Months = append(report.Months, last.AddDate(0, -i, 0))
+	}
+	if len(report.Months) == 0 {
+		return report
+	}
+	start := report.Months[0]
+	end := last.AddDate(0, 1, 0)
+
+	logsByVehicle := make(map[int][]MileageLog)
+	for _, l := range logs {
+		logsByVehicle[l.Vehicle.ID] = append(logsByVehicle[l.Vehicle.ID], l)
+	}
+
+	for _, v := range vehicles {
+		total := newVehicleUtilizationAccumulator(VehicleUtilization{Vehicle: v, Days: int(end.Sub(start).Hours() / 24)})
+
+		monthly := make(map[time.Time]*vehicleUtilizationAccumulator)
+		for _, m := range report.Months {
+			monthly[m] = newVehicleUtilizationAccumulator(VehicleUtilization{
+				Vehicle: v,
+				Year:    m.Year(),
+				Month:   int(m.Month()),
+				Days:    m.AddDate(0, 1, -1).Day(),
+			})
+		}
+
+		for _, l := range logsByVehicle[v.ID] {
+			m := time.Date(l.Year, time.Month(l.Month), 1, 0, 0, 0, 0, time.UTC)
+			a, ok := monthly[m]
+			if !ok {
+				continue
+			}
+
+			for _, t := range l.Trips {
+				a.add(t, m, m.AddDate(0, 1, 0))
+				total.add(t, start, end)
+			}
+		}
+
+		series := VehicleUtilizationSeries{Vehicle: v, Total: total.VehicleUtilization}
+		for _, m := range report.Months {
+			series.Months = append(series.Months, monthly[m].VehicleUtilization)
+		}
+
+		report.Vehicles = append(report.Vehicles, series)
+	}
+
+	sort.SliceStable(report.Vehicles, func(i, j int) bool {
+		a, b := report.Vehicles[i].Total, report.Vehicles[j].Total
+		if a.DaysUsed != b.DaysUsed {
+			return a.DaysUsed < b.DaysUsed
+		}
+
+		return a.Miles < b.Miles
+	})
+
+	return report
+}
+
+// CSVRows returns a row of the utilization CSV for every vehicle and month, followed by each vehicle's total
+func (r UtilizationReport) CSVRows() [][]string {
+	rows := [][]string{UtilizationColumns}
+	for _, s := range r.Vehicles {
+		for _, u := range s.Months {
+			rows = append(rows, u.CSVRow())
+		}
+		rows = append(rows, s.Total.CSVRow())
+	}
+
+	return rows
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewUtilizationReport(t *testing.T) {
+	prius := Vehicle{ID: 1, Name: "Prius", BillingType: "Basic", BasePerMile: 33}
+	leaf := Vehicle{ID: 2, Name: "Leaf"}
+	alice := Rider{Member: Member{ID: 1}, Weight: 1}
+	bob := Rider{Member: Member{ID: 2}, Weight: 1}
+
+	log := MileageLog{Vehicle: prius, Year: 2026, Month: 9}
+	trip := func(day int, miles int, ldDays int, riders ...Rider) Trip {
+		return Trip{MileageLog: log, TripDate: time.Date(2026, 9, day, 0, 0, 0, 0, time.UTC),
+			StartMileage: 1000, EndMileage: 1000 + miles, LongDistanceDays: ldDays, Riders: riders}
+	}
+	log.Trips = []Trip{
+		trip(3, 100, 0, alice),
+		trip(3, 20, 0, alice, bob),
+		// out for the last day of September and the first two of October
+		trip(30, 300, 3, bob),
+	}
+	old := MileageLog{Vehicle: prius, Year: 2024, Month: 1, Trips: []Trip{trip(1, 50, 0, alice)}}
+
+	report := NewUtilizationReport([]Vehicle{prius, leaf}, []MileageLog{log, old}, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), 24)
+
+	if len(report.Months) != 24 || !report.Months[0].Equal(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected 24 months from 2024-11 but got %d from %v", len(report.Months), report.Months[0])
+	}
+	if report.Vehicles[0].Vehicle.Name != "Leaf" {
+		t.Errorf("expected the unused Leaf first but got %s", report.Vehicles[0].Vehicle.Name)
+	}
+
+	prius1 := report.Vehicles[1]
+	sep := prius1.Months[22]
+	if sep.Label() != "2026-09" {
+		t.Fatalf("expected 2026-09 but got %s", sep.Label())
+	}
+	if sep.Trips != 3 || sep.Miles != 420 || sep.Riders != 2 || sep.DaysUsed != 2 || sep.Days != 30 || sep.LongDistanceDays != 3 {
+		t.Errorf("unexpected September utilization %+v", sep)
+	}
+	if tripRevenue(log.Trips[0]) != 33_00 || sep.Revenue != tripRevenue(log.Trips[0])+tripRevenue(log.Trips[1])+tripRevenue(log.Trips[2]) {
+		t.Errorf("expected revenue to add up the trips but got %s", sep.Revenue)
+	}
+
+	if prius1.Total.Trips != 3 || prius1.Total.DaysUsed != 4 {
+		t.Errorf("expected the 2024-01 log left out and the October days counted in the total but got %+v", prius1.Total)
+	}
+	if prius1.Total.Days != 730 {
+		t.Errorf("expected 730 days in the report but got %d", prius1.Total.Days)
+	}
+
+	rows := report.CSVRows()
+	if len(rows) != 1+2*25 || rows[0][0] != "Vehicle" {
+		t.Errorf("expected a header and 25 rows per vehicle but got %d rows", len(rows))
+	}
+}
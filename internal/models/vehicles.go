@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"maps"
+	"time"
+)
 
 // FuelTypes contains the list of possible fuel types a vehicle can be
 var FuelTypes = map[string]string{
@@ -10,9 +13,6 @@ var FuelTypes = map[string]string{
 	"DI": "Diesel",
 }
 
-// BillingTypes contains the list of allowed billing methods
-var BillingTypes = [...]string{"Basic", "Truck"}
-
 // BillingRates contains the list of allowed billing rates
 var BillingRates = [...]string{"Primary", "Secondary"}
 
@@ -41,4 +41,106 @@ type Vehicle struct {
 	BasePerMile      USD
 	SecondaryPerMile USD
 	MinimumFee       USD
+	// FuelSurchargePerMile is added to every trip on top of the billing type's cost
+	FuelSurchargePerMile USD
+	// BillingParams holds the rates for the billing type that don't have their own column
+	BillingParams BillingParams
+	RateSchedules []RateSchedule
+	// LongDistanceRates holds the co-op wide long distance rates and those for any billing type
+	LongDistanceRates []LongDistanceRate
+}
+
+// RateSchedule is the billing rate for a vehicle over a range of dates.
+// ValidTo is nil for the schedule that is still in effect
+type RateSchedule struct {
+	ID                   int
+	Vehicle              Vehicle
+	BillingType          string
+	BasePerMile          USD
+	SecondaryPerMile     USD
+	MinimumFee           USD
+	FuelSurchargePerMile USD
+	Params               BillingParams
+	ValidFrom            time.Time
+	ValidTo              *time.Time
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// InEffect returns true if the rate schedule applies on the given date
+func (r RateSchedule) InEffect(d time.Time) bool {
+	return inDateRange(d, r.ValidFrom, r.ValidTo)
+}
+
+// inDateRange returns true if d falls between from and to inclusive. A nil to is open-ended
+func inDateRange(d time.Time, from time.Time, to *time.Time) bool {
+	if d.Before(from) {
+		return false
+	}
+
+	return to == nil || !d.After(*to)
+}
+
+// CurrentRates returns a rate schedule built from the billing fields on the vehicle row
+func (v Vehicle) CurrentRates() RateSchedule {
+	return RateSchedule{
+		Vehicle:              Vehicle{ID: v.ID},
+		BillingType:          v.BillingType,
+		BasePerMile:          v.BasePerMile,
+		SecondaryPerMile:     v.SecondaryPerMile,
+		MinimumFee:           v.MinimumFee,
+		FuelSurchargePerMile: v.FuelSurchargePerMile,
+		Params:               cloneParams(v.BillingParams),
+	}
+}
+
+// RatesOn returns the rate schedule in effect on the given date.
+// If no schedule covers the date, the vehicle's current billing fields are used
+func (v Vehicle) RatesOn(d time.Time) RateSchedule {
+	for _, r := range v.RateSchedules {
+		if r.InEffect(d) {
+			return r
+		}
+	}
+
+	return v.CurrentRates()
+}
+
+// LongDistanceRatesOn returns the long distance rate in effect on the given date.
+// A rate for the vehicle's billing type on that date takes priority over the co-op wide rate
+func (v Vehicle) LongDistanceRatesOn(d time.Time) LongDistanceRate {
+	billingType := v.RatesOn(d).BillingType
+	found := false
+	var rate LongDistanceRate
+
+	for _, r := range v.LongDistanceRates {
+		if !r.InEffect(d) {
+			continue
+		}
+
+		if r.BillingType == billingType {
+			return r
+		}
+
+		if r.BillingType == "" {
+			rate = r
+			found = true
+		}
+	}
+
+	if !found {
+		return defaultLongDistanceRate
+	}
+
+	return rate
+}
+
+// RatesChanged returns true if any billing field differs between the two vehicles
+func (v Vehicle) RatesChanged(o Vehicle) bool {
+	return v.BillingType != o.BillingType ||
+		v.BasePerMile != o.BasePerMile ||
+		v.SecondaryPerMile != o.SecondaryPerMile ||
+		v.MinimumFee != o.MinimumFee ||
+		v.FuelSurchargePerMile != o.FuelSurchargePerMile ||
+		!maps.Equal(v.BillingParams, o.BillingParams)
 }
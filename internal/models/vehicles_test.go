@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateScheduleInEffect(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	closed := RateSchedule{ValidFrom: from, ValidTo: &to}
+	open := RateSchedule{ValidFrom: from}
+
+	tests := []struct {
+		name   string
+		r      RateSchedule
+		d      time.Time
+		expect bool
+	}{
+		{"day before valid from", closed, from.AddDate(0, 0, -1), false},
+		{"on valid from", closed, from, true},
+		{"on valid to", closed, to, true},
+		{"day after valid to", closed, to.AddDate(0, 0, 1), false},
+		{"open ended before valid from", open, from.AddDate(0, 0, -1), false},
+		{"open ended long after", open, from.AddDate(10, 0, 0), true},
+	}
+
+	for _, tt := range tests {
+		if tt.r.InEffect(tt.d) != tt.expect {
+			t.Errorf("%s: expected %t", tt.name, tt.expect)
+		}
+	}
+}
+
+func TestVehicleRatesOn(t *testing.T) {
+	changed := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	oldTo := changed.AddDate(0, 0, -1)
+
+	v := Vehicle{
+		BillingType: "Basic",
+		BasePerMile: ToUSD(0.50),
+		RateSchedules: []RateSchedule{
+			{ID: 2, BillingType: "Basic", BasePerMile: ToUSD(0.40), ValidFrom: changed},
+			{ID: 1, BillingType: "Basic", BasePerMile: ToUSD(0.30), ValidFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), ValidTo: &oldTo},
+		},
+	}
+
+	tests := []struct {
+		name string
+		d    time.Time
+		id   int
+		rate USD
+	}{
+		{"before any schedule uses the vehicle row", time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), 0, ToUSD(0.50)},
+		{"first day of the old schedule", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 1, ToUSD(0.30)},
+		{"last day of the old schedule", oldTo, 1, ToUSD(0.30)},
+		{"first day of the new schedule", changed, 2, ToUSD(0.40)},
+		{"open ended new schedule", time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC), 2, ToUSD(0.40)},
+	}
+
+	for _, tt := range tests {
+		r := v.RatesOn(tt.d)
+		if r.ID != tt.id || r.BasePerMile != tt.rate {
+			t.Errorf("%s: expected schedule %d at %s but got schedule %d at %s", tt.name, tt.id, tt.rate, r.ID, r.BasePerMile)
+		}
+	}
+}
+
+func TestVehicleLongDistanceRatesOn(t *testing.T) {
+	changed := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	oldTo := changed.AddDate(0, 0, -1)
+	coopFrom := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	v := Vehicle{
+		BillingType: "Truck",
+		RateSchedules: []RateSchedule{
+			{ID: 2, BillingType: "Truck", ValidFrom: changed},
+			{ID: 1, BillingType: "Basic", ValidFrom: coopFrom, ValidTo: &oldTo},
+		},
+		LongDistanceRates: []LongDistanceRate{
+			{ID: 1, SingleDayRate: ToUSD(80), ValidFrom: coopFrom},
+			{ID: 2, BillingType: "Truck", SingleDayRate: ToUSD(120), ValidFrom: changed},
+			{ID: 3, BillingType: "Basic", SingleDayRate: ToUSD(70), ValidFrom: coopFrom, ValidTo: &oldTo},
+		},
+	}
+
+	tests := []struct {
+		name string
+		d    time.Time
+		id   int
+		rate USD
+	}{
+		{"before any rate uses the default", coopFrom.AddDate(0, 0, -1), 0, defaultLongDistanceRate.SingleDayRate},
+		{"billing type rate beats the co-op wide rate", coopFrom, 3, ToUSD(70)},
+		{"billing type rate for the type in effect that day", changed, 2, ToUSD(120)},
+		{"last day of the old billing type and its rate", oldTo, 3, ToUSD(70)},
+	}
+
+	for _, tt := range tests {
+		r := v.LongDistanceRatesOn(tt.d)
+		if r.ID != tt.id || r.SingleDayRate != tt.rate {
+			t.Errorf("%s: expected rate %d at %s but got rate %d at %s", tt.name, tt.id, tt.rate, r.ID, r.SingleDayRate)
+		}
+	}
+
+	basic := Vehicle{BillingType: "Basic", LongDistanceRates: v.LongDistanceRates[:2]}
+	if r := basic.LongDistanceRatesOn(changed); r.ID != 1 {
+		t.Errorf("expected the co-op wide rate for a type without its own rate, got rate %d", r.ID)
+	}
+}
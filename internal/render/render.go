@@ -39,6 +39,7 @@ func AddDefaultData(td *models.TemplateData, r *http.Request) *models.TemplateDa
 
 	if app.Session.Exists(r.Context(), "user_id") {
 		td.IsAuthenticated = 1
+		td.ComplianceAlerts = int(app.ComplianceAlerts.Load())
 	}
 	
 	return td
@@ -0,0 +1,151 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// attachmentCols lists the columns in the mileage_log_attachments table EXCEPT "id"
+const attachmentCols = `mileage_log_id, storage_key, filename, content_type, size, uploaded_by,
+				created_at, updated_at`
+
+// storageKeyUnusedQuery checks that no attachment or compliance document refers to the stored file with key $1,
+// since identical uploads share one file
+const storageKeyUnusedQuery = `SELECT NOT EXISTS (SELECT 1 FROM mileage_log_attachments WHERE storage_key = $1)
+			AND NOT EXISTS (SELECT 1 FROM compliance_documents WHERE storage_key = $1)`
+
+// InsertAttachment records a file uploaded for a mileage log and returns its id
+func (m *postgresDBRepo) InsertAttachment(a models.Attachment, userID int) (int, error) {
+	return runInTxReturnID(m.DB, func(tx *sql.Tx) (int, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		stmt := fmt.Sprintf(`INSERT INTO mileage_log_attachments (%s)
+				VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8) RETURNING id`,
+			attachmentCols)
+
+		err := tx.QueryRowContext(ctx, stmt,
+			a.MileageLogID, a.Key, a.Filename, a.ContentType, a.Size, userID,
+			time.Now(), time.Now(),
+		).Scan(&a.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		err = insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditAttachment,
+			EntityID:     a.ID,
+			MileageLogID: a.MileageLogID,
+			Action:       models.AuditInsert,
+			User:         models.User{ID: userID},
+		}, nil, models.NewAttachmentAuditSnapshot(a))
+		if err != nil {
+			return 0, err
+		}
+
+		return a.ID, nil
+	})
+}
+
+func scanRowsToAttachments(rows *sql.Rows) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+
+	for rows.Next() {
+		a := models.Attachment{}
+		err := rows.Scan(&a.ID, &a.MileageLogID, &a.Key, &a.Filename, &a.ContentType, &a.Size,
+			&a.UploadedBy.ID, &a.UploadedBy.FirstName, &a.UploadedBy.LastName,
+			&a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			return attachments, err
+		}
+
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// attachmentSelect selects attachments with the name of the user who uploaded them
+const attachmentSelect = `SELECT a.id, a.mileage_log_id, a.storage_key, a.filename, a.content_type, a.size,
+			COALESCE(a.uploaded_by, 0), COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+			a.created_at, a.updated_at
+		FROM mileage_log_attachments a
+		LEFT JOIN users u ON u.id = a.uploaded_by`
+
+// GetAttachmentsByMileageLogID returns the files uploaded for a mileage log in the order they were uploaded
+func (m *postgresDBRepo) GetAttachmentsByMileageLogID(logID int) ([]models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := attachmentSelect + ` WHERE a.mileage_log_id = $1 ORDER BY a.created_at, a.id`
+
+	rows, err := m.DB.QueryContext(ctx, q, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToAttachments(rows)
+}
+
+// GetAttachmentByID returns one attachment
+func (m *postgresDBRepo) GetAttachmentByID(id int) (models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, attachmentSelect+` WHERE a.id = $1`, id)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	defer rows.Close()
+
+	attachments, err := scanRowsToAttachments(rows)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	if len(attachments) == 0 {
+		return models.Attachment{}, sql.ErrNoRows
+	}
+
+	return attachments[0], nil
+}
+
+// DeleteAttachment removes an attachment from its mileage log. It returns true if no other attachment
+// or compliance document refers to the same stored file, in which case the file can be deleted from storage
+func (m *postgresDBRepo) DeleteAttachment(id int, userID int) (bool, error) {
+	a, err := m.GetAttachmentByID(id)
+	if err != nil {
+		return false, err
+	}
+
+	var unused bool
+	err = runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM mileage_log_attachments WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, storageKeyUnusedQuery, a.Key).Scan(&unused)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditAttachment,
+			EntityID:     id,
+			MileageLogID: a.MileageLogID,
+			Action:       models.AuditDelete,
+			User:         models.User{ID: userID},
+		}, models.NewAttachmentAuditSnapshot(a), nil)
+	})
+
+	return unused, err
+}
@@ -0,0 +1,155 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+const auditLogCols = `entity_type, entity_id, mileage_log_id, action, user_id, before_data, after_data, created_at`
+
+// GetAuditEntriesByMileageLogID returns the history of a mileage log and its trips, newest first
+func (m *postgresDBRepo) GetAuditEntriesByMileageLogID(logID int) ([]models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT a.id, a.entity_type, a.entity_id, a.mileage_log_id, a.action,
+			COALESCE(a.user_id, 0), COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+			a.before_data, a.after_data, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.mileage_log_id = $1
+		ORDER BY a.created_at DESC, a.id DESC`
+
+	rows, err := m.DB.QueryContext(ctx, q, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToAuditEntries(rows)
+}
+
+// GetAuditEntriesByEntity returns the history of one member, vehicle, mileage log or trip, newest first
+func (m *postgresDBRepo) GetAuditEntriesByEntity(entityType string, entityID int) ([]models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT a.id, a.entity_type, a.entity_id, a.mileage_log_id, a.action,
+			COALESCE(a.user_id, 0), COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+			a.before_data, a.after_data, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.entity_type = $1 AND a.entity_id = $2
+		ORDER BY a.created_at DESC, a.id DESC`
+
+	rows, err := m.DB.QueryContext(ctx, q, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToAuditEntries(rows)
+}
+
+func scanRowsToAuditEntries(rows *sql.Rows) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+
+	for rows.Next() {
+		e := models.AuditEntry{}
+		err := rows.Scan(&e.ID, &e.EntityType, &e.EntityID, &e.MileageLogID, &e.Action,
+			&e.User.ID, &e.User.FirstName, &e.User.LastName,
+			&e.Before, &e.After, &e.CreatedAt)
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// insertAuditEntryTx records a change in the audit log as part of the transaction making the change.
+// before and after are marshalled to JSON, a nil snapshot is stored as empty
+func insertAuditEntryTx(tx *sql.Tx, ctx context.Context, e models.AuditEntry, before interface{}, after interface{}) error {
+	var err error
+
+	e.Before, err = auditJSON(before)
+	if err != nil {
+		return err
+	}
+
+	e.After, err = auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO audit_log (%s)
+			VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, $7, $8)`,
+		auditLogCols)
+
+	_, err = tx.ExecContext(ctx, stmt,
+		e.EntityType, e.EntityID, e.MileageLogID, e.Action, e.User.ID, e.Before, e.After, time.Now())
+
+	return err
+}
+
+func auditJSON(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// getTripAuditSnapshotTx reads a trip and its riders' names inside a transaction for the audit log
+func getTripAuditSnapshotTx(tx *sql.Tx, ctx context.Context, id int) (models.TripAuditSnapshot, error) {
+	t := models.Trip{ID: id}
+
+	q := `SELECT mileage_log_id, trip_date, start_mileage, end_mileage, long_distance_days, billing_rate,
+			destination, purpose, hours, days
+		FROM trips WHERE id = $1`
+
+	err := tx.QueryRowContext(ctx, q, id).Scan(&t.MileageLog.ID, &t.TripDate, &t.StartMileage, &t.EndMileage,
+		&t.LongDistanceDays, &t.BillingRate, &t.Destination, &t.Purpose, &t.Hours, &t.Days)
+	if err != nil {
+		return models.TripAuditSnapshot{}, err
+	}
+
+	q = `SELECT r.member_id, m.name, r.share_weight, r.is_exempt
+		FROM riders r
+		JOIN members m ON m.id = r.member_id
+		WHERE r.trip_id = $1
+		ORDER BY r.id`
+
+	rows, err := tx.QueryContext(ctx, q, id)
+	if err != nil {
+		return models.TripAuditSnapshot{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := models.Rider{}
+		err := rows.Scan(&r.Member.ID, &r.Member.Name, &r.Weight, &r.Exempt)
+		if err != nil {
+			return models.TripAuditSnapshot{}, err
+		}
+
+		t.Riders = append(t.Riders, r)
+	}
+	if err := rows.Err(); err != nil {
+		return models.TripAuditSnapshot{}, err
+	}
+
+	return models.NewTripAuditSnapshot(t), nil
+}
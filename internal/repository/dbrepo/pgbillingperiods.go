@@ -0,0 +1,314 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// billingPeriodCols lists the columns in the billing_periods table EXCEPT "id"
+const billingPeriodCols = `year, month, status, finalized_at, exported_at, created_at, updated_at`
+
+// billingPeriodChargeCols lists the columns in the billing_period_charges table EXCEPT "id"
+const billingPeriodChargeCols = `billing_period_id, member_id, vehicle_id, mileage_log_id,
+				regular_trips_cost, long_distance_trips_cost, fuel_surcharge_cost, fuel_credit,
+				created_at, updated_at`
+
+// billingPeriodEventCols lists the columns in the billing_period_events table EXCEPT "id"
+const billingPeriodEventCols = `billing_period_id, user_id, action, reason, created_at, updated_at`
+
+// scanBillingPeriod scans a billing_periods row. A missing row is returned as an open period
+func scanBillingPeriod(row *sql.Row, year int, month int) (models.BillingPeriod, error) {
+	p := models.BillingPeriod{}
+	err := row.Scan(&p.ID, &p.Year, &p.Month, &p.Status, &p.FinalizedAt, &p.ExportedAt, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.BillingPeriod{Year: year, Month: month, Status: models.BillingPeriodOpen}, nil
+	}
+
+	return p, err
+}
+
+// GetBillingPeriod returns the billing period for a year & month with its charges and events.
+// A period that has never been finalized is returned as open with an ID of 0
+func (m *postgresDBRepo) GetBillingPeriod(year int, month int) (models.BillingPeriod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM billing_periods WHERE year = $1 AND month = $2`, billingPeriodCols)
+
+	p, err := scanBillingPeriod(m.DB.QueryRowContext(ctx, q, year, month), year, month)
+	if err != nil || p.ID == 0 {
+		return p, err
+	}
+
+	p.Charges, err = m.getBillingPeriodCharges(p.ID)
+	if err != nil {
+		return p, err
+	}
+
+	p.Events, err = m.getBillingPeriodEvents(p.ID)
+	if err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+// GetBillingPeriodByMileageLogID returns the billing period that a mileage log falls in, without charges or events
+func (m *postgresDBRepo) GetBillingPeriodByMileageLogID(logID int) (models.BillingPeriod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	var year, month int
+	err := m.DB.QueryRowContext(ctx, `SELECT year, month FROM mileage_logs WHERE id = $1`, logID).Scan(&year, &month)
+	if err != nil {
+		return models.BillingPeriod{}, err
+	}
+
+	q := fmt.Sprintf(`SELECT id, %s FROM billing_periods WHERE year = $1 AND month = $2`, billingPeriodCols)
+
+	return scanBillingPeriod(m.DB.QueryRowContext(ctx, q, year, month), year, month)
+}
+
+// GetLatestLockedBillingPeriod returns the most recent finalized or exported billing period, without its charges
+// and events. If no period has been finalized an open period with an ID of 0 is returned
+func (m *postgresDBRepo) GetLatestLockedBillingPeriod() (models.BillingPeriod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM billing_periods
+		WHERE status IN ('finalized', 'exported')
+		ORDER BY year DESC, month DESC LIMIT 1`, billingPeriodCols)
+
+	return scanBillingPeriod(m.DB.QueryRowContext(ctx, q), 0, 0)
+}
+
+// getBillingPeriodCharges returns the snapshotted charges for a billing period ordered by member and vehicle name
+func (m *postgresDBRepo) getBillingPeriodCharges(periodID int) ([]models.BillingPeriodCharge, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT c.id, c.billing_period_id, c.member_id, mb.name, c.vehicle_id, v.name, c.mileage_log_id,
+			c.regular_trips_cost, c.long_distance_trips_cost, c.fuel_surcharge_cost, c.fuel_credit,
+			c.created_at, c.updated_at
+		FROM billing_period_charges c
+		JOIN members mb ON mb.id = c.member_id
+		JOIN vehicles v ON v.id = c.vehicle_id
+		WHERE c.billing_period_id = $1
+		ORDER BY mb.name, v.name`
+
+	rows, err := m.DB.QueryContext(ctx, q, periodID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var charges []models.BillingPeriodCharge
+
+	for rows.Next() {
+		c := models.BillingPeriodCharge{}
+		err := rows.Scan(&c.ID, &c.BillingPeriodID, &c.Member.ID, &c.Member.Name, &c.Vehicle.ID, &c.Vehicle.Name,
+			&c.MileageLogID, &c.RegularTripsCost, &c.LongDistanceTripsCost, &c.FuelSurchargeCost, &c.FuelCredit,
+			&c.CreatedAt, &c.UpdatedAt)
+		if err != nil {
+			return charges, err
+		}
+
+		charges = append(charges, c)
+	}
+
+	return charges, rows.Err()
+}
+
+// getBillingPeriodEvents returns the status changes for a billing period, oldest first
+func (m *postgresDBRepo) getBillingPeriodEvents(periodID int) ([]models.BillingPeriodEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT e.id, e.billing_period_id, e.user_id, u.first_name, u.last_name, e.action, e.reason,
+			e.created_at, e.updated_at
+		FROM billing_period_events e
+		JOIN users u ON u.id = e.user_id
+		WHERE e.billing_period_id = $1
+		ORDER BY e.created_at, e.id`
+
+	rows, err := m.DB.QueryContext(ctx, q, periodID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.BillingPeriodEvent
+
+	for rows.Next() {
+		e := models.BillingPeriodEvent{}
+		err := rows.Scan(&e.ID, &e.BillingPeriodID, &e.User.ID, &e.User.FirstName, &e.User.LastName,
+			&e.Action, &e.Reason, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return events, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// upsertBillingPeriodStatus sets the status of a year & month's billing period, creating the row
+// if needed, and returns its id
+func upsertBillingPeriodStatus(ctx context.Context, tx *sql.Tx, year int, month int, status string) (int, error) {
+	var id int
+
+	stmt := fmt.Sprintf(`INSERT INTO billing_periods (%s)
+				VALUES ($1, $2, $3, NULL, NULL, $4, $5)
+				ON CONFLICT (year, month) DO UPDATE SET status = EXCLUDED.status, updated_at = EXCLUDED.updated_at
+				RETURNING id`,
+		billingPeriodCols)
+
+	err := tx.QueryRowContext(ctx, stmt, year, month, status, time.Now(), time.Now()).Scan(&id)
+
+	return id, err
+}
+
+// lockBillingPeriodStatus creates the billing period for year/month if it doesn't exist, locks its row until the
+// transaction ends and returns its status, so a status change can't race another one
+func lockBillingPeriodStatus(ctx context.Context, tx *sql.Tx, year int, month int) (string, error) {
+	stmt := fmt.Sprintf(`INSERT INTO billing_periods (%s)
+				VALUES ($1, $2, $3, NULL, NULL, $4, $5)
+				ON CONFLICT (year, month) DO NOTHING`,
+		billingPeriodCols)
+
+	_, err := tx.ExecContext(ctx, stmt, year, month, models.BillingPeriodOpen, time.Now(), time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM billing_periods WHERE year = $1 AND month = $2 FOR UPDATE`,
+		year, month).Scan(&status)
+
+	return status, err
+}
+
+// insertBillingPeriodEvent records a status change on a billing period
+func insertBillingPeriodEvent(ctx context.Context, tx *sql.Tx, periodID int, userID int, action string, reason string) error {
+	stmt := fmt.Sprintf(`INSERT INTO billing_period_events (%s) VALUES ($1, $2, $3, $4, $5, $6)`,
+		billingPeriodEventCols)
+
+	_, err := tx.ExecContext(ctx, stmt, periodID, userID, action, reason, time.Now(), time.Now())
+
+	return err
+}
+
+// FinalizeBillingPeriod marks the period as finalized, replaces its snapshot with the given charges and replaces
+// the ledger entries posted from its billing with entries, all in one transaction.
+// reason is recorded on the finalize event, e.g. when reconciliation errors were overridden.
+// Returns models.ErrBillingPeriodStatus if the period is already finalized or exported
+func (m *postgresDBRepo) FinalizeBillingPeriod(p models.BillingPeriod, entries []models.LedgerEntry, userID int, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		status, err := lockBillingPeriodStatus(ctx, tx, p.Year, p.Month)
+		if err != nil {
+			return err
+		}
+
+		if status != models.BillingPeriodOpen {
+			return models.ErrBillingPeriodStatus
+		}
+
+		periodID, err := upsertBillingPeriodStatus(ctx, tx, p.Year, p.Month, models.BillingPeriodFinalized)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE billing_periods SET finalized_at = $1, exported_at = NULL WHERE id = $2`,
+			time.Now(), periodID)
+		if err != nil {
+			return err
+		}
+
+		// a period that was re-opened is snapshotted again from scratch
+		_, err = tx.ExecContext(ctx, `DELETE FROM billing_period_charges WHERE billing_period_id = $1`, periodID)
+		if err != nil {
+			return err
+		}
+
+		stmt := fmt.Sprintf(`INSERT INTO billing_period_charges (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			billingPeriodChargeCols)
+
+		for _, c := range p.Charges {
+			_, err = tx.ExecContext(ctx, stmt,
+				periodID, c.Member.ID, c.Vehicle.ID, c.MileageLogID,
+				c.RegularTripsCost, c.LongDistanceTripsCost, c.FuelSurchargeCost, c.FuelCredit,
+				time.Now(), time.Now(),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = replaceBillingLedgerEntries(ctx, tx, p.Year, p.Month, entries)
+		if err != nil {
+			return err
+		}
+
+		return insertBillingPeriodEvent(ctx, tx, periodID, userID, models.BillingPeriodActionFinalize, reason)
+	})
+}
+
+// MarkBillingPeriodExported moves a finalized billing period to exported. Periods in any other status are unchanged
+func (m *postgresDBRepo) MarkBillingPeriodExported(year int, month int, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		var periodID int
+
+		err := tx.QueryRowContext(ctx, `UPDATE billing_periods SET status = $1, exported_at = $2, updated_at = $3
+				WHERE year = $4 AND month = $5 AND status = $6
+				RETURNING id`,
+			models.BillingPeriodExported, time.Now(), time.Now(), year, month, models.BillingPeriodFinalized,
+		).Scan(&periodID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return insertBillingPeriodEvent(ctx, tx, periodID, userID, models.BillingPeriodActionExport, "")
+	})
+}
+
+// ReopenBillingPeriod moves a billing period back to open so its mileage logs can be edited.
+// The snapshot is kept until the period is finalized again.
+// Returns models.ErrBillingPeriodStatus unless the period is finalized or exported
+func (m *postgresDBRepo) ReopenBillingPeriod(year int, month int, userID int, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		status, err := lockBillingPeriodStatus(ctx, tx, year, month)
+		if err != nil {
+			return err
+		}
+
+		if status != models.BillingPeriodFinalized && status != models.BillingPeriodExported {
+			return models.ErrBillingPeriodStatus
+		}
+
+		periodID, err := upsertBillingPeriodStatus(ctx, tx, year, month, models.BillingPeriodOpen)
+		if err != nil {
+			return err
+		}
+
+		return insertBillingPeriodEvent(ctx, tx, periodID, userID, models.BillingPeriodActionReopen, reason)
+	})
+}
@@ -0,0 +1,171 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// complianceDocumentCols lists the columns in the compliance_documents table EXCEPT "id"
+const complianceDocumentCols = `vehicle_id, document_type, reference, issued_date, expires_date,
+				storage_key, filename, content_type, size, notes, created_at, updated_at`
+
+// InsertComplianceDocument inserts a vehicle's compliance document into the database and returns its id
+func (m *postgresDBRepo) InsertComplianceDocument(d models.ComplianceDocument) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	stmt := fmt.Sprintf(`INSERT INTO compliance_documents (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`,
+		complianceDocumentCols)
+
+	var id int
+	err := m.DB.QueryRowContext(ctx, stmt,
+		d.Vehicle.ID, d.DocumentType, d.Reference, d.IssuedDate, d.ExpiresDate,
+		d.File.Key, d.File.Filename, d.File.ContentType, d.File.Size, d.Notes,
+		time.Now(), time.Now(),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// complianceDocumentSelect selects compliance documents with the name of their vehicle
+const complianceDocumentSelect = `SELECT d.id, d.vehicle_id, d.document_type, d.reference, d.issued_date, d.expires_date,
+			d.storage_key, d.filename, d.content_type, d.size, d.notes, d.created_at, d.updated_at, v.name
+		FROM compliance_documents d
+		JOIN vehicles v ON v.id = d.vehicle_id`
+
+func scanRowsToComplianceDocuments(rows *sql.Rows) ([]models.ComplianceDocument, error) {
+	var docs []models.ComplianceDocument
+
+	for rows.Next() {
+		d := models.ComplianceDocument{}
+		err := rows.Scan(&d.ID, &d.Vehicle.ID, &d.DocumentType, &d.Reference, &d.IssuedDate, &d.ExpiresDate,
+			&d.File.Key, &d.File.Filename, &d.File.ContentType, &d.File.Size, &d.Notes,
+			&d.CreatedAt, &d.UpdatedAt, &d.Vehicle.Name)
+		if err != nil {
+			return docs, err
+		}
+
+		docs = append(docs, d)
+	}
+
+	return docs, rows.Err()
+}
+
+// GetComplianceDocumentsByVehicleID returns a vehicle's compliance documents by type, latest expiry first
+func (m *postgresDBRepo) GetComplianceDocumentsByVehicleID(vehicleID int) ([]models.ComplianceDocument, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := complianceDocumentSelect + ` WHERE d.vehicle_id = $1
+		ORDER BY d.document_type, d.expires_date DESC, d.id DESC`
+
+	rows, err := m.DB.QueryContext(ctx, q, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToComplianceDocuments(rows)
+}
+
+// GetComplianceDocumentByID returns one compliance document. It returns sql.ErrNoRows if there isn't one
+func (m *postgresDBRepo) GetComplianceDocumentByID(id int) (models.ComplianceDocument, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, complianceDocumentSelect+` WHERE d.id = $1`, id)
+	if err != nil {
+		return models.ComplianceDocument{}, err
+	}
+	defer rows.Close()
+
+	docs, err := scanRowsToComplianceDocuments(rows)
+	if err != nil {
+		return models.ComplianceDocument{}, err
+	}
+	if len(docs) == 0 {
+		return models.ComplianceDocument{}, sql.ErrNoRows
+	}
+
+	return docs[0], nil
+}
+
+// GetActiveVehicleComplianceDocuments returns the compliance documents of every active vehicle that isn't in the trash
+func (m *postgresDBRepo) GetActiveVehicleComplianceDocuments() ([]models.ComplianceDocument, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := complianceDocumentSelect + ` WHERE v.is_active AND v.deleted_at IS NULL
+		ORDER BY v.name, d.document_type, d.expires_date`
+
+	rows, err := m.DB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToComplianceDocuments(rows)
+}
+
+// DeleteComplianceDocument deletes one of a vehicle's compliance documents. It returns true if the document had a
+// stored file that no attachment or other compliance document refers to, in which case the file can be deleted
+func (m *postgresDBRepo) DeleteComplianceDocument(vehicleID int, id int) (bool, error) {
+	d, err := m.GetComplianceDocumentByID(id)
+	if err != nil {
+		return false, err
+	}
+
+	var unused bool
+	err = runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM compliance_documents WHERE id = $1 AND vehicle_id = $2`, id, vehicleID)
+		if err != nil {
+			return err
+		}
+
+		if !d.HasFile() {
+			return nil
+		}
+
+		return tx.QueryRowContext(ctx, storageKeyUnusedQuery, d.File.Key).Scan(&unused)
+	})
+
+	return unused, err
+}
+
+// InsertComplianceDigest records that the compliance digest was emailed to the given addresses
+func (m *postgresDBRepo) InsertComplianceDigest(to []string, sentAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `INSERT INTO compliance_digests (recipients, sent_at, created_at, updated_at)
+				VALUES ($1, $2, $3, $4)`,
+		strings.Join(to, ", "), sentAt, time.Now(), time.Now())
+
+	return err
+}
+
+// GetLastComplianceDigestTime returns when the compliance digest was last emailed, or the zero time if it never was
+func (m *postgresDBRepo) GetLastComplianceDigestTime() (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	var sentAt sql.NullTime
+	err := m.DB.QueryRowContext(ctx, `SELECT MAX(sent_at) FROM compliance_digests`).Scan(&sentAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return sentAt.Time, nil
+}
@@ -0,0 +1,429 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// dependentLogCols are the columns scanned into a models.DependentMileageLog, bp being the log's billing period
+const dependentLogCols = `l.id, l.name, l.year, l.month, COUNT(DISTINCT t.id), COUNT(r.id),
+	COALESCE(bp.status IN ('finalized', 'exported'), false)`
+
+// memberHistoryCount counts the trip riders, fuel purchases and billing records that refer to member $1
+const memberHistoryCount = `SELECT
+	(SELECT COUNT(*) FROM riders r
+		JOIN trips t ON t.id = r.trip_id
+		JOIN mileage_logs l ON l.id = t.mileage_log_id
+		WHERE r.member_id = $1 AND t.deleted_at IS NULL AND l.deleted_at IS NULL)
+	+ (SELECT COUNT(*) FROM fuel_purchases WHERE member_id = $1)
+	+ (SELECT COUNT(*) FROM billing_period_charges WHERE member_id = $1)
+	+ (SELECT COUNT(*) FROM ledger_entries WHERE member_id = $1)
+	+ (SELECT COUNT(*) FROM qbo_invoices WHERE member_id = $1)
+	+ (SELECT COUNT(*) FROM statement_sends WHERE member_id = $1)`
+
+// vehicleHistoryCount counts the mileage logs, fuel purchases, billing records, service records, expenses and
+// compliance documents that refer to vehicle $1
+const vehicleHistoryCount = `SELECT
+	(SELECT COUNT(*) FROM mileage_logs WHERE vehicle_id = $1 AND deleted_at IS NULL)
+	+ (SELECT COUNT(*) FROM fuel_purchases WHERE vehicle_id = $1)
+	+ (SELECT COUNT(*) FROM billing_period_charges WHERE vehicle_id = $1)
+	+ (SELECT COUNT(*) FROM service_records WHERE vehicle_id = $1)
+	+ (SELECT COUNT(*) FROM vehicle_expenses WHERE vehicle_id = $1)
+	+ (SELECT COUNT(*) FROM compliance_documents WHERE vehicle_id = $1)`
+
+// scanDependentMileageLogs scans rows of dependentLogCols
+func scanDependentMileageLogs(rows *sql.Rows) ([]models.DependentMileageLog, error) {
+	defer rows.Close()
+
+	var logs []models.DependentMileageLog
+	for rows.Next() {
+		var l models.DependentMileageLog
+		err := rows.Scan(&l.ID, &l.Name, &l.Year, &l.Month, &l.Trips, &l.Riders, &l.Billed)
+		if err != nil {
+			return logs, err
+		}
+
+		logs = append(logs, l)
+	}
+
+	return logs, rows.Err()
+}
+
+// countDependents runs each count query with id and stores the results
+func countDependents(ctx context.Context, db *sql.DB, id int, counts map[string]*int) error {
+	for q, n := range counts {
+		err := db.QueryRowContext(ctx, q, id).Scan(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetMemberDependents returns the mileage logs the member rode on and the fuel purchases and billing records
+// that refer to the member
+func (m *postgresDBRepo) GetMemberDependents(id int) (models.Dependents, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	var d models.Dependents
+
+	q := fmt.Sprintf(`SELECT %s FROM riders r
+		JOIN trips t ON t.id = r.trip_id
+		JOIN mileage_logs l ON l.id = t.mileage_log_id
+		LEFT JOIN billing_periods bp ON bp.year = l.year AND bp.month = l.month
+		WHERE r.member_id = $1 AND t.deleted_at IS NULL AND l.deleted_at IS NULL
+		GROUP BY l.id, bp.status
+		ORDER BY l.year, l.month, l.name`, dependentLogCols)
+
+	rows, err := m.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return d, err
+	}
+
+	d.MileageLogs, err = scanDependentMileageLogs(rows)
+	if err != nil {
+		return d, err
+	}
+
+	err = countDependents(ctx, m.DB, id, map[string]*int{
+		`SELECT COUNT(*) FROM fuel_purchases WHERE member_id = $1`:         &d.FuelPurchases,
+		`SELECT COUNT(*) FROM billing_period_charges WHERE member_id = $1`: &d.BillingCharges,
+		`SELECT COUNT(*) FROM ledger_entries WHERE member_id = $1`:         &d.LedgerEntries,
+		`SELECT COUNT(*) FROM qbo_invoices WHERE member_id = $1`:           &d.QBOInvoices,
+		`SELECT COUNT(*) FROM statement_sends WHERE member_id = $1`:        &d.Statements,
+	})
+
+	return d, err
+}
+
+// GetVehicleDependents returns the vehicle's mileage logs, the fuel purchases and billing records that refer to
+// the vehicle, and counts the records that belong to it
+func (m *postgresDBRepo) GetVehicleDependents(id int) (models.Dependents, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	var d models.Dependents
+
+	q := fmt.Sprintf(`SELECT %s FROM mileage_logs l
+		LEFT JOIN trips t ON t.mileage_log_id = l.id AND t.deleted_at IS NULL
+		LEFT JOIN riders r ON r.trip_id = t.id
+		LEFT JOIN billing_periods bp ON bp.year = l.year AND bp.month = l.month
+		WHERE l.vehicle_id = $1 AND l.deleted_at IS NULL
+		GROUP BY l.id, bp.status
+		ORDER BY l.year, l.month, l.name`, dependentLogCols)
+
+	rows, err := m.DB.QueryContext(ctx, q, id)
+	if err != nil {
+		return d, err
+	}
+
+	d.MileageLogs, err = scanDependentMileageLogs(rows)
+	if err != nil {
+		return d, err
+	}
+
+	err = countDependents(ctx, m.DB, id, map[string]*int{
+		`SELECT COUNT(*) FROM fuel_purchases WHERE vehicle_id = $1`:         &d.FuelPurchases,
+		`SELECT COUNT(*) FROM billing_period_charges WHERE vehicle_id = $1`: &d.BillingCharges,
+		`SELECT COUNT(*) FROM service_records WHERE vehicle_id = $1`:        &d.ServiceRecords,
+		`SELECT COUNT(*) FROM maintenance_schedules WHERE vehicle_id = $1`:  &d.MaintenanceSchedules,
+		`SELECT COUNT(*) FROM vehicle_expenses WHERE vehicle_id = $1`:       &d.Expenses,
+		`SELECT COUNT(*) FROM compliance_documents WHERE vehicle_id = $1`:   &d.ComplianceDocuments,
+	})
+
+	return d, err
+}
+
+// conflictingMonths returns the YYYY-MM months the query finds, for the error of a merge that can't go ahead
+func conflictingMonths(ctx context.Context, tx *sql.Tx, q string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var months []string
+	for rows.Next() {
+		var year, month int
+		err = rows.Scan(&year, &month)
+		if err != nil {
+			return months, err
+		}
+
+		months = append(months, fmt.Sprintf("%04d-%02d", year, month))
+	}
+
+	return months, rows.Err()
+}
+
+// maxShareWeight is the largest share weight the riders.share_weight NUMERIC(4,2) column holds
+const maxShareWeight = "99.99"
+
+// conflictingTripDates runs q, which selects trip dates, and returns them formatted for an error message
+func conflictingTripDates(ctx context.Context, tx *sql.Tx, q string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var d sql.NullTime
+		err = rows.Scan(&d)
+		if err != nil {
+			return dates, err
+		}
+
+		if !d.Valid {
+			dates = append(dates, "an undated trip")
+			continue
+		}
+
+		dates = append(dates, d.Time.Format(config.DateLayout))
+	}
+
+	return dates, rows.Err()
+}
+
+// MergeMember moves everything that refers to a member over to the member with intoID and then moves the member to
+// the trash, for when the same person was entered twice. Where both rode the same trip their share weights are added
+// together. The member's name and aliases become aliases of the other member so imports still match them.
+// Members that both have a QuickBooks invoice or a sent statement for the same month can't be merged, and neither
+// can members who rode the same trip if only one of them was exempt or their share weights add up past the maximum
+func (m *postgresDBRepo) MergeMember(id int, intoID int, userID int) error {
+	if id == intoID {
+		return fmt.Errorf("%w: a member can't be merged into itself", models.ErrCannotMerge)
+	}
+
+	from, err := m.GetMemberByID(id)
+	if err != nil {
+		return err
+	}
+
+	into, err := m.GetMemberByID(intoID)
+	if err != nil {
+		return err
+	}
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		for _, c := range []struct{ table, what string }{
+			{"qbo_invoices", "a QuickBooks invoice"},
+			{"statement_sends", "a sent statement"},
+		} {
+			q := fmt.Sprintf(`SELECT a.year, a.month FROM %[1]s a
+				JOIN %[1]s b ON b.year = a.year AND b.month = a.month
+				WHERE a.member_id = $1 AND b.member_id = $2
+				ORDER BY a.year, a.month`, c.table)
+			months, err := conflictingMonths(ctx, tx, q, id, intoID)
+			if err != nil {
+				return err
+			}
+
+			if len(months) > 0 {
+				return fmt.Errorf("%w: %s and %s both have %s for %s", models.ErrCannotMerge,
+					from.Name, into.Name, c.what, strings.Join(months, ", "))
+			}
+		}
+
+		// riders on the same trip are combined into one, which can only be exempt or not
+		// and whose weight has to fit the share_weight column
+		for _, c := range []struct{ where, what string }{
+			{"f.is_exempt <> r.is_exempt", "only one of them was exempt"},
+			{fmt.Sprintf("f.share_weight + r.share_weight > %s", maxShareWeight), "their share weights add up to more than " + maxShareWeight},
+		} {
+			q := fmt.Sprintf(`SELECT DISTINCT t.trip_date FROM riders f
+				JOIN riders r ON r.trip_id = f.trip_id
+				JOIN trips t ON t.id = f.trip_id
+				WHERE f.member_id = $1 AND r.member_id = $2 AND %s
+				ORDER BY t.trip_date`, c.where)
+			dates, err := conflictingTripDates(ctx, tx, q, id, intoID)
+			if err != nil {
+				return err
+			}
+
+			if len(dates) > 0 {
+				return fmt.Errorf("%w: %s and %s rode together on %s but %s", models.ErrCannotMerge,
+					from.Name, into.Name, strings.Join(dates, ", "), c.what)
+			}
+		}
+
+		stmts := []string{
+			`UPDATE riders r SET share_weight = r.share_weight + f.share_weight
+				FROM riders f
+				WHERE f.trip_id = r.trip_id AND f.member_id = $1 AND r.member_id = $2`,
+			`DELETE FROM riders f
+				WHERE f.member_id = $1 AND EXISTS (SELECT 1 FROM riders r WHERE r.trip_id = f.trip_id AND r.member_id = $2)`,
+			`UPDATE riders SET member_id = $2 WHERE member_id = $1`,
+			`UPDATE fuel_purchases SET member_id = $2 WHERE member_id = $1`,
+			`UPDATE billing_period_charges SET member_id = $2 WHERE member_id = $1`,
+			`UPDATE ledger_entries SET member_id = $2 WHERE member_id = $1`,
+			`UPDATE qbo_invoices SET member_id = $2 WHERE member_id = $1`,
+			`UPDATE statement_sends SET member_id = $2 WHERE member_id = $1`,
+		}
+
+		for _, q := range stmts {
+			_, err := tx.ExecContext(ctx, q, id, intoID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the merged member's name and aliases become aliases of the other member, skipping names it already has
+		known := map[string]bool{strings.ToLower(into.Name): true}
+		for _, a := range into.Aliases {
+			known[strings.ToLower(a.Name)] = true
+		}
+
+		names := []string{from.Name}
+		for _, a := range from.Aliases {
+			names = append(names, a.Name)
+		}
+
+		var added []models.MemberAlias
+		for _, name := range names {
+			if known[strings.ToLower(name)] {
+				continue
+			}
+			known[strings.ToLower(name)] = true
+
+			err := insertMemberAliasesTx(tx, ctx, intoID, name)
+			if err != nil {
+				return err
+			}
+			added = append(added, models.MemberAlias{Name: name})
+		}
+
+		_, err := tx.ExecContext(ctx, `UPDATE members SET deleted_at = $1 WHERE id = $2`, time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		err = insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditMember,
+			EntityID:   id,
+			Action:     models.AuditMerge,
+			User:       models.User{ID: userID},
+		}, models.NewMemberAuditSnapshot(from), nil)
+		if err != nil {
+			return err
+		}
+
+		after := into
+		after.Aliases = append(append([]models.MemberAlias{}, into.Aliases...), added...)
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditMember,
+			EntityID:   intoID,
+			Action:     models.AuditMerge,
+			User:       models.User{ID: userID},
+		}, models.NewMemberAuditSnapshot(into), models.NewMemberAuditSnapshot(after))
+	})
+}
+
+// MergeVehicle moves a vehicle's mileage logs, fuel purchases, maintenance, expenses and documents over to the
+// vehicle with intoID and then moves the vehicle to the trash, for when the same vehicle was entered twice.
+// Trip costs are worked out from the vehicle's rate schedules, so a vehicle with mileage logs in a finalized billing
+// period can't be merged, and neither can two vehicles with mileage logs for the same month. Maintenance schedules
+// for a service the other vehicle already schedules are left behind and deleted with the vehicle
+func (m *postgresDBRepo) MergeVehicle(id int, intoID int, userID int) error {
+	if id == intoID {
+		return fmt.Errorf("%w: a vehicle can't be merged into itself", models.ErrCannotMerge)
+	}
+
+	from, err := m.GetVehicleByID(id)
+	if err != nil {
+		return err
+	}
+
+	into, err := m.GetVehicleByID(intoID)
+	if err != nil {
+		return err
+	}
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		months, err := conflictingMonths(ctx, tx, `SELECT l.year, l.month FROM mileage_logs l
+			JOIN billing_periods bp ON bp.year = l.year AND bp.month = l.month
+			WHERE l.vehicle_id = $1 AND bp.status IN ('finalized', 'exported')
+			UNION
+			SELECT bp.year, bp.month FROM billing_period_charges c
+			JOIN billing_periods bp ON bp.id = c.billing_period_id
+			WHERE c.vehicle_id = $1
+			ORDER BY 1, 2`, id)
+		if err != nil {
+			return err
+		}
+
+		if len(months) > 0 {
+			return fmt.Errorf("%w: %s has been billed for %s, merging would re-price those trips at %s's rates",
+				models.ErrCannotMerge, from.Name, strings.Join(months, ", "), into.Name)
+		}
+
+		months, err = conflictingMonths(ctx, tx, `SELECT a.year, a.month FROM mileage_logs a
+			JOIN mileage_logs b ON b.year = a.year AND b.month = a.month
+			WHERE a.vehicle_id = $1 AND b.vehicle_id = $2 AND a.deleted_at IS NULL AND b.deleted_at IS NULL
+			ORDER BY a.year, a.month`, id, intoID)
+		if err != nil {
+			return err
+		}
+
+		if len(months) > 0 {
+			return fmt.Errorf("%w: %s and %s both have mileage logs for %s", models.ErrCannotMerge,
+				from.Name, into.Name, strings.Join(months, ", "))
+		}
+
+		stmts := []string{
+			`UPDATE mileage_logs SET vehicle_id = $2 WHERE vehicle_id = $1`,
+			`UPDATE fuel_purchases SET vehicle_id = $2 WHERE vehicle_id = $1`,
+			`UPDATE service_records SET vehicle_id = $2 WHERE vehicle_id = $1`,
+			`UPDATE maintenance_schedules s SET vehicle_id = $2
+				WHERE s.vehicle_id = $1
+				AND NOT EXISTS (SELECT 1 FROM maintenance_schedules WHERE vehicle_id = $2 AND service_type = s.service_type)`,
+			`UPDATE vehicle_expenses SET vehicle_id = $2 WHERE vehicle_id = $1`,
+			`UPDATE compliance_documents SET vehicle_id = $2 WHERE vehicle_id = $1`,
+		}
+
+		for _, q := range stmts {
+			_, err := tx.ExecContext(ctx, q, id, intoID)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE vehicles SET deleted_at = $1 WHERE id = $2`, time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		err = insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditVehicle,
+			EntityID:   id,
+			Action:     models.AuditMerge,
+			User:       models.User{ID: userID},
+		}, models.NewVehicleAuditSnapshot(from), nil)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditVehicle,
+			EntityID:   intoID,
+			Action:     models.AuditMerge,
+			User:       models.User{ID: userID},
+		}, models.NewVehicleAuditSnapshot(into), models.NewVehicleAuditSnapshot(into))
+	})
+}
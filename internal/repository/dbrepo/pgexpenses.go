@@ -0,0 +1,76 @@
+package dbrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// vehicleExpenseCols lists the columns in the vehicle_expenses table EXCEPT "id"
+const vehicleExpenseCols = `vehicle_id, expense_date, category, amount, coverage_months, notes,
+				created_at, updated_at`
+
+// InsertVehicleExpense inserts a vehicle expense into the database
+func (m *postgresDBRepo) InsertVehicleExpense(v models.VehicleExpense) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	stmt := fmt.Sprintf(`INSERT INTO vehicle_expenses (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		vehicleExpenseCols)
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		v.Vehicle.ID, v.ExpenseDate, v.Category, v.Amount, v.CoverageMonths, v.Notes,
+		time.Now(), time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetVehicleExpensesByVehicleID returns a vehicle's expenses, most recent first
+func (m *postgresDBRepo) GetVehicleExpensesByVehicleID(vehicleID int) ([]models.VehicleExpense, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM vehicle_expenses
+		WHERE vehicle_id = $1
+		ORDER BY expense_date DESC, id DESC`, vehicleExpenseCols)
+
+	rows, err := m.DB.QueryContext(ctx, q, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []models.VehicleExpense
+	for rows.Next() {
+		x := models.VehicleExpense{}
+		err := rows.Scan(&x.ID, &x.Vehicle.ID, &x.ExpenseDate, &x.Category, &x.Amount, &x.CoverageMonths, &x.Notes,
+			&x.CreatedAt, &x.UpdatedAt)
+		if err != nil {
+			return expenses, err
+		}
+
+		expenses = append(expenses, x)
+	}
+
+	return expenses, rows.Err()
+}
+
+// DeleteVehicleExpense deletes one of a vehicle's expenses
+func (m *postgresDBRepo) DeleteVehicleExpense(vehicleID int, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM vehicle_expenses WHERE id = $1 AND vehicle_id = $2`, id, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
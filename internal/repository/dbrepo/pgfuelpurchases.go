@@ -0,0 +1,144 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// fuelPurchaseCols lists the columns in the fuel_purchases table EXCEPT "id"
+const fuelPurchaseCols = `vehicle_id, member_id, purchase_date, quantity, unit, amount, notes,
+				created_at, updated_at`
+
+// InsertFuelPurchase inserts a fuel purchase into the database
+func (m *postgresDBRepo) InsertFuelPurchase(v models.FuelPurchase) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	stmt := fmt.Sprintf(`INSERT INTO fuel_purchases (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		fuelPurchaseCols)
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		v.Vehicle.ID, v.Member.ID, v.PurchaseDate, v.Quantity, v.Unit, v.Amount, v.Notes,
+		time.Now(), time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// scanRowsToFuelPurchases takes a pointer to *sql.Rows and scans those values into a slice of FuelPurchases
+// also gets the member who paid for each purchase
+func (m *postgresDBRepo) scanRowsToFuelPurchases(rows *sql.Rows) ([]models.FuelPurchase, error) {
+	var purchases []models.FuelPurchase
+
+	for rows.Next() {
+		f := models.FuelPurchase{}
+		err := rows.Scan(&f.ID, &f.Vehicle.ID, &f.Member.ID, &f.PurchaseDate, &f.Quantity, &f.Unit, &f.Amount, &f.Notes,
+			&f.CreatedAt, &f.UpdatedAt)
+		if err != nil {
+			return purchases, err
+		}
+
+		purchases = append(purchases, f)
+	}
+	err := rows.Err()
+	if err != nil {
+		return purchases, err
+	}
+
+	// populate members once the rows are read
+	for i, f := range purchases {
+		purchases[i].Member, err = m.GetMemberByID(f.Member.ID)
+		if err != nil {
+			return purchases, err
+		}
+	}
+
+	return purchases, nil
+}
+
+// GetFuelPurchasesByVehicleYearMonth returns all fuel purchases for a vehicle in the given year & month
+func (m *postgresDBRepo) GetFuelPurchasesByVehicleYearMonth(vehicleID int, year int, month int) ([]models.FuelPurchase, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	q := fmt.Sprintf(`SELECT id, %s FROM fuel_purchases
+		WHERE vehicle_id = $1 AND purchase_date >= $2 AND purchase_date < $3
+		ORDER BY purchase_date, id`, fuelPurchaseCols)
+
+	// execute our DB query
+	rows, err := m.DB.QueryContext(ctx, q, vehicleID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return m.scanRowsToFuelPurchases(rows)
+}
+
+// GetFuelPurchasesByVehicleID returns all fuel purchases for a vehicle, oldest first
+func (m *postgresDBRepo) GetFuelPurchasesByVehicleID(vehicleID int) ([]models.FuelPurchase, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM fuel_purchases
+		WHERE vehicle_id = $1
+		ORDER BY purchase_date, id`, fuelPurchaseCols)
+
+	// execute our DB query
+	rows, err := m.DB.QueryContext(ctx, q, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return m.scanRowsToFuelPurchases(rows)
+}
+
+// GetFuelPurchaseByID returns one fuel purchase from a given id
+func (m *postgresDBRepo) GetFuelPurchaseByID(id int) (models.FuelPurchase, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM fuel_purchases WHERE id = $1`, fuelPurchaseCols)
+
+	// execute our DB query
+	row := m.DB.QueryRowContext(ctx, q, id)
+
+	f := models.FuelPurchase{}
+	err := row.Scan(&f.ID, &f.Vehicle.ID, &f.Member.ID, &f.PurchaseDate, &f.Quantity, &f.Unit, &f.Amount, &f.Notes,
+		&f.CreatedAt, &f.UpdatedAt)
+	if err != nil {
+		return f, err
+	}
+
+	f.Member, err = m.GetMemberByID(f.Member.ID)
+	if err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
+
+// DeleteFuelPurchase deletes a fuel purchase by id
+func (m *postgresDBRepo) DeleteFuelPurchase(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM fuel_purchases WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// ledgerEntryCols lists the columns in the ledger_entries table EXCEPT "id"
+const ledgerEntryCols = `member_id, entry_date, entry_type, amount, description, period_year, period_month,
+				created_at, updated_at`
+
+// insertLedgerEntry inserts a ledger entry using the given transaction
+func insertLedgerEntry(ctx context.Context, tx *sql.Tx, v models.LedgerEntry) error {
+	stmt := fmt.Sprintf(`INSERT INTO ledger_entries (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		ledgerEntryCols)
+
+	_, err := tx.ExecContext(ctx, stmt,
+		v.Member.ID, v.EntryDate, v.EntryType, v.Amount, v.Description, v.PeriodYear, v.PeriodMonth,
+		time.Now(), time.Now(),
+	)
+
+	return err
+}
+
+// InsertLedgerEntry inserts a ledger entry into the database
+func (m *postgresDBRepo) InsertLedgerEntry(v models.LedgerEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		return insertLedgerEntry(ctx, tx, v)
+	})
+}
+
+// replaceBillingLedgerEntries replaces the ledger entries generated from a year & month's billing with the given
+// entries using the given transaction
+func replaceBillingLedgerEntries(ctx context.Context, tx *sql.Tx, year int, month int, entries []models.LedgerEntry) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM ledger_entries WHERE period_year = $1 AND period_month = $2`,
+		year, month)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		err = insertLedgerEntry(ctx, tx, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanRowsToLedgerEntries takes a pointer to *sql.Rows and scans those values into a slice of LedgerEntries.
+// Only the member id is set on each entry
+func scanRowsToLedgerEntries(rows *sql.Rows) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+
+	for rows.Next() {
+		e := models.LedgerEntry{}
+		err := rows.Scan(&e.ID, &e.Member.ID, &e.EntryDate, &e.EntryType, &e.Amount, &e.Description,
+			&e.PeriodYear, &e.PeriodMonth, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetLedgerEntriesByMemberID returns all ledger entries for a member, oldest first
+func (m *postgresDBRepo) GetLedgerEntriesByMemberID(memberID int) ([]models.LedgerEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM ledger_entries WHERE member_id = $1 ORDER BY entry_date, id`, ledgerEntryCols)
+
+	rows, err := m.DB.QueryContext(ctx, q, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToLedgerEntries(rows)
+}
+
+// AllLedgerEntries returns every ledger entry ordered by member, oldest first
+func (m *postgresDBRepo) AllLedgerEntries() ([]models.LedgerEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM ledger_entries ORDER BY member_id, entry_date, id`, ledgerEntryCols)
+
+	rows, err := m.DB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToLedgerEntries(rows)
+}
+
+// GetLedgerEntryByID returns one ledger entry from a given id
+func (m *postgresDBRepo) GetLedgerEntryByID(id int) (models.LedgerEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM ledger_entries WHERE id = $1`, ledgerEntryCols)
+
+	e := models.LedgerEntry{}
+	err := m.DB.QueryRowContext(ctx, q, id).Scan(&e.ID, &e.Member.ID, &e.EntryDate, &e.EntryType, &e.Amount,
+		&e.Description, &e.PeriodYear, &e.PeriodMonth, &e.CreatedAt, &e.UpdatedAt)
+
+	return e, err
+}
+
+// DeleteLedgerEntry deletes a member's ledger entry entered by hand by id. Entries generated from a billing
+// are never deleted, sql.ErrNoRows is returned if there is no such entry entered by hand
+func (m *postgresDBRepo) DeleteLedgerEntry(memberID int, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	res, err := m.DB.ExecContext(ctx, `DELETE FROM ledger_entries WHERE id = $1 AND member_id = $2 AND period_year = 0`,
+		id, memberID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
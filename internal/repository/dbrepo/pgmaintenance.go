@@ -0,0 +1,170 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// serviceRecordCols lists the columns in the service_records table EXCEPT "id"
+const serviceRecordCols = `vehicle_id, service_date, odometer, service_type, cost, vendor, notes,
+				created_at, updated_at`
+
+// maintenanceScheduleCols lists the columns in the maintenance_schedules table EXCEPT "id"
+const maintenanceScheduleCols = `vehicle_id, service_type, interval_miles, interval_months, created_at, updated_at`
+
+// InsertServiceRecord inserts a service record into the database
+func (m *postgresDBRepo) InsertServiceRecord(v models.ServiceRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	stmt := fmt.Sprintf(`INSERT INTO service_records (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		serviceRecordCols)
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		v.Vehicle.ID, v.ServiceDate, v.Odometer, v.ServiceType, v.Cost, v.Vendor, v.Notes,
+		time.Now(), time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetServiceRecordsByVehicleID returns a vehicle's service records, most recent first
+func (m *postgresDBRepo) GetServiceRecordsByVehicleID(vehicleID int) ([]models.ServiceRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM service_records
+		WHERE vehicle_id = $1
+		ORDER BY service_date DESC, odometer DESC, id DESC`, serviceRecordCols)
+
+	rows, err := m.DB.QueryContext(ctx, q, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []models.ServiceRecord
+	for rows.Next() {
+		r := models.ServiceRecord{}
+		err := rows.Scan(&r.ID, &r.Vehicle.ID, &r.ServiceDate, &r.Odometer, &r.ServiceType, &r.Cost, &r.Vendor, &r.Notes,
+			&r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return records, err
+		}
+
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
+
+// DeleteServiceRecord deletes one of a vehicle's service records
+func (m *postgresDBRepo) DeleteServiceRecord(vehicleID int, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM service_records WHERE id = $1 AND vehicle_id = $2`, id, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpsertMaintenanceSchedule adds a maintenance schedule for a vehicle, or changes the intervals if the vehicle
+// already has a schedule for the service type
+func (m *postgresDBRepo) UpsertMaintenanceSchedule(v models.MaintenanceSchedule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	stmt := fmt.Sprintf(`INSERT INTO maintenance_schedules (%s)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (vehicle_id, service_type) DO UPDATE
+				SET interval_miles = EXCLUDED.interval_miles, interval_months = EXCLUDED.interval_months,
+					updated_at = EXCLUDED.updated_at`,
+		maintenanceScheduleCols)
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		v.Vehicle.ID, v.ServiceType, v.IntervalMiles, v.IntervalMonths,
+		time.Now(), time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMaintenanceSchedulesByVehicleID returns a vehicle's maintenance schedules by service type
+func (m *postgresDBRepo) GetMaintenanceSchedulesByVehicleID(vehicleID int) ([]models.MaintenanceSchedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM maintenance_schedules
+		WHERE vehicle_id = $1
+		ORDER BY service_type`, maintenanceScheduleCols)
+
+	rows, err := m.DB.QueryContext(ctx, q, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []models.MaintenanceSchedule
+	for rows.Next() {
+		s := models.MaintenanceSchedule{}
+		err := rows.Scan(&s.ID, &s.Vehicle.ID, &s.ServiceType, &s.IntervalMiles, &s.IntervalMonths,
+			&s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return schedules, err
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+// DeleteMaintenanceSchedule deletes one of a vehicle's maintenance schedules
+func (m *postgresDBRepo) DeleteMaintenanceSchedule(vehicleID int, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM maintenance_schedules WHERE id = $1 AND vehicle_id = $2`, id, vehicleID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetLatestOdometer returns the highest odometer reading in a vehicle's mileage logs, from either a log's
+// end odometer or a trip's end mileage. It returns 0 if the vehicle has no logs
+func (m *postgresDBRepo) GetLatestOdometer(vehicleID int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT GREATEST(COALESCE(MAX(l.end_odometer), 0), COALESCE(MAX(t.end_mileage), 0))
+		FROM mileage_logs l
+		LEFT JOIN trips t ON t.mileage_log_id = l.id AND t.deleted_at IS NULL
+		WHERE l.vehicle_id = $1 AND l.deleted_at IS NULL`
+
+	var odometer int
+	err := m.DB.QueryRowContext(ctx, q, vehicleID).Scan(&odometer)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return odometer, nil
+}
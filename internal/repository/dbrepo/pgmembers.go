@@ -126,12 +126,12 @@ func scanRowsToMemberAliases(rows *sql.Rows) ([]models.MemberAlias, error) {
 	return aliases, nil
 }
 
-// AllMembers returns a slice of all members in database. Does not populate member aliases
+// AllMembers returns a slice of all members in database that aren't in the trash. Does not populate member aliases
 func (m *postgresDBRepo) AllMembers() ([]models.Member, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM members`, memberCols)
+	q := fmt.Sprintf(`SELECT id, %s FROM members WHERE deleted_at IS NULL`, memberCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q)
@@ -149,7 +149,7 @@ func (m *postgresDBRepo) GetMemberByActive(active bool) ([]models.Member, error)
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM members WHERE is_active=$1 ORDER BY name`, memberCols)
+	q := fmt.Sprintf(`SELECT id, %s FROM members WHERE is_active=$1 AND deleted_at IS NULL ORDER BY name`, memberCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q, active)
@@ -217,7 +217,12 @@ func (m *postgresDBRepo) getAliasesByMemberID(id int) ([]models.MemberAlias, err
 }
 
 // UpdateMember updates a member in the database
-func (m *postgresDBRepo) UpdateMember(v models.Member) error {
+func (m *postgresDBRepo) UpdateMember(v models.Member, userID int) error {
+	before, err := m.GetMemberByID(v.ID)
+	if err != nil {
+		return err
+	}
+
 	return runInTx(m.DB, func(tx *sql.Tx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 		defer cancel()
@@ -230,7 +235,7 @@ func (m *postgresDBRepo) UpdateMember(v models.Member) error {
 			qbo_name = $5
 		WHERE id =  $6 `
 
-		_, err := tx.ExecContext(ctx, q,
+		_, err = tx.ExecContext(ctx, q,
 			v.Name,
 			v.Email,
 			v.Active,
@@ -260,55 +265,89 @@ func (m *postgresDBRepo) UpdateMember(v models.Member) error {
 
 		}
 
-		return nil
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditMember,
+			EntityID:   v.ID,
+			Action:     models.AuditUpdate,
+			User:       models.User{ID: userID},
+		}, models.NewMemberAuditSnapshot(before), models.NewMemberAuditSnapshot(v))
 	})
 }
 
 // UpdateMemberActiveByID updates the active status of a member by id
-func (m *postgresDBRepo) UpdateMemberActiveByID(id int, active bool) error {
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
+func (m *postgresDBRepo) UpdateMemberActiveByID(id int, active bool, userID int) error {
+	before, err := m.GetMemberByID(id)
+	if err != nil {
+		return err
+	}
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
 
-	q := `UPDATE members SET
-			is_active = $1,
-			updated_at = $2
-		WHERE id =  $3
-		`
+		q := `UPDATE members SET
+				is_active = $1,
+				updated_at = $2
+			WHERE id =  $3
+			`
 
-	_, err := m.DB.ExecContext(ctx, q,
-		active,
-		time.Now(),
-		id,
-	)
+		_, err := tx.ExecContext(ctx, q,
+			active,
+			time.Now(),
+			id,
+		)
 
+		if err != nil {
+			return err
+		}
+
+		after := before
+		after.Active = active
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditMember,
+			EntityID:   id,
+			Action:     models.AuditUpdate,
+			User:       models.User{ID: userID},
+		}, models.NewMemberAuditSnapshot(before), models.NewMemberAuditSnapshot(after))
+	})
+}
+
+// DeleteMember moves one member to the trash by id, keeping their aliases so the member can be restored.
+// Members that ride on trips or have fuel purchases or billing records can't be deleted and models.ErrHasHistory
+// is returned, since purging them from the trash would cascade to that history
+func (m *postgresDBRepo) DeleteMember(id int, userID int) error {
+	before, err := m.GetMemberByID(id)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// DeleteMember deletes one member by id. Also deletes all member_aliases with that member id
-// We should almost never actually delete a member
-// because it will be referenced by a lot of mileage logs.
-func (m *postgresDBRepo) DeleteMember(id int) error {
 	return runInTx(m.DB, func(tx *sql.Tx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 		defer cancel()
 
-		q := `DELETE from member_aliases WHERE member_id = $1`
-		_, err := tx.ExecContext(ctx, q, id)
+		var history int
+		err := tx.QueryRowContext(ctx, memberHistoryCount, id).Scan(&history)
 		if err != nil {
 			return err
 		}
 
-		q = `DELETE from members WHERE id=$1`
+		if history > 0 {
+			return fmt.Errorf("%w: %s has trips, fuel purchases or billing records", models.ErrHasHistory, before.Name)
+		}
+
+		q := `UPDATE members SET deleted_at = $1 WHERE id = $2`
 
-		_, err = tx.ExecContext(ctx, q, id)
+		_, err = tx.ExecContext(ctx, q, time.Now(), id)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditMember,
+			EntityID:   id,
+			Action:     models.AuditDelete,
+			User:       models.User{ID: userID},
+		}, models.NewMemberAuditSnapshot(before), nil)
 	})
 }
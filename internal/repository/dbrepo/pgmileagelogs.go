@@ -12,11 +12,19 @@ import (
 // memberCols lists the columns in the members table EXCEPT "id"
 const mileageLogCols = `vehicle_id, name, year, month, start_odometer, end_odometer, created_at, updated_at`
 const tripCols = `mileage_log_id, trip_date, start_mileage, end_mileage, long_distance_days, billing_rate, destination,
-		purpose, created_at, updated_at`
-const riderCols = `trip_id, member_id, created_at, updated_at`
+		purpose, created_at, updated_at, hours, days`
+const riderCols = `trip_id, member_id, created_at, updated_at, share_weight, is_exempt`
+
+// mileageLogStatusCols selects a mileage log's review status along with the names of the users who
+// submitted and approved it. They are kept out of mileageLogCols since new logs always start as drafts
+const mileageLogStatusCols = `status,
+		COALESCE(submitted_by, 0), COALESCE((SELECT first_name FROM users WHERE id = submitted_by), ''),
+		COALESCE((SELECT last_name FROM users WHERE id = submitted_by), ''), submitted_at,
+		COALESCE(approved_by, 0), COALESCE((SELECT first_name FROM users WHERE id = approved_by), ''),
+		COALESCE((SELECT last_name FROM users WHERE id = approved_by), ''), approved_at`
 
 // InsertMileageLog inserts a MileageLog into the database.
-func (m *postgresDBRepo) InsertMileageLog(v models.MileageLog) (int, error) {
+func (m *postgresDBRepo) InsertMileageLog(v models.MileageLog, userID int) (int, error) {
 	return runInTxReturnID(m.DB, func(tx *sql.Tx) (int, error) {
 		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 		defer cancel()
@@ -36,45 +44,96 @@ func (m *postgresDBRepo) InsertMileageLog(v models.MileageLog) (int, error) {
 			return 0, err
 		}
 
+		v.ID = lastInsertId
+		v.Status = models.MileageLogDraft
+		err = insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditMileageLog,
+			EntityID:     v.ID,
+			MileageLogID: v.ID,
+			Action:       models.AuditInsert,
+			User:         models.User{ID: userID},
+		}, nil, models.NewMileageLogAuditSnapshot(v))
+		if err != nil {
+			return 0, err
+		}
+
 		return lastInsertId, nil
 	})
 }
 
-func (m *postgresDBRepo) InsertTrip(v models.Trip) (int, error) {
+func (m *postgresDBRepo) InsertTrip(v models.Trip, userID int) (int, error) {
 	return runInTxReturnID(m.DB, func(tx *sql.Tx) (int, error) {
 		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 		defer cancel()
 
-		var lastInsertId int
+		return insertTripTx(tx, ctx, v, userID)
+	})
+}
 
-		// insert into trips table & return trip-id
-		stmt := fmt.Sprintf(`INSERT INTO trips (%s)
-					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-					RETURNING id`,
-			tripCols)
+// InsertTrips inserts several trips and their riders in one transaction, so either all or none are inserted
+func (m *postgresDBRepo) InsertTrips(trips []models.Trip, userID int) error {
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
 
-		err := tx.QueryRowContext(ctx, stmt,
-			v.MileageLog.ID, v.TripDate, v.StartMileage, v.EndMileage,
-			v.LongDistanceDays, v.BillingRate, v.Destination, v.Purpose,
-			time.Now(), time.Now(),
-		).Scan(&lastInsertId)
+		for _, v := range trips {
+			_, err := insertTripTx(tx, ctx, v, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// insertTripTx is a helper function that takes a transaction and uses it to insert a trip & its riders
+func insertTripTx(tx *sql.Tx, ctx context.Context, v models.Trip, userID int) (int, error) {
+	var lastInsertId int
+
+	// insert into trips table & return trip-id
+	stmt := fmt.Sprintf(`INSERT INTO trips (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+				RETURNING id`,
+		tripCols)
+
+	err := tx.QueryRowContext(ctx, stmt,
+		v.MileageLog.ID, v.TripDate, v.StartMileage, v.EndMileage,
+		v.LongDistanceDays, v.BillingRate, v.Destination, v.Purpose,
+		time.Now(), time.Now(), v.Hours, v.Days,
+	).Scan(&lastInsertId)
+	if err != nil {
+		return 0, err
+	}
+
+	// insert into riders table
+	for _, rider := range v.Riders {
+		stmt := fmt.Sprintf(`INSERT INTO riders(%s)
+						VALUES ($1, $2, $3, $4, $5, $6)`, riderCols)
+
+		_, err := tx.ExecContext(ctx, stmt, lastInsertId, rider.Member.ID, time.Now(), time.Now(), rider.Weight, rider.Exempt)
 		if err != nil {
 			return 0, err
 		}
+	}
 
-		// insert into riders table
-		for _, member := range v.Riders {
-			stmt := fmt.Sprintf(`INSERT INTO riders(%s)
-							VALUES ($1, $2, $3, $4)`, riderCols)
+	after, err := getTripAuditSnapshotTx(tx, ctx, lastInsertId)
+	if err != nil {
+		return 0, err
+	}
 
-			_, err := tx.ExecContext(ctx, stmt, lastInsertId, member.ID, time.Now(), time.Now())
-			if err != nil {
-				return 0, err
-			}
-		}
+	err = insertAuditEntryTx(tx, ctx, models.AuditEntry{
+		EntityType:   models.AuditTrip,
+		EntityID:     lastInsertId,
+		MileageLogID: v.MileageLog.ID,
+		Action:       models.AuditInsert,
+		User:         models.User{ID: userID},
+	}, nil, after)
+	if err != nil {
+		return 0, err
+	}
 
-		return lastInsertId, nil
-	})
+	return lastInsertId, nil
 }
 
 // scanRowsToMileageLogs takes a pointer to *sql.Rows and scans those values into a slice of MileageLogs
@@ -85,7 +144,9 @@ func (m *postgresDBRepo) scanRowsToMileageLogs(rows *sql.Rows) ([]models.Mileage
 		t := models.MileageLog{}
 		err := rows.Scan(&t.ID, &t.Vehicle.ID, &t.Name, &t.Year, &t.Month,
 			&t.StartOdometer, &t.EndOdometer,
-			&t.CreatedAt, &t.UpdatedAt)
+			&t.CreatedAt, &t.UpdatedAt,
+			&t.Status, &t.SubmittedBy.ID, &t.SubmittedBy.FirstName, &t.SubmittedBy.LastName, &t.SubmittedAt,
+			&t.ApprovedBy.ID, &t.ApprovedBy.FirstName, &t.ApprovedBy.LastName, &t.ApprovedAt)
 		if err != nil {
 			return logs, err
 		}
@@ -107,6 +168,12 @@ func (m *postgresDBRepo) scanRowsToMileageLogs(rows *sql.Rows) ([]models.Mileage
 			return logs, err
 		}
 
+		// populate fuel purchases made during the log's month
+		t.FuelPurchases, err = m.GetFuelPurchasesByVehicleYearMonth(t.Vehicle.ID, t.Year, t.Month)
+		if err != nil {
+			return logs, err
+		}
+
 		logs = append(logs, t)
 	}
 	err := rows.Err()
@@ -126,7 +193,7 @@ func (m *postgresDBRepo) scanRowsToTrips(rows *sql.Rows, mileageLogID int) ([]mo
 		t := models.Trip{}
 		err := rows.Scan(&t.ID, &t.MileageLog.ID, &t.TripDate, &t.StartMileage,
 			&t.EndMileage, &t.LongDistanceDays, &t.BillingRate, &t.Destination, &t.Purpose,
-			&t.CreatedAt, &t.UpdatedAt)
+			&t.CreatedAt, &t.UpdatedAt, &t.Hours, &t.Days)
 		if err != nil {
 			return trips, err
 		}
@@ -181,12 +248,12 @@ func (m *postgresDBRepo) getMileageLogVehicle(mileageLogID int) (models.Vehicle,
 
 }
 
-// AllMileageLogs returns a slice of all mileage logs in database. Does not populate trips
+// AllMileageLogs returns a slice of all mileage logs in database that aren't in the trash. Does not populate trips
 func (m *postgresDBRepo) AllMileageLogs() ([]models.MileageLog, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM mileage_logs`, mileageLogCols)
+	q := fmt.Sprintf(`SELECT id, %s, %s FROM mileage_logs WHERE deleted_at IS NULL`, mileageLogCols, mileageLogStatusCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q)
@@ -204,7 +271,7 @@ func (m *postgresDBRepo) GetMileageLogsByVehicleID(vehicle_id int) ([]models.Mil
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM mileage_logs WHERE vehicle_id=$1`, mileageLogCols)
+	q := fmt.Sprintf(`SELECT id, %s, %s FROM mileage_logs WHERE vehicle_id=$1 AND deleted_at IS NULL`, mileageLogCols, mileageLogStatusCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q, vehicle_id)
@@ -217,12 +284,62 @@ func (m *postgresDBRepo) GetMileageLogsByVehicleID(vehicle_id int) ([]models.Mil
 	return m.scanRowsToMileageLogs(rows)
 }
 
+// GetPreviousOdometer returns the last odometer reading for a vehicle before a year & month. It uses the most recent
+// earlier mileage log, taking the greater of its end odometer and its last trip's end mileage.
+// Returns 0 if the vehicle has no earlier mileage logs
+func (m *postgresDBRepo) GetPreviousOdometer(vehicleID int, year int, month int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT GREATEST(l.end_odometer, COALESCE(MAX(t.end_mileage), 0))
+		FROM mileage_logs l
+		LEFT JOIN trips t ON t.mileage_log_id = l.id AND t.deleted_at IS NULL
+		WHERE l.vehicle_id = $1 AND (l.year < $2 OR (l.year = $2 AND l.month < $3)) AND l.deleted_at IS NULL
+		GROUP BY l.id, l.year, l.month, l.end_odometer
+		ORDER BY l.year DESC, l.month DESC, l.id DESC
+		LIMIT 1`
+
+	var odometer int
+	err := m.DB.QueryRowContext(ctx, q, vehicleID, year, month).Scan(&odometer)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return odometer, nil
+}
+
+// GetPreviousMileageLog returns a vehicle's most recent mileage log before a year & month, with its trips.
+// The returned log has an ID of 0 if there isn't one
+func (m *postgresDBRepo) GetPreviousMileageLog(vehicleID int, year int, month int) (models.MileageLog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT id FROM mileage_logs
+		WHERE vehicle_id = $1 AND (year < $2 OR (year = $2 AND month < $3)) AND deleted_at IS NULL
+		ORDER BY year DESC, month DESC, id DESC
+		LIMIT 1`
+
+	var id int
+	err := m.DB.QueryRowContext(ctx, q, vehicleID, year, month).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.MileageLog{}, nil
+	}
+	if err != nil {
+		return models.MileageLog{}, err
+	}
+
+	return m.GetMileageLogByID(id)
+}
+
 // GetMileageLogsByYearMonth returns a slice of all Mileage Logs for a given year & month
 func (m *postgresDBRepo) GetMileageLogsByYearMonth(year int, month int) ([]models.MileageLog, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM mileage_logs WHERE year=$1 AND month=$2`, mileageLogCols)
+	q := fmt.Sprintf(`SELECT id, %s, %s FROM mileage_logs WHERE year=$1 AND month=$2 AND deleted_at IS NULL`, mileageLogCols, mileageLogStatusCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q, year, month)
@@ -235,6 +352,25 @@ func (m *postgresDBRepo) GetMileageLogsByYearMonth(year int, month int) ([]model
 	return m.scanRowsToMileageLogs(rows)
 }
 
+// GetMileageLogsSince returns the mileage logs of every vehicle from a year & month onwards
+func (m *postgresDBRepo) GetMileageLogsSince(year int, month int) ([]models.MileageLog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s, %s FROM mileage_logs
+		WHERE (year > $1 OR (year = $1 AND month >= $2)) AND deleted_at IS NULL
+		ORDER BY year, month`, mileageLogCols, mileageLogStatusCols)
+
+	// execute our DB query
+	rows, err := m.DB.QueryContext(ctx, q, year, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return m.scanRowsToMileageLogs(rows)
+}
+
 // GetMileageLogByID returns one mileage_log from a given id, populates trips
 func (m *postgresDBRepo) GetMileageLogByID(id int) (models.MileageLog, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
@@ -242,8 +378,8 @@ func (m *postgresDBRepo) GetMileageLogByID(id int) (models.MileageLog, error) {
 
 	var v models.MileageLog
 
-	q := fmt.Sprintf(`SELECT id, %s FROM mileage_logs
-		WHERE id = $1`, mileageLogCols)
+	q := fmt.Sprintf(`SELECT id, %s, %s FROM mileage_logs
+		WHERE id = $1`, mileageLogCols, mileageLogStatusCols)
 
 	// execute our DB query
 	row := m.DB.QueryRowContext(ctx, q, id)
@@ -251,7 +387,9 @@ func (m *postgresDBRepo) GetMileageLogByID(id int) (models.MileageLog, error) {
 	// scan single db row into mileage log model
 	err := row.Scan(&v.ID, &v.Vehicle.ID, &v.Name, &v.Year,
 		&v.Month, &v.StartOdometer, &v.EndOdometer,
-		&v.CreatedAt, &v.UpdatedAt)
+		&v.CreatedAt, &v.UpdatedAt,
+		&v.Status, &v.SubmittedBy.ID, &v.SubmittedBy.FirstName, &v.SubmittedBy.LastName, &v.SubmittedAt,
+		&v.ApprovedBy.ID, &v.ApprovedBy.FirstName, &v.ApprovedBy.LastName, &v.ApprovedAt)
 	if err != nil {
 		return v, err
 	}
@@ -272,16 +410,22 @@ func (m *postgresDBRepo) GetMileageLogByID(id int) (models.MileageLog, error) {
 		return v, err
 	}
 
+	// get fuel purchases made during the log's month
+	v.FuelPurchases, err = m.GetFuelPurchasesByVehicleYearMonth(v.Vehicle.ID, v.Year, v.Month)
+	if err != nil {
+		return v, err
+	}
+
 	return v, nil
 }
 
-func (m *postgresDBRepo) getRidersByTripID(id int) ([]models.Member, error) {
+func (m *postgresDBRepo) getRidersByTripID(id int) ([]models.Rider, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	var riders []models.Member
+	var riders []models.Rider
 
-	q := `SELECT member_id FROM riders WHERE trip_id = $1`
+	q := fmt.Sprintf(`SELECT id, %s FROM riders WHERE trip_id = $1 ORDER BY id`, riderCols)
 	rows, err := m.DB.QueryContext(ctx, q, id)
 	if err != nil {
 		return riders, err
@@ -289,20 +433,21 @@ func (m *postgresDBRepo) getRidersByTripID(id int) ([]models.Member, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var member_id int
+		rider := models.Rider{}
 
-		err := rows.Scan(&member_id)
+		err := rows.Scan(&rider.ID, &rider.Trip.ID, &rider.Member.ID, &rider.CreatedAt, &rider.UpdatedAt,
+			&rider.Weight, &rider.Exempt)
 		if err != nil {
 			return riders, err
 		}
 
 		// get member by id
-		member, err := m.GetMemberByID(member_id)
+		rider.Member, err = m.GetMemberByID(rider.Member.ID)
 		if err != nil {
 			return riders, err
 		}
 
-		riders = append(riders, member)
+		riders = append(riders, rider)
 	}
 	err = rows.Err()
 	if err != nil {
@@ -313,7 +458,12 @@ func (m *postgresDBRepo) getRidersByTripID(id int) ([]models.Member, error) {
 }
 
 // UpdateMileageLog updates a mielage log in the database
-func (m *postgresDBRepo) UpdateMileageLog(v models.MileageLog) error {
+func (m *postgresDBRepo) UpdateMileageLog(v models.MileageLog, userID int) error {
+	before, err := m.GetMileageLogByID(v.ID)
+	if err != nil {
+		return err
+	}
+
 	return runInTx(m.DB, func(tx *sql.Tx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 		defer cancel()
@@ -342,37 +492,103 @@ func (m *postgresDBRepo) UpdateMileageLog(v models.MileageLog) error {
 			return err
 		}
 
-		return nil
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditMileageLog,
+			EntityID:     v.ID,
+			MileageLogID: v.ID,
+			Action:       models.AuditUpdate,
+			User:         models.User{ID: userID},
+		}, models.NewMileageLogAuditSnapshot(before), models.NewMileageLogAuditSnapshot(v))
 	})
 }
 
-// DeleteMileageLog deletes one mielage log by id. Also deletes all trips with that mileage log id
-// TODO: allow deleting milage logs
-func (m *postgresDBRepo) DeleteMileageLog(id int) error {
+// UpdateMileageLogStatus moves a mileage log to a review status, recording who made the change and when.
+// Sending a log back to draft clears who submitted and approved it
+func (m *postgresDBRepo) UpdateMileageLogStatus(id int, status string, userID int) error {
+	before, err := m.GetMileageLogByID(id)
+	if err != nil {
+		return err
+	}
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		var err error
+		switch status {
+		case models.MileageLogSubmitted:
+			q := `UPDATE mileage_logs SET status = $1, submitted_by = NULLIF($2, 0), submitted_at = $3, updated_at = $3
+				WHERE id = $4`
+			_, err = tx.ExecContext(ctx, q, status, userID, time.Now(), id)
+		case models.MileageLogApproved:
+			q := `UPDATE mileage_logs SET status = $1, approved_by = NULLIF($2, 0), approved_at = $3, updated_at = $3
+				WHERE id = $4`
+			_, err = tx.ExecContext(ctx, q, status, userID, time.Now(), id)
+		default:
+			q := `UPDATE mileage_logs SET status = $1, submitted_by = NULL, submitted_at = NULL,
+					approved_by = NULL, approved_at = NULL, updated_at = $2
+				WHERE id = $3`
+			_, err = tx.ExecContext(ctx, q, status, time.Now(), id)
+		}
+		if err != nil {
+			return err
+		}
+
+		after := before
+		after.Status = status
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditMileageLog,
+			EntityID:     id,
+			MileageLogID: id,
+			Action:       models.AuditUpdate,
+			User:         models.User{ID: userID},
+		}, models.NewMileageLogAuditSnapshot(before), models.NewMileageLogAuditSnapshot(after))
+	})
+}
+
+// DeleteMileageLog moves one mileage log and its trips to the trash. The trips are given the same deleted_at
+// time as the log so restoring the log brings back exactly the trips deleted with it
+func (m *postgresDBRepo) DeleteMileageLog(id int, userID int) error {
+	log, err := m.GetMileageLogByID(id)
+	if err != nil {
+		return err
+	}
+
+	// the deleted log's trips are kept in its audit history
+	before := models.NewMileageLogAuditSnapshot(log)
+	for _, t := range log.Trips {
+		before.Trips = append(before.Trips, models.NewTripAuditSnapshot(t))
+	}
+
 	return runInTx(m.DB, func(tx *sql.Tx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 		defer cancel()
 
-		// delete riders first
-		q := `DELETE FROM riders r
-				USING trips t 
-				where r.trip_id = t.id AND t.mileage_log_id = $1`
-		_, err := tx.ExecContext(ctx, q, id)
+		err := insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditMileageLog,
+			EntityID:     id,
+			MileageLogID: id,
+			Action:       models.AuditDelete,
+			User:         models.User{ID: userID},
+		}, before, nil)
 		if err != nil {
 			return err
 		}
 
-		// delete trips
-		q = `DELETE from trips WHERE mileage_log_id = $1`
-		_, err = tx.ExecContext(ctx, q, id)
+		deletedAt := time.Now()
+
+		// trash trips, riders are kept so the trips can be restored
+		q := `UPDATE trips SET deleted_at = $1 WHERE mileage_log_id = $2 AND deleted_at IS NULL`
+		_, err = tx.ExecContext(ctx, q, deletedAt, id)
 		if err != nil {
 			return err
 		}
 
-		// delete mileage log
-		q = `DELETE from mileage_logs WHERE id=$1`
+		// trash mileage log
+		q = `UPDATE mileage_logs SET deleted_at = $1 WHERE id = $2`
 
-		_, err = tx.ExecContext(ctx, q, id)
+		_, err = tx.ExecContext(ctx, q, deletedAt, id)
 		if err != nil {
 			return err
 		}
@@ -381,13 +597,13 @@ func (m *postgresDBRepo) DeleteMileageLog(id int) error {
 	})
 }
 
-// GetTripsByMileageLogID returns a slice of Trips for a given mileage_log_id
+// GetTripsByMileageLogID returns a slice of Trips for a given mileage_log_id, leaving out trips in the trash
 // Trips are ordered by start_mileage descending
 func (m *postgresDBRepo) GetTripsByMileageLogID(mileage_log_id int) ([]models.Trip, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM trips WHERE mileage_log_id=$1 ORDER BY start_mileage DESC, end_mileage DESC, trip_date DESC, id DESC`, tripCols)
+	q := fmt.Sprintf(`SELECT id, %s FROM trips WHERE mileage_log_id=$1 AND deleted_at IS NULL ORDER BY start_mileage DESC, end_mileage DESC, trip_date DESC, id DESC`, tripCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q, mileage_log_id)
@@ -415,7 +631,7 @@ func (m *postgresDBRepo) GetTripByID(id int) (models.Trip, error) {
 	t := models.Trip{}
 	err := row.Scan(&t.ID, &t.MileageLog.ID, &t.TripDate, &t.StartMileage,
 		&t.EndMileage, &t.LongDistanceDays, &t.BillingRate, &t.Destination, &t.Purpose,
-		&t.CreatedAt, &t.UpdatedAt)
+		&t.CreatedAt, &t.UpdatedAt, &t.Hours, &t.Days)
 	if err != nil {
 		return t, err
 	}
@@ -438,104 +654,150 @@ func (m *postgresDBRepo) GetTripByID(id int) (models.Trip, error) {
 }
 
 // UpdateTripByID updates a Trip & its Riders by trip id
-func (m *postgresDBRepo) UpdateTripByID(v models.Trip) error {
+func (m *postgresDBRepo) UpdateTripByID(v models.Trip, userID int) error {
 	return runInTx(m.DB, func(tx *sql.Tx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 		defer cancel()
 
-		// update trips table
-		stmt := `UPDATE trips SET
-					mileage_log_id = $1,
-					trip_date = $2,
-					start_mileage = $3,
-					end_mileage = $4,
-					long_distance_days = $5,
-					destination = $6,
-					purpose = $7,
-					updated_at = $8,
-					billing_rate = $9
-					WHERE id=$10`
-
-		_, err := tx.ExecContext(ctx, stmt,
-			v.MileageLog.ID,
-			v.TripDate,
-			v.StartMileage,
-			v.EndMileage,
-			v.LongDistanceDays,
-			v.Destination,
-			v.Purpose,
-			time.Now(),
-			v.BillingRate,
-			v.ID)
-		if err != nil {
-			return err
-		}
+		return updateTripTx(tx, ctx, v, userID)
+	})
+}
 
-		// delete riders by trip id
-		stmt = `DELETE FROM riders WHERE trip_id = $1`
-		_, err = tx.ExecContext(ctx, stmt, v.ID)
+// updateTripTx is a helper function that takes a transaction and uses it to update a trip & replace its riders
+func updateTripTx(tx *sql.Tx, ctx context.Context, v models.Trip, userID int) error {
+	before, err := getTripAuditSnapshotTx(tx, ctx, v.ID)
+	if err != nil {
+		return err
+	}
+
+	// update trips table
+	stmt := `UPDATE trips SET
+				mileage_log_id = $1,
+				trip_date = $2,
+				start_mileage = $3,
+				end_mileage = $4,
+				long_distance_days = $5,
+				destination = $6,
+				purpose = $7,
+				updated_at = $8,
+				billing_rate = $9,
+				hours = $10,
+				days = $11
+				WHERE id=$12`
+
+	_, err = tx.ExecContext(ctx, stmt,
+		v.MileageLog.ID,
+		v.TripDate,
+		v.StartMileage,
+		v.EndMileage,
+		v.LongDistanceDays,
+		v.Destination,
+		v.Purpose,
+		time.Now(),
+		v.BillingRate,
+		v.Hours,
+		v.Days,
+		v.ID)
+	if err != nil {
+		return err
+	}
+
+	// delete riders by trip id
+	stmt = `DELETE FROM riders WHERE trip_id = $1`
+	_, err = tx.ExecContext(ctx, stmt, v.ID)
+	if err != nil {
+		return err
+	}
+
+	// insert into riders table
+	for _, rider := range v.Riders {
+		stmt := fmt.Sprintf(`INSERT INTO riders(%s)
+						VALUES ($1, $2, $3, $4, $5, $6)`, riderCols)
+
+		_, err := tx.ExecContext(ctx, stmt, v.ID, rider.Member.ID, time.Now(), time.Now(), rider.Weight, rider.Exempt)
 		if err != nil {
 			return err
 		}
+	}
 
-		// insert into riders table
-		for _, member := range v.Riders {
-			stmt := fmt.Sprintf(`INSERT INTO riders(%s)
-							VALUES ($1, $2, $3, $4)`, riderCols)
+	after, err := getTripAuditSnapshotTx(tx, ctx, v.ID)
+	if err != nil {
+		return err
+	}
+
+	return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+		EntityType:   models.AuditTrip,
+		EntityID:     v.ID,
+		MileageLogID: v.MileageLog.ID,
+		Action:       models.AuditUpdate,
+		User:         models.User{ID: userID},
+	}, before, after)
+}
 
-			_, err := tx.ExecContext(ctx, stmt, v.ID, member.ID, time.Now(), time.Now())
+// ApplyTripPlan inserts, updates or deletes the plan's target trip and moves its neighbouring trips
+// in one transaction, so the mileage log's trips are never left half changed
+func (m *postgresDBRepo) ApplyTripPlan(p models.TripPlan, userID int) error {
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		// move the neighbouring trips, then change the target trip
+		for _, c := range p.Cascades {
+			err := updateTripTx(tx, ctx, c.Trip, userID)
 			if err != nil {
 				return err
 			}
 		}
 
-		return nil
+		switch p.Target.Action {
+		case models.TripInserted:
+			_, err := insertTripTx(tx, ctx, p.Target.Trip, userID)
+			return err
+		case models.TripUpdated:
+			return updateTripTx(tx, ctx, p.Target.Trip, userID)
+		case models.TripDeleted:
+			return deleteTripTx(tx, ctx, p.Target.Trip.ID, userID)
+		}
+
+		return fmt.Errorf("unknown trip change %q", p.Target.Action)
 	})
 }
 
-// GetLaterTrips returns a slice of trips that happened in the same mileage log, after the given trip
-// The slice of trips is returned in ascending order
-func (m *postgresDBRepo) GetLaterTrips(v models.Trip) ([]models.Trip, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
+// DeleteTripByID moves a Trip to the trash by trip id
+func (m *postgresDBRepo) DeleteTripByID(v models.Trip, userID int) error {
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM trips WHERE mileage_log_id = $1 AND start_mileage >= $2 
-				ORDER BY start_mileage ASC, trip_date ASC`, tripCols)
+		return deleteTripTx(tx, ctx, v.ID, userID)
+	})
+}
 
-	// execute our DB query
-	rows, err := m.DB.QueryContext(ctx, q, v.MileageLog.ID, v.EndMileage)
+// deleteTripTx is a helper function that takes a transaction and uses it to move a trip to the trash.
+// Its riders are kept so the trip can be restored
+func deleteTripTx(tx *sql.Tx, ctx context.Context, id int, userID int) error {
+	before, err := getTripAuditSnapshotTx(tx, ctx, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	// process query result into slice of members to return
-	return m.scanRowsToTrips(rows, v.MileageLog.ID)
-
-}
-
-// DeleteTripByID deletes a Trip & its Riders by trip id
-func (m *postgresDBRepo) DeleteTripByID(v models.Trip) error {
-	return runInTx(m.DB, func(tx *sql.Tx) error {
-		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-		defer cancel()
-
-		// delete riders by trip id
-		stmt := `DELETE FROM riders WHERE trip_id = $1`
-		_, err := tx.ExecContext(ctx, stmt, v.ID)
-		if err != nil {
-			return err
-		}
+	err = insertAuditEntryTx(tx, ctx, models.AuditEntry{
+		EntityType:   models.AuditTrip,
+		EntityID:     id,
+		MileageLogID: before.MileageLogID,
+		Action:       models.AuditDelete,
+		User:         models.User{ID: userID},
+	}, before, nil)
+	if err != nil {
+		return err
+	}
 
-		// delete trip from trips table
-		stmt = `DELETE FROM trips WHERE id = $1`
+	stmt := `UPDATE trips SET deleted_at = $1 WHERE id = $2`
 
-		_, err = tx.ExecContext(ctx, stmt,
-			v.ID)
-		if err != nil {
-			return err
-		}
+	_, err = tx.ExecContext(ctx, stmt, time.Now(), id)
+	if err != nil {
+		return err
+	}
 
-		return nil
-	})
+	return nil
 }
@@ -0,0 +1,52 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GetQBOInvoiceNumbers returns the QBO invoice number for each member in the year & month, keyed by member id.
+// Members without an invoice number for the period are given the next number in the sequence in the order given,
+// so re-exporting a period always reuses the same numbers
+func (m *postgresDBRepo) GetQBOInvoiceNumbers(year int, month int, memberIDs []int) (map[int]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	invoiceNos := make(map[int]int)
+
+	err := runInTx(m.DB, func(tx *sql.Tx) error {
+		// only insert missing rows so existing numbers never use up a value from the sequence
+		stmt := `INSERT INTO qbo_invoices (year, month, member_id, created_at, updated_at)
+				SELECT $1, $2, $3, $4, $5
+				WHERE NOT EXISTS (SELECT 1 FROM qbo_invoices WHERE year = $1 AND month = $2 AND member_id = $3)`
+
+		for _, id := range memberIDs {
+			_, err := tx.ExecContext(ctx, stmt, year, month, id, time.Now(), time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		rows, err := tx.QueryContext(ctx, `SELECT member_id, invoice_no FROM qbo_invoices WHERE year = $1 AND month = $2`,
+			year, month)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var memberID, invoiceNo int
+			err := rows.Scan(&memberID, &invoiceNo)
+			if err != nil {
+				return err
+			}
+
+			invoiceNos[memberID] = invoiceNo
+		}
+
+		return rows.Err()
+	})
+
+	return invoiceNos, err
+}
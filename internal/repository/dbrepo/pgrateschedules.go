@@ -0,0 +1,249 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// rateScheduleCols lists the columns in the rate_schedules table EXCEPT "id"
+const rateScheduleCols = `vehicle_id, billing_type, base_per_mile, secondary_per_mile, minimum_fee,
+				valid_from, valid_to, created_at, updated_at, billing_params, fuel_surcharge_per_mile`
+
+// firstRateScheduleDate is the valid_from date given to a vehicle's first rate schedule
+// so that it covers every trip the vehicle could have
+var firstRateScheduleDate = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// InsertRateSchedule inserts a new rate schedule for a vehicle. The schedule that was in effect
+// before the new schedule's ValidFrom date is closed off the day before.
+func (m *postgresDBRepo) InsertRateSchedule(v models.RateSchedule) error {
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		return startRateScheduleTx(tx, ctx, v)
+	})
+}
+
+// startRateScheduleTx inserts a new rate schedule and closes off the schedule it replaces. A schedule can't start
+// on or before an existing schedule, or in a month that has been finalized, since that would re-price billed trips
+func startRateScheduleTx(tx *sql.Tx, ctx context.Context, v models.RateSchedule) error {
+	// don't allow rewriting schedules that start on or after the new schedule
+	var laterCount int
+	q := `SELECT COUNT(*) FROM rate_schedules WHERE vehicle_id = $1 AND valid_from >= $2`
+	err := tx.QueryRowContext(ctx, q, v.Vehicle.ID, v.ValidFrom).Scan(&laterCount)
+	if err != nil {
+		return err
+	}
+
+	if laterCount > 0 {
+		return fmt.Errorf("a rate schedule starting on or after %s already exists", v.ValidFrom.Format(config.DateLayout))
+	}
+
+	err = checkRatesUnbilledTx(tx, ctx, v.ValidFrom)
+	if err != nil {
+		return err
+	}
+
+	// close the schedule that was in effect on the new start date
+	q = `UPDATE rate_schedules SET
+			valid_to = $1,
+			updated_at = $2
+		WHERE vehicle_id = $3 AND valid_from < $4 AND (valid_to IS NULL OR valid_to >= $4)`
+	_, err = tx.ExecContext(ctx, q, v.ValidFrom.AddDate(0, 0, -1), time.Now(), v.Vehicle.ID, v.ValidFrom)
+	if err != nil {
+		return err
+	}
+
+	return insertRateScheduleTx(tx, ctx, v)
+}
+
+// checkRatesUnbilledTx returns an error if rates taking effect on validFrom would re-price a month that has been
+// finalized or exported
+func checkRatesUnbilledTx(tx *sql.Tx, ctx context.Context, validFrom time.Time) error {
+	var lockedCount int
+	q := `SELECT COUNT(*) FROM billing_periods
+		WHERE status IN ('finalized', 'exported') AND MAKE_DATE(year, month, 1) + INTERVAL '1 month' > $1`
+	err := tx.QueryRowContext(ctx, q, validFrom).Scan(&lockedCount)
+	if err != nil {
+		return err
+	}
+
+	if lockedCount > 0 {
+		return fmt.Errorf("rates starting %s would re-price a finalized billing period", validFrom.Format(config.DateLayout))
+	}
+
+	return nil
+}
+
+// insertRateScheduleTx is a helper function that takes a transaction and uses it to insert a rate schedule
+func insertRateScheduleTx(tx *sql.Tx, ctx context.Context, v models.RateSchedule) error {
+	stmt := fmt.Sprintf(`INSERT INTO rate_schedules (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		rateScheduleCols)
+
+	_, err := tx.ExecContext(ctx, stmt,
+		v.Vehicle.ID, v.BillingType, v.BasePerMile, v.SecondaryPerMile, v.MinimumFee,
+		v.ValidFrom, v.ValidTo,
+		time.Now(), time.Now(), v.Params, v.FuelSurchargePerMile,
+	)
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// scanRowsToRateSchedules takes a pointer to *sql.Rows and scans those values into a slice of RateSchedules
+func scanRowsToRateSchedules(rows *sql.Rows) ([]models.RateSchedule, error) {
+	var schedules []models.RateSchedule
+
+	for rows.Next() {
+		r := models.RateSchedule{}
+		err := rows.Scan(&r.ID, &r.Vehicle.ID, &r.BillingType, &r.BasePerMile, &r.SecondaryPerMile, &r.MinimumFee,
+			&r.ValidFrom, &r.ValidTo, &r.CreatedAt, &r.UpdatedAt, &r.Params, &r.FuelSurchargePerMile)
+		if err != nil {
+			return schedules, err
+		}
+
+		schedules = append(schedules, r)
+	}
+	err := rows.Err()
+	if err != nil {
+		return schedules, err
+	}
+
+	return schedules, nil
+}
+
+// GetRateSchedulesByVehicleID returns all rate schedules for a vehicle, newest first
+func (m *postgresDBRepo) GetRateSchedulesByVehicleID(vehicleID int) ([]models.RateSchedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM rate_schedules WHERE vehicle_id = $1 ORDER BY valid_from DESC`, rateScheduleCols)
+
+	// execute our DB query
+	rows, err := m.DB.QueryContext(ctx, q, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRowsToRateSchedules(rows)
+}
+
+// GetRateScheduleByDate returns the rate schedule in effect for a vehicle on a given date
+func (m *postgresDBRepo) GetRateScheduleByDate(vehicleID int, d time.Time) (models.RateSchedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM rate_schedules
+		WHERE vehicle_id = $1 AND valid_from <= $2 AND (valid_to IS NULL OR valid_to >= $2)
+		ORDER BY valid_from DESC LIMIT 1`, rateScheduleCols)
+
+	// execute our DB query
+	row := m.DB.QueryRowContext(ctx, q, vehicleID, d)
+
+	r := models.RateSchedule{}
+	err := row.Scan(&r.ID, &r.Vehicle.ID, &r.BillingType, &r.BasePerMile, &r.SecondaryPerMile, &r.MinimumFee,
+		&r.ValidFrom, &r.ValidTo, &r.CreatedAt, &r.UpdatedAt, &r.Params, &r.FuelSurchargePerMile)
+	if err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// longDistanceRateCols lists the columns in the long_distance_rates table EXCEPT "id"
+const longDistanceRateCols = `billing_type, single_day_rate, multi_day_rate, valid_from, valid_to,
+				created_at, updated_at`
+
+// InsertLongDistanceRate inserts a new long distance rate. The rate for the same billing type
+// that was in effect before the new rate's ValidFrom date is closed off the day before.
+// Like rate schedules, a rate can't take effect in a month that has been finalized
+func (m *postgresDBRepo) InsertLongDistanceRate(v models.LongDistanceRate) error {
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		// don't allow rewriting rates that start on or after the new rate
+		var laterCount int
+		q := `SELECT COUNT(*) FROM long_distance_rates WHERE billing_type = $1 AND valid_from >= $2`
+		err := tx.QueryRowContext(ctx, q, v.BillingType, v.ValidFrom).Scan(&laterCount)
+		if err != nil {
+			return err
+		}
+
+		if laterCount > 0 {
+			return fmt.Errorf("a long distance rate starting on or after %s already exists", v.ValidFrom.Format(config.DateLayout))
+		}
+
+		err = checkRatesUnbilledTx(tx, ctx, v.ValidFrom)
+		if err != nil {
+			return err
+		}
+
+		// close the rate that was in effect on the new start date
+		q = `UPDATE long_distance_rates SET
+				valid_to = $1,
+				updated_at = $2
+			WHERE billing_type = $3 AND valid_from < $4 AND (valid_to IS NULL OR valid_to >= $4)`
+		_, err = tx.ExecContext(ctx, q, v.ValidFrom.AddDate(0, 0, -1), time.Now(), v.BillingType, v.ValidFrom)
+		if err != nil {
+			return err
+		}
+
+		stmt := fmt.Sprintf(`INSERT INTO long_distance_rates (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			longDistanceRateCols)
+
+		_, err = tx.ExecContext(ctx, stmt,
+			v.BillingType, v.SingleDayRate, v.MultiDayRate, v.ValidFrom, v.ValidTo,
+			time.Now(), time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// AllLongDistanceRates returns a slice of all long distance rates, ordered by billing type then newest first
+func (m *postgresDBRepo) AllLongDistanceRates() ([]models.LongDistanceRate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT id, %s FROM long_distance_rates ORDER BY billing_type, valid_from DESC`, longDistanceRateCols)
+
+	// execute our DB query
+	rows, err := m.DB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.LongDistanceRate
+
+	for rows.Next() {
+		r := models.LongDistanceRate{}
+		err := rows.Scan(&r.ID, &r.BillingType, &r.SingleDayRate, &r.MultiDayRate, &r.ValidFrom, &r.ValidTo,
+			&r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return rates, err
+		}
+
+		rates = append(rates, r)
+	}
+	err = rows.Err()
+	if err != nil {
+		return rates, err
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,67 @@
+package dbrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// statementSendCols lists the columns in the statement_sends table EXCEPT "id"
+const statementSendCols = `year, month, member_id, email, status, error, attempts, sent_at, created_at, updated_at`
+
+// RecordStatementSend saves the result of an attempt to email a member's statement,
+// replacing the result of any earlier attempt for the same year & month
+func (m *postgresDBRepo) RecordStatementSend(v models.StatementSend) error {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	stmt := fmt.Sprintf(`INSERT INTO statement_sends (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, 1, $7, $8, $9)
+				ON CONFLICT (year, month, member_id) DO UPDATE SET
+					email = EXCLUDED.email, status = EXCLUDED.status, error = EXCLUDED.error,
+					attempts = statement_sends.attempts + 1,
+					sent_at = COALESCE(EXCLUDED.sent_at, statement_sends.sent_at),
+					updated_at = EXCLUDED.updated_at`,
+		statementSendCols)
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		v.Year, v.Month, v.Member.ID, v.Email, v.Status, v.Error, v.SentAt,
+		time.Now(), time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetStatementSends returns the send status of each member's statement for a year & month, keyed by member id
+func (m *postgresDBRepo) GetStatementSends(year int, month int) (map[int]models.StatementSend, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	sends := make(map[int]models.StatementSend)
+
+	q := fmt.Sprintf(`SELECT id, %s FROM statement_sends WHERE year = $1 AND month = $2`, statementSendCols)
+
+	rows, err := m.DB.QueryContext(ctx, q, year, month)
+	if err != nil {
+		return sends, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := models.StatementSend{}
+		err := rows.Scan(&s.ID, &s.Year, &s.Month, &s.Member.ID, &s.Email, &s.Status, &s.Error, &s.Attempts,
+			&s.SentAt, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return sends, err
+		}
+
+		sends[s.Member.ID] = s
+	}
+
+	return sends, rows.Err()
+}
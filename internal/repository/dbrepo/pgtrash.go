@@ -0,0 +1,278 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// GetTrash returns every trip, mileage log, member and vehicle in the trash, most recently deleted first.
+// Trips that were deleted along with their mileage log are restored with the log and aren't listed
+func (m *postgresDBRepo) GetTrash() ([]models.TrashItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+	defer cancel()
+
+	q := `SELECT 'trip', t.id,
+				CONCAT(l.name, ': ', TO_CHAR(t.trip_date, 'YYYY-MM-DD'), ' ', t.start_mileage, '-', t.end_mileage, ' ', t.destination),
+				t.mileage_log_id, l.deleted_at IS NOT NULL, t.deleted_at
+			FROM trips t
+			JOIN mileage_logs l ON l.id = t.mileage_log_id
+			WHERE t.deleted_at IS NOT NULL AND (l.deleted_at IS NULL OR l.deleted_at <> t.deleted_at)
+		UNION ALL
+		SELECT 'mileage_log', id, name, id, false, deleted_at FROM mileage_logs WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'member', id, name, 0, false, deleted_at FROM members WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'vehicle', id, name, 0, false, deleted_at FROM vehicles WHERE deleted_at IS NOT NULL
+		ORDER BY 6 DESC, 2 DESC`
+
+	rows, err := m.DB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.TrashItem
+	for rows.Next() {
+		var i models.TrashItem
+		err := rows.Scan(&i.EntityType, &i.ID, &i.Name, &i.MileageLogID, &i.MileageLogDeleted, &i.DeletedAt)
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, i)
+	}
+
+	return items, rows.Err()
+}
+
+// RestoreTrip takes a trip out of the trash. Its mileage log has to be restored first if it is also in the trash
+func (m *postgresDBRepo) RestoreTrip(id int, userID int) error {
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		var logDeleted bool
+		q := `SELECT l.deleted_at IS NOT NULL FROM trips t JOIN mileage_logs l ON l.id = t.mileage_log_id WHERE t.id = $1`
+		err := tx.QueryRowContext(ctx, q, id).Scan(&logDeleted)
+		if err != nil {
+			return err
+		}
+
+		if logDeleted {
+			return fmt.Errorf("%w: the trip's mileage log is in the trash, restore the mileage log first", models.ErrCannotRestore)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE trips SET deleted_at = NULL WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		after, err := getTripAuditSnapshotTx(tx, ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditTrip,
+			EntityID:     id,
+			MileageLogID: after.MileageLogID,
+			Action:       models.AuditRestore,
+			User:         models.User{ID: userID},
+		}, nil, after)
+	})
+}
+
+// RestoreMileageLog takes a mileage log out of the trash along with the trips that were deleted with it.
+// It fails if the vehicle has been given another mileage log for the same year & month since
+func (m *postgresDBRepo) RestoreMileageLog(id int, userID int) error {
+	log, err := m.GetMileageLogByID(id)
+	if err != nil {
+		return err
+	}
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		var others int
+		q := `SELECT COUNT(*) FROM mileage_logs
+			WHERE vehicle_id = $1 AND year = $2 AND month = $3 AND id <> $4 AND deleted_at IS NULL`
+		err := tx.QueryRowContext(ctx, q, log.Vehicle.ID, log.Year, log.Month, id).Scan(&others)
+		if err != nil {
+			return err
+		}
+
+		if others > 0 {
+			return fmt.Errorf("%w: %s already has a mileage log for %04d-%02d", models.ErrCannotRestore, log.Vehicle.Name, log.Year, log.Month)
+		}
+
+		q = `UPDATE trips t SET deleted_at = NULL
+			FROM mileage_logs l
+			WHERE l.id = t.mileage_log_id AND l.id = $1 AND t.deleted_at = l.deleted_at`
+		_, err = tx.ExecContext(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE mileage_logs SET deleted_at = NULL WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType:   models.AuditMileageLog,
+			EntityID:     id,
+			MileageLogID: id,
+			Action:       models.AuditRestore,
+			User:         models.User{ID: userID},
+		}, nil, models.NewMileageLogAuditSnapshot(log))
+	})
+}
+
+// RestoreMember takes a member out of the trash
+func (m *postgresDBRepo) RestoreMember(id int, userID int) error {
+	v, err := m.GetMemberByID(id)
+	if err != nil {
+		return err
+	}
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, `UPDATE members SET deleted_at = NULL WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditMember,
+			EntityID:   id,
+			Action:     models.AuditRestore,
+			User:       models.User{ID: userID},
+		}, nil, models.NewMemberAuditSnapshot(v))
+	})
+}
+
+// RestoreVehicle takes a vehicle out of the trash
+func (m *postgresDBRepo) RestoreVehicle(id int, userID int) error {
+	v, err := m.GetVehicleByID(id)
+	if err != nil {
+		return err
+	}
+
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, `UPDATE vehicles SET deleted_at = NULL WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditVehicle,
+			EntityID:   id,
+			Action:     models.AuditRestore,
+			User:       models.User{ID: userID},
+		}, nil, models.NewVehicleAuditSnapshot(v))
+	})
+}
+
+// PurgeTrash permanently deletes everything that was put in the trash before the given time.
+// Members and vehicles that trips, mileage logs, fuel purchases or billing records still refer to
+// are left in the trash, since deleting them would cascade to that history. The stored files of purged
+// attachments and compliance documents are returned so they can be removed from storage
+func (m *postgresDBRepo) PurgeTrash(before time.Time) (models.TrashPurgeResult, error) {
+	var result models.TrashPurgeResult
+
+	err := runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		// files attached to the mileage logs and vehicles about to be purged, checked after the delete for
+		// whether anything else still refers to them
+		var keys []string
+		q := `SELECT a.storage_key FROM mileage_log_attachments a
+			JOIN mileage_logs l ON l.id = a.mileage_log_id
+			WHERE l.deleted_at < $1
+			UNION
+			SELECT d.storage_key FROM compliance_documents d
+			JOIN vehicles v ON v.id = d.vehicle_id
+			WHERE v.deleted_at < $1 AND d.storage_key <> ''`
+		rows, err := tx.QueryContext(ctx, q, before)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var key string
+			err = rows.Scan(&key)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		// riders are deleted with their trips, trips and attachments with their mileage logs, and compliance
+		// documents with their vehicles by the foreign keys
+		stmts := []struct {
+			q     string
+			count *int64
+		}{
+			{`DELETE FROM trips WHERE deleted_at < $1`, &result.Trips},
+			{`DELETE FROM mileage_logs WHERE deleted_at < $1`, &result.MileageLogs},
+			{`DELETE FROM members mb WHERE deleted_at < $1
+				AND NOT EXISTS (SELECT 1 FROM riders WHERE member_id = mb.id)
+				AND NOT EXISTS (SELECT 1 FROM fuel_purchases WHERE member_id = mb.id)
+				AND NOT EXISTS (SELECT 1 FROM billing_period_charges WHERE member_id = mb.id)
+				AND NOT EXISTS (SELECT 1 FROM ledger_entries WHERE member_id = mb.id)
+				AND NOT EXISTS (SELECT 1 FROM qbo_invoices WHERE member_id = mb.id)
+				AND NOT EXISTS (SELECT 1 FROM statement_sends WHERE member_id = mb.id)`, &result.Members},
+			{`DELETE FROM vehicles v WHERE deleted_at < $1
+				AND NOT EXISTS (SELECT 1 FROM mileage_logs WHERE vehicle_id = v.id)
+				AND NOT EXISTS (SELECT 1 FROM fuel_purchases WHERE vehicle_id = v.id)
+				AND NOT EXISTS (SELECT 1 FROM billing_period_charges WHERE vehicle_id = v.id)
+				AND NOT EXISTS (SELECT 1 FROM service_records WHERE vehicle_id = v.id)
+				AND NOT EXISTS (SELECT 1 FROM vehicle_expenses WHERE vehicle_id = v.id)
+				AND NOT EXISTS (SELECT 1 FROM compliance_documents WHERE vehicle_id = v.id)`, &result.Vehicles},
+		}
+
+		for _, s := range stmts {
+			res, err := tx.ExecContext(ctx, s.q, before)
+			if err != nil {
+				return err
+			}
+
+			*s.count, err = res.RowsAffected()
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, key := range keys {
+			var unused bool
+			err = tx.QueryRowContext(ctx, storageKeyUnusedQuery, key).Scan(&unused)
+			if err != nil {
+				return err
+			}
+
+			if unused {
+				result.UnusedAttachmentKeys = append(result.UnusedAttachmentKeys, key)
+			}
+		}
+
+		q = `SELECT (SELECT COUNT(*) FROM members WHERE deleted_at < $1) + (SELECT COUNT(*) FROM vehicles WHERE deleted_at < $1)`
+		return tx.QueryRowContext(ctx, q, before).Scan(&result.Skipped)
+	})
+
+	return result, err
+}
@@ -15,31 +15,40 @@ const vehicleCols = `name, year, make, model, fuel_type,
 				is_active, sale_price, sale_date,
 				billing_type, base_per_mile, secondary_per_mile, minimum_fee,
 				created_at, updated_at,
-				qbo_class`
+				qbo_class, billing_params, fuel_surcharge_per_mile`
 
-// InsertVehicle inserts a Vehicle into the database
+// InsertVehicle inserts a Vehicle into the database. This is wrapped in a transaction
+// because the vehicle's starting rate schedule is inserted along with it
 func (m *postgresDBRepo) InsertVehicle(v models.Vehicle) error {
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
-
-	stmt := fmt.Sprintf(`INSERT INTO vehicles (%s)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`,
-		vehicleCols)
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		var lastInsertId int
+		stmt := fmt.Sprintf(`INSERT INTO vehicles (%s)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+				RETURNING id`,
+			vehicleCols)
+
+		err := tx.QueryRowContext(ctx, stmt,
+			v.Name, v.Year, v.Make, v.Model, v.FuelType,
+			v.PurchasePrice, v.PurchaseDate, v.Vin, v.LicensePlate,
+			v.Active, v.SalePrice, v.SaleDate,
+			v.BillingType, v.BasePerMile, v.SecondaryPerMile, v.MinimumFee,
+			time.Now(), time.Now(),
+			v.QBOClass, v.BillingParams, v.FuelSurchargePerMile,
+		).Scan(&lastInsertId)
+		if err != nil {
+			return err
+		}
 
-	_, err := m.DB.ExecContext(ctx, stmt,
-		v.Name, v.Year, v.Make, v.Model, v.FuelType,
-		v.PurchasePrice, v.PurchaseDate, v.Vin, v.LicensePlate,
-		v.Active, v.SalePrice, v.SaleDate,
-		v.BillingType, v.BasePerMile, v.SecondaryPerMile, v.MinimumFee,
-		time.Now(), time.Now(),
-		v.QBOClass,
-	)
+		// the first rate schedule covers every trip the vehicle could have
+		v.ID = lastInsertId
+		rates := v.CurrentRates()
+		rates.ValidFrom = firstRateScheduleDate
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+		return insertRateScheduleTx(tx, ctx, rates)
+	})
 }
 
 // scanRowsToVehicles takes a pointer to *sql.Rows and scans those values into a slice of Vehicles
@@ -52,7 +61,7 @@ func scanRowsToVehicles(rows *sql.Rows) ([]models.Vehicle, error) {
 			&m.PurchasePrice, &m.PurchaseDate, &m.Vin, &m.LicensePlate,
 			&m.Active, &m.SalePrice, &m.SaleDate,
 			&m.BillingType, &m.BasePerMile, &m.SecondaryPerMile, &m.MinimumFee,
-			&m.CreatedAt, &m.UpdatedAt, &m.QBOClass)
+			&m.CreatedAt, &m.UpdatedAt, &m.QBOClass, &m.BillingParams, &m.FuelSurchargePerMile)
 		if err != nil {
 			return vehicles, err
 		}
@@ -67,12 +76,12 @@ func scanRowsToVehicles(rows *sql.Rows) ([]models.Vehicle, error) {
 	return vehicles, nil
 }
 
-// AllVehicles returns a slice of all vehicles in database
+// AllVehicles returns a slice of all vehicles in database that aren't in the trash
 func (m *postgresDBRepo) AllVehicles() ([]models.Vehicle, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM vehicles ORDER BY name`, vehicleCols)
+	q := fmt.Sprintf(`SELECT id, %s FROM vehicles WHERE deleted_at IS NULL ORDER BY name`, vehicleCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q)
@@ -90,7 +99,7 @@ func (m *postgresDBRepo) GetVehicleByActive(active bool) ([]models.Vehicle, erro
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT id, %s FROM vehicles WHERE is_active=$1 ORDER BY name`, vehicleCols)
+	q := fmt.Sprintf(`SELECT id, %s FROM vehicles WHERE is_active=$1 AND deleted_at IS NULL ORDER BY name`, vehicleCols)
 
 	// execute our DB query
 	rows, err := m.DB.QueryContext(ctx, q, active)
@@ -103,7 +112,7 @@ func (m *postgresDBRepo) GetVehicleByActive(active bool) ([]models.Vehicle, erro
 	return scanRowsToVehicles(rows)
 }
 
-// GetVehicleByID returns one vehicle from a given id
+// GetVehicleByID returns one vehicle from a given id, populates rate schedules and long distance rates
 func (m *postgresDBRepo) GetVehicleByID(id int) (models.Vehicle, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
 	defer cancel()
@@ -121,107 +130,181 @@ func (m *postgresDBRepo) GetVehicleByID(id int) (models.Vehicle, error) {
 		&v.PurchasePrice, &v.PurchaseDate, &v.Vin, &v.LicensePlate,
 		&v.Active, &v.SalePrice, &v.SaleDate,
 		&v.BillingType, &v.BasePerMile, &v.SecondaryPerMile, &v.MinimumFee,
-		&v.CreatedAt, &v.UpdatedAt, &v.QBOClass)
+		&v.CreatedAt, &v.UpdatedAt, &v.QBOClass, &v.BillingParams, &v.FuelSurchargePerMile)
 	if err != nil {
 		return v, err
 	}
 
-	return v, nil
-}
+	// get rate schedules so trips can be billed at the rate in effect on the trip date
+	v.RateSchedules, err = m.GetRateSchedulesByVehicleID(id)
+	if err != nil {
+		return v, err
+	}
 
-// UpdateVehicle updates a vehicle in the database
-func (m *postgresDBRepo) UpdateVehicle(v models.Vehicle) error {
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
+	// get long distance rates, the model picks the one matching the vehicle's billing type
+	v.LongDistanceRates, err = m.AllLongDistanceRates()
+	if err != nil {
+		return v, err
+	}
 
-	q := `UPDATE vehicles SET
-			name = $1,
-			year = $2,
-			make = $3,
-			model = $4,
-			fuel_type = $5,
-			purchase_price = $6,
-			purchase_date = $7,
-			vin = $8,
-			license_plate = $9,
-			is_active = $10,
-			sale_price = $11,
-			sale_date = $12,
-			billing_type = $13,
-			base_per_mile = $14,
-			secondary_per_mile = $15,
-			minimum_fee = $16,
-			updated_at = $17,
-			qbo_class = $18
-		WHERE id =  $19
-		`
-
-	_, err := m.DB.ExecContext(ctx, q,
-		v.Name,
-		v.Year,
-		v.Make,
-		v.Model,
-		v.FuelType,
-		v.PurchasePrice,
-		v.PurchaseDate,
-		v.Vin,
-		v.LicensePlate,
-		v.Active,
-		v.SalePrice,
-		v.SaleDate,
-		v.BillingType,
-		v.BasePerMile,
-		v.SecondaryPerMile,
-		v.MinimumFee,
-		time.Now(),
-		v.QBOClass,
-		v.ID,
-	)
+	return v, nil
+}
 
+// UpdateVehicle updates a vehicle in the database. When rates is given the new rate schedule is started
+// in the same transaction, so the vehicle row and its schedules can't disagree
+func (m *postgresDBRepo) UpdateVehicle(v models.Vehicle, rates *models.RateSchedule, userID int) error {
+	before, err := m.GetVehicleByID(v.ID)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		q := `UPDATE vehicles SET
+				name = $1,
+				year = $2,
+				make = $3,
+				model = $4,
+				fuel_type = $5,
+				purchase_price = $6,
+				purchase_date = $7,
+				vin = $8,
+				license_plate = $9,
+				is_active = $10,
+				sale_price = $11,
+				sale_date = $12,
+				billing_type = $13,
+				base_per_mile = $14,
+				secondary_per_mile = $15,
+				minimum_fee = $16,
+				updated_at = $17,
+				qbo_class = $18,
+				billing_params = $19,
+				fuel_surcharge_per_mile = $20
+			WHERE id =  $21
+			`
+
+		_, err = tx.ExecContext(ctx, q,
+			v.Name,
+			v.Year,
+			v.Make,
+			v.Model,
+			v.FuelType,
+			v.PurchasePrice,
+			v.PurchaseDate,
+			v.Vin,
+			v.LicensePlate,
+			v.Active,
+			v.SalePrice,
+			v.SaleDate,
+			v.BillingType,
+			v.BasePerMile,
+			v.SecondaryPerMile,
+			v.MinimumFee,
+			time.Now(),
+			v.QBOClass,
+			v.BillingParams,
+			v.FuelSurchargePerMile,
+			v.ID,
+		)
 
-// UpdateVehicleActiveByID updates the active status of a vehicle by id
-func (m *postgresDBRepo) UpdateVehicleActiveByID(id int, active bool) error {
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
+		if err != nil {
+			return err
+		}
 
-	q := `UPDATE vehicles SET
-			is_active = $1,
-			updated_at = $2
-		WHERE id =  $3
-		`
+		if rates != nil {
+			err = startRateScheduleTx(tx, ctx, *rates)
+			if err != nil {
+				return err
+			}
+		}
 
-	_, err := m.DB.ExecContext(ctx, q,
-		active,
-		time.Now(),
-		id,
-	)
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditVehicle,
+			EntityID:   v.ID,
+			Action:     models.AuditUpdate,
+			User:       models.User{ID: userID},
+		}, models.NewVehicleAuditSnapshot(before), models.NewVehicleAuditSnapshot(v))
+	})
+}
 
+// UpdateVehicleActiveByID updates the active status of a vehicle by id
+func (m *postgresDBRepo) UpdateVehicleActiveByID(id int, active bool, userID int) error {
+	before, err := m.GetVehicleByID(id)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
 
-// DeleteVehicle deletes one vehicle by id
-// We should almost never actually delete a vehicle
-// because it will be referenced by a lot of mileage logs.
-func (m *postgresDBRepo) DeleteVehicle(id int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
-	defer cancel()
+		q := `UPDATE vehicles SET
+				is_active = $1,
+				updated_at = $2
+			WHERE id =  $3
+			`
+
+		_, err := tx.ExecContext(ctx, q,
+			active,
+			time.Now(),
+			id,
+		)
+
+		if err != nil {
+			return err
+		}
 
-	q := `DELETE from vehicles WHERE id=$1`
+		after := before
+		after.Active = active
 
-	_, err := m.DB.ExecContext(ctx, q, id)
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditVehicle,
+			EntityID:   id,
+			Action:     models.AuditUpdate,
+			User:       models.User{ID: userID},
+		}, models.NewVehicleAuditSnapshot(before), models.NewVehicleAuditSnapshot(after))
+	})
+}
+
+// DeleteVehicle moves one vehicle to the trash by id so it can be restored. Vehicles with mileage logs,
+// fuel purchases, billing records, service records, expenses or compliance documents can't be deleted and
+// models.ErrHasHistory is returned, since purging them from the trash would cascade to that history
+func (m *postgresDBRepo) DeleteVehicle(id int, userID int) error {
+	before, err := m.GetVehicleByID(id)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return runInTx(m.DB, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+		defer cancel()
+
+		var history int
+		err := tx.QueryRowContext(ctx, vehicleHistoryCount, id).Scan(&history)
+		if err != nil {
+			return err
+		}
+
+		if history > 0 {
+			return fmt.Errorf("%w: %s has trips, fuel purchases, billing records, maintenance, expenses or documents",
+				models.ErrHasHistory, before.Name)
+		}
+
+		q := `UPDATE vehicles SET deleted_at = $1 WHERE id = $2`
+
+		_, err = tx.ExecContext(ctx, q, time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditEntryTx(tx, ctx, models.AuditEntry{
+			EntityType: models.AuditVehicle,
+			EntityID:   id,
+			Action:     models.AuditDelete,
+			User:       models.User{ID: userID},
+		}, models.NewVehicleAuditSnapshot(before), nil)
+	})
 }
@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/cxt314/drvc-go/internal/models"
+import (
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
 
 type DatabaseRepo interface {
 	AllUsers() ([]models.User, error)
@@ -15,28 +19,104 @@ type DatabaseRepo interface {
 	AllVehicles() ([]models.Vehicle, error)
 	GetVehicleByActive(active bool) ([]models.Vehicle, error)
 	GetVehicleByID(id int) (models.Vehicle, error)
-	UpdateVehicle(v models.Vehicle) error
-	UpdateVehicleActiveByID(id int, active bool) error
-	DeleteVehicle(id int) error
+	UpdateVehicle(v models.Vehicle, rates *models.RateSchedule, userID int) error
+	UpdateVehicleActiveByID(id int, active bool, userID int) error
+	DeleteVehicle(id int, userID int) error
+
+	InsertRateSchedule(v models.RateSchedule) error
+	GetRateSchedulesByVehicleID(vehicleID int) ([]models.RateSchedule, error)
+	GetRateScheduleByDate(vehicleID int, d time.Time) (models.RateSchedule, error)
+	InsertLongDistanceRate(v models.LongDistanceRate) error
+	AllLongDistanceRates() ([]models.LongDistanceRate, error)
 
 	InsertMember(v models.Member) error
 	AllMembers() ([]models.Member, error)
 	GetMemberByActive(active bool) ([]models.Member, error)
 	GetMemberByID(id int) (models.Member, error)
-	UpdateMember(v models.Member) error
-	UpdateMemberActiveByID(id int, active bool) error
-	DeleteMember(id int) error
+	UpdateMember(v models.Member, userID int) error
+	UpdateMemberActiveByID(id int, active bool, userID int) error
+	DeleteMember(id int, userID int) error
 
-	InsertMileageLog(v models.MileageLog) (int, error)
+	InsertMileageLog(v models.MileageLog, userID int) (int, error)
 	AllMileageLogs() ([]models.MileageLog, error)
 	GetMileageLogsByVehicleID(vehicle_id int) ([]models.MileageLog, error)
 	GetMileageLogByID(id int) (models.MileageLog, error)
-	UpdateMileageLog(v models.MileageLog) error
-	DeleteMileageLog(id int) error
-	InsertTrip(v models.Trip) (int, error)
+	UpdateMileageLog(v models.MileageLog, userID int) error
+	DeleteMileageLog(id int, userID int) error
+	UpdateMileageLogStatus(id int, status string, userID int) error
+	InsertTrip(v models.Trip, userID int) (int, error)
+	InsertTrips(trips []models.Trip, userID int) error
 	GetTripByID(id int) (models.Trip, error)
-	UpdateTripByID(v models.Trip) error 
-	GetLaterTrips(v models.Trip) ([]models.Trip, error)
+	UpdateTripByID(v models.Trip, userID int) error 
 	GetMileageLogsByYearMonth(year int, month int) ([]models.MileageLog, error)
-	DeleteTripByID(v models.Trip) error
+	GetPreviousOdometer(vehicleID int, year int, month int) (int, error)
+	GetPreviousMileageLog(vehicleID int, year int, month int) (models.MileageLog, error)
+	DeleteTripByID(v models.Trip, userID int) error
+	ApplyTripPlan(p models.TripPlan, userID int) error
+
+	InsertFuelPurchase(v models.FuelPurchase) error
+	GetFuelPurchasesByVehicleYearMonth(vehicleID int, year int, month int) ([]models.FuelPurchase, error)
+	GetFuelPurchaseByID(id int) (models.FuelPurchase, error)
+	DeleteFuelPurchase(id int) error
+
+	GetBillingPeriod(year int, month int) (models.BillingPeriod, error)
+	GetBillingPeriodByMileageLogID(logID int) (models.BillingPeriod, error)
+	GetLatestLockedBillingPeriod() (models.BillingPeriod, error)
+	FinalizeBillingPeriod(p models.BillingPeriod, entries []models.LedgerEntry, userID int, reason string) error
+	MarkBillingPeriodExported(year int, month int, userID int) error
+	ReopenBillingPeriod(year int, month int, userID int, reason string) error
+
+	GetQBOInvoiceNumbers(year int, month int, memberIDs []int) (map[int]int, error)
+
+	InsertLedgerEntry(v models.LedgerEntry) error
+	GetLedgerEntriesByMemberID(memberID int) ([]models.LedgerEntry, error)
+	AllLedgerEntries() ([]models.LedgerEntry, error)
+	GetLedgerEntryByID(id int) (models.LedgerEntry, error)
+	DeleteLedgerEntry(memberID int, id int) error
+
+	RecordStatementSend(v models.StatementSend) error
+	GetStatementSends(year int, month int) (map[int]models.StatementSend, error)
+
+	GetAuditEntriesByMileageLogID(logID int) ([]models.AuditEntry, error)
+	GetAuditEntriesByEntity(entityType string, entityID int) ([]models.AuditEntry, error)
+
+	GetTrash() ([]models.TrashItem, error)
+	RestoreTrip(id int, userID int) error
+	RestoreMileageLog(id int, userID int) error
+	RestoreMember(id int, userID int) error
+	RestoreVehicle(id int, userID int) error
+	PurgeTrash(before time.Time) (models.TrashPurgeResult, error)
+
+	InsertAttachment(a models.Attachment, userID int) (int, error)
+	GetAttachmentsByMileageLogID(logID int) ([]models.Attachment, error)
+	GetAttachmentByID(id int) (models.Attachment, error)
+	DeleteAttachment(id int, userID int) (bool, error)
+
+	InsertServiceRecord(v models.ServiceRecord) error
+	GetServiceRecordsByVehicleID(vehicleID int) ([]models.ServiceRecord, error)
+	DeleteServiceRecord(vehicleID int, id int) error
+	UpsertMaintenanceSchedule(v models.MaintenanceSchedule) error
+	GetMaintenanceSchedulesByVehicleID(vehicleID int) ([]models.MaintenanceSchedule, error)
+	DeleteMaintenanceSchedule(vehicleID int, id int) error
+	GetLatestOdometer(vehicleID int) (int, error)
+
+	GetFuelPurchasesByVehicleID(vehicleID int) ([]models.FuelPurchase, error)
+	InsertVehicleExpense(v models.VehicleExpense) error
+	GetVehicleExpensesByVehicleID(vehicleID int) ([]models.VehicleExpense, error)
+	DeleteVehicleExpense(vehicleID int, id int) error
+
+	InsertComplianceDocument(d models.ComplianceDocument) (int, error)
+	GetComplianceDocumentsByVehicleID(vehicleID int) ([]models.ComplianceDocument, error)
+	GetComplianceDocumentByID(id int) (models.ComplianceDocument, error)
+	GetActiveVehicleComplianceDocuments() ([]models.ComplianceDocument, error)
+	DeleteComplianceDocument(vehicleID int, id int) (bool, error)
+	InsertComplianceDigest(to []string, sentAt time.Time) error
+	GetLastComplianceDigestTime() (time.Time, error)
+
+	GetMileageLogsSince(year int, month int) ([]models.MileageLog, error)
+
+	GetMemberDependents(id int) (models.Dependents, error)
+	GetVehicleDependents(id int) (models.Dependents, error)
+	MergeMember(id int, intoID int, userID int) error
+	MergeVehicle(id int, intoID int, userID int) error
 }
@@ -0,0 +1,230 @@
+// Package statements renders monthly member statements as PDFs
+package statements
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/cxt314/drvc-go/internal/config"
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+// column is one column of the trip table
+type column struct {
+	title string
+	width float64
+	align string
+}
+
+var tripColumns = []column{
+	{"Date", 22, "L"},
+	{"Vehicle", 25, "L"},
+	{"Miles", 15, "R"},
+	{"Destination", 40, "L"},
+	{"Co-Riders", 38, "L"},
+	{"Type", 15, "L"},
+	{"Share", 20, "R"},
+	{"Fuel", 20, "R"},
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Filename returns the file name for a member's statement, e.g. statement-202609-12-jane-doe.pdf.
+// The member id keeps the names unique in the ZIP when members share a name
+func Filename(s models.MemberStatement) string {
+	name := strings.Trim(unsafeFilenameChars.ReplaceAllString(strings.ToLower(s.Member.Name), "-"), "-")
+
+	return fmt.Sprintf("statement-%04d%02d-%d-%s.pdf", s.Year, s.Month, s.Member.ID, name)
+}
+
+// WritePDF writes a member's statement for the month as a PDF
+func WritePDF(w io.Writer, s models.MemberStatement) error {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetTitle(fmt.Sprintf("DRVC Statement %04d-%02d %s", s.Year, s.Month, s.Member.Name), true)
+	pdf.SetMargins(10, 10, 10)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// core fonts only cover cp1252, so names and destinations are translated from UTF-8
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.AddPage()
+
+	monthName := time.Month(s.Month).String()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 8, "DRVC Monthly Statement", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, tr(s.Member.Name), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("%s %d", monthName, s.Year), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// trip table
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 7, "Trips", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(220, 220, 220)
+	for _, c := range tripColumns {
+		pdf.CellFormat(c.width, 6, c.title, "1", 0, c.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	if len(s.Lines) == 0 {
+		pdf.CellFormat(0, 6, "No trips this month", "1", 1, "L", false, 0, "")
+	}
+
+	for _, l := range s.Lines {
+		tripType := "Regular"
+		if l.LongDistance {
+			tripType = "LD"
+		}
+		if l.Exempt {
+			tripType += " (exempt)"
+		}
+
+		values := []string{
+			l.TripDate.Format(config.DateLayout),
+			l.Vehicle,
+			strconv.FormatFloat(l.Miles, 'f', -1, 64),
+			l.Destination,
+			strings.Join(l.CoRiders, ", "),
+			tripType,
+			l.Share.String(),
+			l.FuelSurcharge.String(),
+		}
+
+		for i, c := range tripColumns {
+			pdf.CellFormat(c.width, 6, fitText(pdf, tr(values[i]), c.width), "1", 0, c.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	// fuel the member paid for
+	if len(s.FuelPurchases) > 0 {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 7, "Fuel Purchases", "", 1, "L", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 9)
+		for _, f := range s.FuelPurchases {
+			pdf.CellFormat(22, 6, f.PurchaseDate.Format(config.DateLayout), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(25, 6, fitText(pdf, tr(f.Vehicle.Name), 25), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(30, 6, fmt.Sprintf("%s %s", strconv.FormatFloat(f.Quantity, 'f', -1, 64), f.Unit), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(20, 6, f.Amount.String(), "1", 1, "R", false, 0, "")
+		}
+		pdf.Ln(4)
+	}
+
+	// totals, taken from the member billings so they match the invoice
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 7, "Summary", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	totals := []struct {
+		label  string
+		amount models.USD
+	}{
+		{"Regular Trips", s.RegularTripsCost()},
+		{"Long Distance Trips", s.LongDistanceTripsCost()},
+		{"Fuel Surcharge", s.FuelSurchargeCost()},
+		{"Fuel Credit", -s.FuelCredit()},
+	}
+	for _, t := range totals {
+		pdf.CellFormat(60, 6, t.label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 6, t.amount.SignedString(), "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(60, 7, "Total Due", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, s.Total().SignedString(), "T", 1, "R", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+// WriteZIP writes a PDF statement for each member into a zip archive
+func WriteZIP(w io.Writer, statements []models.MemberStatement) error {
+	zw := zip.NewWriter(w)
+
+	for _, s := range statements {
+		f, err := zw.Create(Filename(s))
+		if err != nil {
+			return err
+		}
+
+		err = WritePDF(f, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// fitText shortens text with an ellipsis so it fits in a cell of the given width
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	// leave room for the cell's padding
+	maxWidth := width - 2
+
+	if pdf.GetStringWidth(text) <= maxWidth {
+		return text
+	}
+
+	// text has already been translated to a single byte code page, so it is safe to cut on any byte
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > maxWidth {
+		text = text[:len(text)-1]
+	}
+
+	return text + "..."
+}
+
+// EmailSubject returns the subject line for a member's statement email
+func EmailSubject(s models.MemberStatement) string {
+	return fmt.Sprintf("DRVC statement for %s %d", time.Month(s.Month), s.Year)
+}
+
+// EmailBody returns a plain text breakdown of a member's statement for the body of the statement email
+func EmailBody(s models.MemberStatement) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Hi %s,\n\n", s.Member.Name)
+	fmt.Fprintf(&b, "Here is your DRVC statement for %s %d. The full statement is attached as a PDF.\n\n", time.Month(s.Month), s.Year)
+
+	b.WriteString("Trips:\n")
+	if len(s.Lines) == 0 {
+		b.WriteString("  No trips this month\n")
+	}
+	for _, l := range s.Lines {
+		tripType := ""
+		if l.LongDistance {
+			tripType = " (long distance)"
+		}
+		if l.Exempt {
+			tripType += " (exempt)"
+		}
+
+		fmt.Fprintf(&b, "  %s  %s  %s mi  %s%s  %s\n", l.TripDate.Format(config.DateLayout), l.Vehicle,
+			strconv.FormatFloat(l.Miles, 'f', -1, 64), l.Destination, tripType, l.Share)
+	}
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Regular Trips:       %s\n", s.RegularTripsCost())
+	fmt.Fprintf(&b, "Long Distance Trips: %s\n", s.LongDistanceTripsCost())
+	fmt.Fprintf(&b, "Fuel Surcharge:      %s\n", s.FuelSurchargeCost())
+	fmt.Fprintf(&b, "Fuel Credit:         %s\n", (-s.FuelCredit()).SignedString())
+	fmt.Fprintf(&b, "Total Due:           %s\n", s.Total().SignedString())
+
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package statements
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cxt314/drvc-go/internal/models"
+)
+
+var testStatements = []models.MemberStatement{
+	{
+		Member: models.Member{ID: 1, Name: "Zoë O'Brien"},
+		Year:   2026,
+		Month:  9,
+		Lines: []models.StatementLine{
+			{TripDate: time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC), Vehicle: "Prius", Miles: 101,
+				Destination: "A very long destination name that will not fit in its column", CoRiders: []string{"Bob", "Carol"},
+				Share: 1111, FuelSurcharge: 34},
+		},
+		Billings: []models.MemberMileageLogBilling{{RegularTripsCost: 1111, FuelSurchargeCost: 34}},
+	},
+	{Member: models.Member{ID: 2, Name: "Bob"}, Year: 2026, Month: 9},
+}
+
+func TestFilename(t *testing.T) {
+	if got := Filename(testStatements[0]); got != "statement-202609-1-zo-o-brien.pdf" {
+		t.Errorf("unexpected filename %s", got)
+	}
+}
+
+func TestWriteZIP(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteZIP(&buf, testStatements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(zr.File) != len(testStatements) {
+		t.Fatalf("expected %d files but got %d", len(testStatements), len(zr.File))
+	}
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header := make([]byte, 5)
+		_, err = rc.Read(header)
+		rc.Close()
+		if err != nil || string(header) != "%PDF-" {
+			t.Errorf("%s is not a PDF", f.Name)
+		}
+	}
+}
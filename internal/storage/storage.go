@@ -0,0 +1,170 @@
+// Package storage keeps uploaded files. Files are content addressed: each one is stored under the
+// SHA-256 of its data, so uploading the same scan twice only keeps one copy
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// MaxSize is the largest file that can be stored
+const MaxSize = 10 << 20
+
+// ContentTypes lists the types of file that can be stored, with a short label for each
+var ContentTypes = map[string]string{
+	"image/jpeg":      "JPEG",
+	"image/png":       "PNG",
+	"image/gif":       "GIF",
+	"image/webp":      "WebP",
+	"application/pdf": "PDF",
+}
+
+var (
+	// ErrNotFound is returned when no file is stored under a key
+	ErrNotFound = errors.New("file not found")
+	// ErrTooLarge is returned for files larger than MaxSize
+	ErrTooLarge = fmt.Errorf("file is larger than %d MB", MaxSize>>20)
+	// ErrUnsupportedType is returned for files that aren't one of the ContentTypes
+	ErrUnsupportedType = errors.New("only images and PDFs can be uploaded")
+)
+
+// Store keeps files by key
+type Store interface {
+	// Put stores data and returns its key
+	Put(data []byte) (string, error)
+	// Open returns the file stored under key, or ErrNotFound
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key. Deleting a missing file is not an error
+	Delete(key string) error
+}
+
+// Check returns the content type of data, or an error if it is too large or not a supported type.
+// The type is sniffed from the data itself rather than trusted from the upload
+func Check(data []byte) (string, error) {
+	if len(data) > MaxSize {
+		return "", ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := ContentTypes[contentType]; !ok {
+		return "", ErrUnsupportedType
+	}
+
+	return contentType, nil
+}
+
+// Key returns the key data is stored under
+func Key(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var validKey = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// LocalStore keeps files in Dir. Each file is in a subdirectory named by the first two characters
+// of its key so no one directory gets too large
+type LocalStore struct {
+	Dir string
+}
+
+// NewLocalStore creates a store that keeps files in dir, creating it when the first file is stored
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{
+		Dir: dir,
+	}
+}
+
+// path returns where the file for key is kept, or ErrNotFound if key isn't a valid key
+func (s *LocalStore) path(key string) (string, error) {
+	if !validKey.MatchString(key) {
+		return "", ErrNotFound
+	}
+
+	return filepath.Join(s.Dir, key[:2], key), nil
+}
+
+// Put checks data and writes it to Dir if it isn't already there
+func (s *LocalStore) Put(data []byte) (string, error) {
+	_, err := Check(data)
+	if err != nil {
+		return "", err
+	}
+
+	key := Key(data)
+	p, _ := s.path(key)
+
+	if _, err := os.Stat(p); err == nil {
+		return key, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// write to a temporary file first so a partly written file is never left under the key
+	tmp, err := os.CreateTemp(filepath.Dir(p), key+".tmp*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, bytes.NewReader(data))
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(tmp.Name(), p)
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Open opens the file stored under key
+func (s *LocalStore) Open(key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Delete removes the file stored under key
+func (s *LocalStore) Delete(key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return nil
+	}
+
+	err = os.Remove(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
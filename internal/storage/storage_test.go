@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testPDF = []byte("%PDF-1.4\n1 0 obj << >> endobj\n%%EOF")
+
+func TestCheck(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		err  error
+	}{
+		{"pdf", testPDF, "application/pdf", nil},
+		{"png", png, "image/png", nil},
+		{"text", []byte("not a scan"), "", ErrUnsupportedType},
+		{"too large", append(append([]byte{}, testPDF...), make([]byte, MaxSize)...), "", ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		got, err := Check(tt.data)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected error %v but got %v", tt.name, tt.err, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected content type %q but got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStore(dir)
+
+	key, err := s.Put(testPDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key != Key(testPDF) {
+		t.Errorf("expected key %s but got %s", Key(testPDF), key)
+	}
+
+	// storing the same data again keeps one copy under the same key
+	again, err := s.Put(testPDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != key {
+		t.Errorf("expected the same key for the same data but got %s and %s", key, again)
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, key[:2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected one stored file but got %v", files)
+	}
+
+	f, err := s.Open(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, testPDF) {
+		t.Errorf("stored file does not match what was put")
+	}
+
+	err = s.Delete(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Open(key)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete but got %v", err)
+	}
+}
+
+func TestLocalStoreInvalidKey(t *testing.T) {
+	s := NewLocalStore(t.TempDir())
+
+	_, err := s.Open("../../etc/passwd")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an invalid key but got %v", err)
+	}
+
+	_, err = s.Put([]byte("plain text"))
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType but got %v", err)
+	}
+}